@@ -0,0 +1,156 @@
+package command
+
+//go:generate go run github.com/xtls/xray-core/common/errors/errorgen
+
+import (
+	"context"
+	"sort"
+
+	grpc "google.golang.org/grpc"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
+)
+
+// connectionServer is an implementation of ConnectionService.
+type connectionServer struct {
+	v *core.Instance
+}
+
+func NewConnectionServer(v *core.Instance) ConnectionServiceServer {
+	return &connectionServer{v: v}
+}
+
+func (s *connectionServer) manager() (routing.ConnectionManager, error) {
+	manager, ok := s.v.GetFeature(routing.DispatcherType()).(routing.ConnectionManager)
+	if !ok {
+		return nil, newError("connections are not tracked by the dispatcher")
+	}
+	return manager, nil
+}
+
+func (s *connectionServer) ListConnections(ctx context.Context, request *ListConnectionsRequest) (*ListConnectionsResponse, error) {
+	manager, err := s.manager()
+	if err != nil {
+		return nil, err
+	}
+
+	conns := manager.Connections()
+	sort.Slice(conns, func(i, j int) bool {
+		return conns[i].Start.Before(conns[j].Start)
+	})
+	response := &ListConnectionsResponse{}
+	for _, c := range conns {
+		if request.Email != "" && c.Email != request.Email {
+			continue
+		}
+		if request.InboundTag != "" && c.InboundTag != request.InboundTag {
+			continue
+		}
+		response.Connections = append(response.Connections, toConnection(c))
+	}
+	return response, nil
+}
+
+func (s *connectionServer) CloseConnection(ctx context.Context, request *CloseConnectionRequest) (*CloseConnectionResponse, error) {
+	if request.Id == 0 {
+		return nil, newError("connection id is not specified")
+	}
+	manager, err := s.manager()
+	if err != nil {
+		return nil, err
+	}
+	n := manager.CloseConnection(request.Id)
+	if n == 0 {
+		return nil, newError("connection ", request.Id, " not found")
+	}
+	return &CloseConnectionResponse{Closed: uint32(n)}, nil
+}
+
+func (s *connectionServer) CloseConnectionsByUser(ctx context.Context, request *CloseConnectionsByUserRequest) (*CloseConnectionsByUserResponse, error) {
+	if request.Email == "" {
+		return nil, newError("email is not specified")
+	}
+	manager, err := s.manager()
+	if err != nil {
+		return nil, err
+	}
+	return &CloseConnectionsByUserResponse{Closed: uint32(manager.CloseConnectionsByUser(request.Email))}, nil
+}
+
+func (s *connectionServer) WatchConnections(request *WatchConnectionsRequest, stream ConnectionService_WatchConnectionsServer) error {
+	var channel stats.Channel
+	if manager, ok := s.v.GetFeature(stats.ManagerType()).(stats.Manager); ok {
+		channel = manager.GetChannel(routing.ConnectionEventChannel)
+	}
+	if channel == nil {
+		return newError("connection events not enabled")
+	}
+
+	subscriber, err := stats.SubscribeRunnableChannel(channel)
+	if err != nil {
+		return err
+	}
+	defer stats.UnsubscribeClosableChannel(channel, subscriber)
+	for {
+		select {
+		case value, ok := <-subscriber:
+			if !ok {
+				return newError("Upstream closed the subscriber channel.")
+			}
+			event, ok := value.(*routing.ConnectionEvent)
+			if !ok {
+				return newError("Upstream sent malformed connection event.")
+			}
+			if err := stream.Send(&ConnectionEvent{
+				Timestamp:  event.Time.UnixNano() / 1e6,
+				Closed:     event.Closed,
+				Reason:     event.Reason,
+				Connection: toConnection(event.Connection),
+			}); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+func toConnection(c routing.Connection) *Connection {
+	conn := &Connection{
+		Id:          c.ID,
+		InboundTag:  c.InboundTag,
+		Email:       c.Email,
+		Domain:      c.Domain,
+		OutboundTag: c.OutboundTag,
+		Uplink:      c.Uplink,
+		Downlink:    c.Downlink,
+		StartTime:   c.Start.UnixNano() / 1e6,
+	}
+	if c.Source.IsValid() {
+		conn.Source = c.Source.NetAddr()
+	}
+	if c.Destination.IsValid() {
+		conn.Destination = c.Destination.String()
+	}
+	return conn
+}
+
+func (s *connectionServer) mustEmbedUnimplementedConnectionServiceServer() {}
+
+type service struct {
+	v *core.Instance
+}
+
+func (s *service) Register(server *grpc.Server) {
+	RegisterConnectionServiceServer(server, NewConnectionServer(s.v))
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, cfg interface{}) (interface{}, error) {
+		s := core.MustFromContext(ctx)
+		return &service{v: s}, nil
+	}))
+}
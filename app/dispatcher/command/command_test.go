@@ -0,0 +1,109 @@
+package command_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/app/dispatcher"
+	. "github.com/xtls/xray-core/app/dispatcher/command"
+	"github.com/xtls/xray-core/app/proxyman"
+	_ "github.com/xtls/xray-core/app/proxyman/outbound"
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/proxy/freedom"
+	"github.com/xtls/xray-core/testing/servers/tcp"
+	_ "github.com/xtls/xray-core/transport/internet/tcp"
+)
+
+func TestConnections(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: func(b []byte) []byte { return b },
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&stats.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				Tag:           "direct",
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	})
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	d := v.GetFeature(routing.DispatcherType()).(routing.Dispatcher)
+	for _, email := range []string{"a@example.com", "a@example.com", "b@example.com"} {
+		ctx := session.ContextWithID(context.Background(), session.NewID())
+		ctx = session.ContextWithInbound(ctx, &session.Inbound{
+			Tag:  "in",
+			User: &protocol.MemoryUser{Email: email},
+		})
+		common.Must2(d.Dispatch(ctx, dest))
+	}
+
+	s := NewConnectionServer(v)
+	list := func(email string) []*Connection {
+		resp, err := s.ListConnections(context.Background(), &ListConnectionsRequest{Email: email})
+		common.Must(err)
+		return resp.Connections
+	}
+
+	waitFor := func(cond func() bool) {
+		for i := 0; !cond(); i++ {
+			if i == 50 {
+				t.Fatal("timeout waiting for connections: ", list(""))
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	if conns := list(""); len(conns) != 3 {
+		t.Fatal("expect 3 connections, but got ", conns)
+	}
+	// Connections are routed asynchronously.
+	waitFor(func() bool {
+		for _, c := range list("") {
+			if c.OutboundTag == "" {
+				return false
+			}
+		}
+		return true
+	})
+	conns := list("b@example.com")
+	if len(conns) != 1 {
+		t.Fatal("expect 1 connection of b@example.com, but got ", conns)
+	}
+	if c := conns[0]; c.InboundTag != "in" || c.OutboundTag != "direct" || c.Destination != dest.String() {
+		t.Error("unexpected connection ", c)
+	}
+
+	resp, err := s.CloseConnectionsByUser(context.Background(), &CloseConnectionsByUserRequest{Email: "a@example.com"})
+	common.Must(err)
+	if resp.Closed != 2 {
+		t.Error("expect 2 connections closed, but got ", resp.Closed)
+	}
+	common.Must2(s.CloseConnection(context.Background(), &CloseConnectionRequest{Id: conns[0].Id}))
+	waitFor(func() bool { return len(list("")) == 0 })
+
+	if _, err := s.CloseConnection(context.Background(), &CloseConnectionRequest{Id: conns[0].Id}); err == nil {
+		t.Error("expect error closing a closed connection")
+	}
+	if _, err := s.CloseConnection(context.Background(), &CloseConnectionRequest{}); err == nil {
+		t.Error("expect error closing connection 0")
+	}
+}
@@ -0,0 +1,94 @@
+package dispatcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/xtls/xray-core/features/routing"
+)
+
+// addConnection tracks the link until it finishes.
+func (d *DefaultDispatcher) addConnection(r *linkRecord) {
+	r.onFinish = d.removeConnection
+
+	d.connAccess.Lock()
+	defer d.connAccess.Unlock()
+	if d.conns == nil {
+		d.conns = make(map[*linkRecord]struct{})
+	}
+	d.conns[r] = struct{}{}
+}
+
+func (d *DefaultDispatcher) removeConnection(r *linkRecord, reason string) {
+	d.connAccess.Lock()
+	delete(d.conns, r)
+	d.connAccess.Unlock()
+
+	d.publishConnection(r, true, reason)
+}
+
+// publishConnection publishes the event of the link to the connection event
+// channel, if subscribed.
+func (d *DefaultDispatcher) publishConnection(r *linkRecord, closed bool, reason string) {
+	if d.connEvents == nil || len(d.connEvents.Subscribers()) == 0 {
+		return
+	}
+	d.connEvents.Publish(context.Background(), &routing.ConnectionEvent{
+		Time:       time.Now(),
+		Closed:     closed,
+		Reason:     reason,
+		Connection: r.connection(),
+	})
+}
+
+func (d *DefaultDispatcher) records() []*linkRecord {
+	d.connAccess.Lock()
+	defer d.connAccess.Unlock()
+
+	records := make([]*linkRecord, 0, len(d.conns))
+	for r := range d.conns {
+		records = append(records, r)
+	}
+	return records
+}
+
+// Connections implements routing.ConnectionManager.
+func (d *DefaultDispatcher) Connections() []routing.Connection {
+	records := d.records()
+	conns := make([]routing.Connection, 0, len(records))
+	for _, r := range records {
+		conns = append(conns, r.connection())
+	}
+	return conns
+}
+
+// CloseConnection implements routing.ConnectionManager. Links without session
+// ID are never closed by ID.
+func (d *DefaultDispatcher) CloseConnection(id uint32) int {
+	if id == 0 {
+		return 0
+	}
+	n := 0
+	for _, r := range d.records() {
+		if r.id == id {
+			r.kill()
+			n++
+		}
+	}
+	return n
+}
+
+// CloseConnectionsByUser implements routing.ConnectionManager.
+func (d *DefaultDispatcher) CloseConnectionsByUser(email string) int {
+	if email == "" {
+		return 0
+	}
+	n := 0
+	for _, r := range d.records() {
+		if r.email == email {
+			r.kill()
+			n++
+		}
+	}
+	return n
+}
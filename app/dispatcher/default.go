@@ -6,8 +6,11 @@ import (
 	"context"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"golang.org/x/time/rate"
+
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/log"
@@ -48,6 +51,21 @@ func (r *cachedReader) Cache(b *buf.Buffer) {
 	r.Unlock()
 }
 
+// CachePacket is like Cache, but copies only the first buffer, which is a
+// single datagram of UDP connections.
+func (r *cachedReader) CachePacket(b *buf.Buffer) {
+	mb, _ := r.reader.ReadMultiBufferTimeout(time.Millisecond * 100)
+	r.Lock()
+	if !mb.IsEmpty() {
+		r.cache, _ = buf.MergeMulti(r.cache, mb)
+	}
+	b.Clear()
+	if !r.cache.IsEmpty() {
+		b.Write(r.cache[0].Bytes())
+	}
+	r.Unlock()
+}
+
 func (r *cachedReader) readInternal() buf.MultiBuffer {
 	r.Lock()
 	defer r.Unlock()
@@ -94,6 +112,16 @@ type DefaultDispatcher struct {
 	router routing.Router
 	policy policy.Manager
 	stats  stats.Manager
+
+	totalLinks  uint64
+	activeLinks int64
+
+	limiterAccess sync.Mutex
+	limiters      map[string]*rateLimiters
+
+	connAccess sync.Mutex
+	conns      map[*linkRecord]struct{}
+	connEvents stats.Channel
 }
 
 func init() {
@@ -114,6 +142,7 @@ func (d *DefaultDispatcher) Init(config *Config, om outbound.Manager, router rou
 	d.router = router
 	d.policy = pm
 	d.stats = sm
+	d.connEvents, _ = stats.GetOrRegisterChannel(sm, routing.ConnectionEventChannel)
 	return nil
 }
 
@@ -130,7 +159,24 @@ func (*DefaultDispatcher) Start() error {
 // Close implements common.Closable.
 func (*DefaultDispatcher) Close() error { return nil }
 
-func (d *DefaultDispatcher) getLink(ctx context.Context) (*transport.Link, *transport.Link) {
+// Metrics implements stats.MetricsProvider.
+func (d *DefaultDispatcher) Metrics() []stats.Metric {
+	return []stats.Metric{
+		{
+			Name:  "xray_dispatcher_links_total",
+			Help:  "Number of links dispatched to outbound handlers.",
+			Value: float64(atomic.LoadUint64(&d.totalLinks)),
+		},
+		{
+			Name:  "xray_dispatcher_active_links",
+			Help:  "Number of links being handled by outbound handlers.",
+			Value: float64(atomic.LoadInt64(&d.activeLinks)),
+			Gauge: true,
+		},
+	}
+}
+
+func (d *DefaultDispatcher) getLink(ctx context.Context) (*transport.Link, *transport.Link, *linkRecord) {
 	opt := pipe.OptionsFromContext(ctx)
 	uplinkReader, uplinkWriter := pipe.New(opt...)
 	downlinkReader, downlinkWriter := pipe.New(opt...)
@@ -151,8 +197,38 @@ func (d *DefaultDispatcher) getLink(ctx context.Context) (*transport.Link, *tran
 		user = sessionInbound.User
 	}
 
+	var record *linkRecord
+	if user != nil {
+		record = newLinkRecord(ctx, policy.ForUser(d.policy, user.Level, user.Email).Timeouts.ConnectionIdle)
+	} else {
+		record = newLinkRecord(ctx, d.policy.ForLevel(0).Timeouts.ConnectionIdle)
+	}
+	record.pipes = []*pipe.Writer{uplinkWriter, downlinkWriter}
+	d.addConnection(record)
+	inboundLink.Writer = &SizeStatWriter{
+		Counter: &record.uplink,
+		Writer:  inboundLink.Writer,
+	}
+	outboundLink.Writer = &SizeStatWriter{
+		Counter: &record.downlink,
+		Writer:  outboundLink.Writer,
+	}
+
+	var uplinkLimiters, downlinkLimiters []*rate.Limiter
+	if sessionInbound != nil && sessionInbound.Tag != "" {
+		if r, ok := d.policy.ForSystem().InboundRate[sessionInbound.Tag]; ok {
+			up, down := d.getLimiters("inbound>>>"+sessionInbound.Tag, r)
+			uplinkLimiters = appendLimiter(uplinkLimiters, up)
+			downlinkLimiters = appendLimiter(downlinkLimiters, down)
+		}
+	}
+
 	if user != nil && len(user.Email) > 0 {
-		p := d.policy.ForLevel(user.Level)
+		p := policy.ForUser(d.policy, user.Level, user.Email)
+		up, down := d.getLimiters("user>>>"+user.Email, p.Rate)
+		uplinkLimiters = appendLimiter(uplinkLimiters, up)
+		downlinkLimiters = appendLimiter(downlinkLimiters, down)
+
 		if p.Stats.UserUplink {
 			name := "user>>>" + user.Email + ">>>traffic>>>uplink"
 			if c, _ := stats.GetOrRegisterCounter(d.stats, name); c != nil {
@@ -173,7 +249,51 @@ func (d *DefaultDispatcher) getLink(ctx context.Context) (*transport.Link, *tran
 		}
 	}
 
-	return inboundLink, outboundLink
+	if len(uplinkLimiters) > 0 {
+		inboundLink.Writer = &RateLimitWriter{
+			Context:  ctx,
+			Limiters: uplinkLimiters,
+			Writer:   inboundLink.Writer,
+		}
+	}
+	if len(downlinkLimiters) > 0 {
+		outboundLink.Writer = &RateLimitWriter{
+			Context:  ctx,
+			Limiters: downlinkLimiters,
+			Writer:   outboundLink.Writer,
+		}
+	}
+
+	outboundLink.Writer = &recordWriter{
+		Writer: outboundLink.Writer,
+		record: record,
+	}
+
+	return inboundLink, outboundLink, record
+}
+
+// isRouteOnly returns whether the domain sniffed from the protocol is used for
+// routing only. The domain of a DNS query is the name being resolved, not the
+// name server it is sent to.
+func isRouteOnly(protocol string) bool {
+	return protocol == "dns"
+}
+
+// setRouteTarget sets the sniffed domain as the route target of the outbound,
+// if the result is for routing only.
+func setRouteTarget(ctx context.Context, ob *session.Outbound, result SniffResult, request session.SniffingRequest) {
+	domain := result.Domain()
+	if domain == "" || !isRouteOnly(result.Protocol()) {
+		return
+	}
+	for _, d := range request.ExcludeForDomain {
+		if strings.ToLower(domain) == d {
+			return
+		}
+	}
+	newError("sniffed domain for routing: ", domain).WriteToLog(session.ExportIDToError(ctx))
+	ob.RouteTarget = ob.Target
+	ob.RouteTarget.Address = net.ParseAddress(domain)
 }
 
 func shouldOverride(ctx context.Context, result SniffResult, request session.SniffingRequest, destination net.Destination) bool {
@@ -191,12 +311,14 @@ func shouldOverride(ctx context.Context, result SniffResult, request session.Sni
 	if resComp, ok := result.(SnifferResultComposite); ok {
 		protocolString = resComp.ProtocolForDomainResult()
 	}
+	if isRouteOnly(protocolString) {
+		return false
+	}
 	for _, p := range request.OverrideDestinationForProtocol {
 		if strings.HasPrefix(protocolString, p) {
 			return true
 		}
-		if fakeDNSEngine != nil && protocolString != "bittorrent" && p == "fakedns" &&
-			destination.Address.Family().IsIP() && fakeDNSEngine.GetFakeIPRange().Contains(destination.Address.IP()) {
+		if fakeDNSEngine != nil && protocolString != "bittorrent" && p == "fakedns" && isFakeIP(fakeDNSEngine, destination.Address) {
 			newError("Using sniffer ", protocolString, " since the fake DNS missed").WriteToLog(session.ExportIDToError(ctx))
 			return true
 		}
@@ -215,7 +337,7 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 	}
 	ctx = session.ContextWithOutbound(ctx, ob)
 
-	inbound, outbound := d.getLink(ctx)
+	inbound, outbound, record := d.getLink(ctx)
 	content := session.ContentFromContext(ctx)
 	if content == nil {
 		content = new(session.Content)
@@ -224,20 +346,23 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 	sniffingRequest := content.SniffingRequest
 	switch {
 	case !sniffingRequest.Enabled:
-		go d.routedDispatch(ctx, outbound, destination)
-	case destination.Network != net.Network_TCP:
-		// Only metadata sniff will be used for non tcp connection
+		go d.routedDispatch(ctx, outbound, record, destination)
+	case destination.Network != net.Network_TCP && destination.Network != net.Network_UDP:
+		// Only metadata sniff will be used for connections other than tcp and udp
 		result, err := sniffer(ctx, nil, true)
 		if err == nil {
 			content.Protocol = result.Protocol()
+			recordSniffedDomain(ctx, record, result.Domain())
 			if shouldOverride(ctx, result, sniffingRequest, destination) {
 				domain := result.Domain()
 				newError("sniffed domain: ", domain).WriteToLog(session.ExportIDToError(ctx))
 				destination.Address = net.ParseAddress(domain)
 				ob.Target = destination
+			} else {
+				setRouteTarget(ctx, ob, result, sniffingRequest)
 			}
 		}
-		go d.routedDispatch(ctx, outbound, destination)
+		go d.routedDispatch(ctx, outbound, record, destination)
 	default:
 		go func() {
 			cReader := &cachedReader{
@@ -247,19 +372,39 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 			result, err := sniffer(ctx, cReader, sniffingRequest.MetadataOnly)
 			if err == nil {
 				content.Protocol = result.Protocol()
+				recordSniffedDomain(ctx, record, result.Domain())
+				if r, ok := result.(SniffResultWithAttributes); ok {
+					for name, value := range r.Attributes() {
+						content.SetAttribute(name, value)
+					}
+				}
 			}
 			if err == nil && shouldOverride(ctx, result, sniffingRequest, destination) {
 				domain := result.Domain()
 				newError("sniffed domain: ", domain).WriteToLog(session.ExportIDToError(ctx))
 				destination.Address = net.ParseAddress(domain)
 				ob.Target = destination
+			} else if err == nil {
+				setRouteTarget(ctx, ob, result, sniffingRequest)
 			}
-			d.routedDispatch(ctx, outbound, destination)
+			d.routedDispatch(ctx, outbound, record, destination)
 		}()
 	}
 	return inbound, nil
 }
 
+// recordSniffedDomain sets the sniffed domain in the record of the link and
+// the access message of the context.
+func recordSniffedDomain(ctx context.Context, record *linkRecord, domain string) {
+	if domain == "" {
+		return
+	}
+	record.setDomain(domain)
+	if accessMessage := log.AccessMessageFromContext(ctx); accessMessage != nil {
+		accessMessage.Domain = domain
+	}
+}
+
 func sniffer(ctx context.Context, cReader *cachedReader, metadataOnly bool) (SniffResult, error) {
 	payload := buf.New()
 	defer payload.Release()
@@ -273,6 +418,20 @@ func sniffer(ctx context.Context, cReader *cachedReader, metadataOnly bool) (Sni
 	}
 
 	contentResult, contentErr := func() (SniffResult, error) {
+		if outbound := session.OutboundFromContext(ctx); outbound != nil && outbound.Target.Network == net.Network_UDP {
+			// Only the first datagram is sniffed, so that unknown flows are
+			// not delayed by waiting for more.
+			cReader.CachePacket(payload)
+			if payload.IsEmpty() {
+				return nil, errSniffingTimeout
+			}
+			result, err := sniffer.Sniff(ctx, payload.Bytes())
+			if err == common.ErrNoClue {
+				return nil, errUnknownContent
+			}
+			return result, err
+		}
+
 		totalAttempt := 0
 		for {
 			select {
@@ -306,7 +465,7 @@ func sniffer(ctx context.Context, cReader *cachedReader, metadataOnly bool) (Sni
 	return contentResult, contentErr
 }
 
-func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.Link, destination net.Destination) {
+func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.Link, record *linkRecord, destination net.Destination) {
 	var handler outbound.Handler
 
 	skipRoutePick := false
@@ -338,12 +497,18 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 
 	if handler == nil {
 		newError("default outbound handler not exist").WriteToLog(session.ExportIDToError(ctx))
+		record.finish(true)
 		common.Close(link.Writer)
 		common.Interrupt(link.Reader)
 		return
 	}
 
+	record.setRoute(destination, handler.Tag())
+	d.publishConnection(record, false, "")
 	if accessMessage := log.AccessMessageFromContext(ctx); accessMessage != nil {
+		accessMessage.SessionID = uint32(session.IDFromContext(ctx))
+		accessMessage.InboundTag = inTag
+		accessMessage.OutboundTag = handler.Tag()
 		if tag := handler.Tag(); tag != "" {
 			if isPickRoute {
 				if inTag != "" {
@@ -362,5 +527,28 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 		log.Record(accessMessage)
 	}
 
+	if tag := handler.Tag(); tag != "" {
+		if r, ok := d.policy.ForSystem().OutboundRate[tag]; ok {
+			up, down := d.getLimiters("outbound>>>"+tag, r)
+			if up != nil {
+				link.Reader = &RateLimitReader{
+					Context:  ctx,
+					Limiters: []*rate.Limiter{up},
+					Reader:   link.Reader,
+				}
+			}
+			if down != nil {
+				link.Writer = &RateLimitWriter{
+					Context:  ctx,
+					Limiters: []*rate.Limiter{down},
+					Writer:   link.Writer,
+				}
+			}
+		}
+	}
+
+	atomic.AddUint64(&d.totalLinks, 1)
+	atomic.AddInt64(&d.activeLinks, 1)
+	defer atomic.AddInt64(&d.activeLinks, -1)
 	handler.Dispatch(ctx, link)
 }
@@ -0,0 +1,141 @@
+package dispatcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/outbound"
+	"github.com/xtls/xray-core/features/policy"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
+	"github.com/xtls/xray-core/transport"
+)
+
+// routeRecorder is a router recording the target domains of routing
+// contexts. It picks no route.
+type routeRecorder struct {
+	domains chan string
+}
+
+func (*routeRecorder) Type() interface{} {
+	return routing.RouterType()
+}
+
+func (*routeRecorder) Start() error {
+	return nil
+}
+
+func (*routeRecorder) Close() error {
+	return nil
+}
+
+func (r *routeRecorder) PickRoute(ctx routing.Context) (routing.Route, error) {
+	r.domains <- ctx.GetTargetDomain()
+	return nil, newError("no route")
+}
+
+// targetRecorder is the default outbound handler, recording the targets of
+// dispatched links.
+type targetRecorder struct {
+	targets chan net.Destination
+}
+
+func (*targetRecorder) Type() interface{} {
+	return outbound.ManagerType()
+}
+
+func (*targetRecorder) Start() error {
+	return nil
+}
+
+func (*targetRecorder) Close() error {
+	return nil
+}
+
+func (*targetRecorder) Tag() string {
+	return "out"
+}
+
+func (h *targetRecorder) Dispatch(ctx context.Context, link *transport.Link) {
+	h.targets <- session.OutboundFromContext(ctx).Target
+	common.Interrupt(link.Reader)
+	common.Close(link.Writer)
+}
+
+func (*targetRecorder) GetHandler(string) outbound.Handler {
+	return nil
+}
+
+func (h *targetRecorder) GetDefaultHandler() outbound.Handler {
+	return h
+}
+
+func (*targetRecorder) AddHandler(context.Context, outbound.Handler) error {
+	return nil
+}
+
+func (*targetRecorder) RemoveHandler(context.Context, string) error {
+	return nil
+}
+
+func TestDispatchDNSRouteOnly(t *testing.T) {
+	instance, err := core.New(&core.Config{})
+	common.Must(err)
+	router := &routeRecorder{domains: make(chan string, 1)}
+	handler := &targetRecorder{targets: make(chan net.Destination, 1)}
+	d := &DefaultDispatcher{
+		ohm:    handler,
+		router: router,
+		policy: policy.DefaultManager{},
+		stats:  stats.NoopManager{},
+	}
+
+	ctx := context.WithValue(context.Background(), core.XrayKey(1), instance)
+	ctx = session.ContextWithContent(ctx, &session.Content{
+		SniffingRequest: session.SniffingRequest{
+			Enabled:                        true,
+			OverrideDestinationForProtocol: []string{"dns"},
+		},
+	})
+	dest := net.UDPDestination(net.ParseAddress("8.8.8.8"), 53)
+	link, err := d.Dispatch(ctx, dest)
+	common.Must(err)
+
+	query, err := (&dnsmessage.Message{
+		Header: dnsmessage.Header{ID: 1, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName("example.com."),
+			Type:  dnsmessage.TypeA,
+			Class: dnsmessage.ClassINET,
+		}},
+	}).Pack()
+	common.Must(err)
+	b := buf.New()
+	b.Write(query)
+	common.Must(link.Writer.WriteMultiBuffer(buf.MultiBuffer{b}))
+
+	select {
+	case domain := <-router.domains:
+		if domain != "example.com" {
+			t.Error("expect the queried domain for routing, but got ", domain)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout waiting for routing")
+	}
+	select {
+	case target := <-handler.targets:
+		if target != dest {
+			t.Error("expect the DNS server as the destination, but got ", target)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout waiting for dispatching")
+	}
+}
@@ -47,3 +47,14 @@ func (fakeDNSSniffResult) Protocol() string {
 func (f fakeDNSSniffResult) Domain() string {
 	return f.domainName
 }
+
+// isFakeIP returns true if addr is an IP in the pools of the fake DNS engine.
+func isFakeIP(engine dns.FakeDNSEngine, addr net.Address) bool {
+	if !addr.Family().IsIP() {
+		return false
+	}
+	if fkr0, ok := engine.(dns.FakeDNSEngineRev0); ok {
+		return fkr0.IsIPInIPPool(addr)
+	}
+	return engine.GetFakeIPRange().Contains(addr.IP())
+}
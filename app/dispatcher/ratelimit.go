@@ -0,0 +1,151 @@
+package dispatcher
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/features/policy"
+)
+
+// newLimiter returns a token bucket limiter of the rate in bytes per second, or
+// nil for unlimited.
+func newLimiter(bytesPerSecond uint64, burst uint64) *rate.Limiter {
+	if bytesPerSecond == 0 {
+		return nil
+	}
+	if burst == 0 {
+		burst = bytesPerSecond
+	}
+	if burst < buf.Size {
+		burst = buf.Size
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), int(burst))
+}
+
+// getLimiters returns the shared limiters of the name for the uplink and
+// downlink of the rate. Limiters are created on first use.
+func (d *DefaultDispatcher) getLimiters(name string, r policy.Rate) (uplink *rate.Limiter, downlink *rate.Limiter) {
+	if r.Uplink == 0 && r.Downlink == 0 {
+		return nil, nil
+	}
+
+	d.limiterAccess.Lock()
+	defer d.limiterAccess.Unlock()
+
+	if d.limiters == nil {
+		d.limiters = make(map[string]*rateLimiters)
+	}
+	l, found := d.limiters[name]
+	if !found || l.rate != r {
+		l = &rateLimiters{
+			rate:     r,
+			uplink:   newLimiter(r.Uplink, r.Burst),
+			downlink: newLimiter(r.Downlink, r.Burst),
+		}
+		d.limiters[name] = l
+	}
+	return l.uplink, l.downlink
+}
+
+type rateLimiters struct {
+	rate     policy.Rate
+	uplink   *rate.Limiter
+	downlink *rate.Limiter
+}
+
+// waitLimiters blocks until n bytes are allowed by all limiters.
+func waitLimiters(ctx context.Context, limiters []*rate.Limiter, n int) error {
+	for _, l := range limiters {
+		for remaining := n; remaining > 0; {
+			size := remaining
+			if b := l.Burst(); size > b {
+				size = b
+			}
+			if err := l.WaitN(ctx, size); err != nil {
+				return err
+			}
+			remaining -= size
+		}
+	}
+	return nil
+}
+
+func appendLimiter(limiters []*rate.Limiter, l *rate.Limiter) []*rate.Limiter {
+	if l == nil {
+		return limiters
+	}
+	return append(limiters, l)
+}
+
+// RateLimitWriter is a buf.Writer limiting its throughput by a set of
+// limiters.
+type RateLimitWriter struct {
+	Context  context.Context
+	Limiters []*rate.Limiter
+	Writer   buf.Writer
+}
+
+func (w *RateLimitWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	if err := waitLimiters(w.Context, w.Limiters, int(mb.Len())); err != nil {
+		buf.ReleaseMulti(mb)
+		return err
+	}
+	return w.Writer.WriteMultiBuffer(mb)
+}
+
+func (w *RateLimitWriter) Close() error {
+	return common.Close(w.Writer)
+}
+
+func (w *RateLimitWriter) Interrupt() {
+	common.Interrupt(w.Writer)
+}
+
+// RateLimitReader is a buf.Reader limiting its throughput by a set of
+// limiters.
+type RateLimitReader struct {
+	Context  context.Context
+	Limiters []*rate.Limiter
+	Reader   buf.Reader
+}
+
+func (r *RateLimitReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	mb, err := r.Reader.ReadMultiBuffer()
+	if err != nil {
+		return mb, err
+	}
+	return r.wait(mb)
+}
+
+// ReadMultiBufferTimeout implements buf.TimeoutReader.
+func (r *RateLimitReader) ReadMultiBufferTimeout(timeout time.Duration) (buf.MultiBuffer, error) {
+	tr, ok := r.Reader.(buf.TimeoutReader)
+	if !ok {
+		return r.ReadMultiBuffer()
+	}
+	mb, err := tr.ReadMultiBufferTimeout(timeout)
+	if err != nil {
+		return mb, err
+	}
+	return r.wait(mb)
+}
+
+func (r *RateLimitReader) wait(mb buf.MultiBuffer) (buf.MultiBuffer, error) {
+	if err := waitLimiters(r.Context, r.Limiters, int(mb.Len())); err != nil {
+		buf.ReleaseMulti(mb)
+		return nil, err
+	}
+	return mb, nil
+}
+
+func (r *RateLimitReader) Close() error {
+	return common.Close(r.Reader)
+}
+
+func (r *RateLimitReader) Interrupt() {
+	common.Interrupt(r.Reader)
+}
@@ -0,0 +1,60 @@
+package dispatcher_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	. "github.com/xtls/xray-core/app/dispatcher"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+)
+
+func TestRateLimitWriter(t *testing.T) {
+	// 64 KB per second with 16 KB burst, shared by two writers.
+	limiter := rate.NewLimiter(64*1024, 16*1024)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			writer := &RateLimitWriter{
+				Context:  context.Background(),
+				Limiters: []*rate.Limiter{limiter},
+				Writer:   buf.Discard,
+			}
+			for j := 0; j < 4; j++ {
+				b := buf.New()
+				b.Extend(buf.Size)
+				common.Must(writer.WriteMultiBuffer(buf.MultiBuffer{b}))
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 64 KB in total, 16 KB of which is the burst.
+	if elapsed := time.Since(start); elapsed < time.Millisecond*600 || elapsed > time.Second*2 {
+		t.Error("unexpected time of writes: ", elapsed)
+	}
+}
+
+func TestRateLimitReaderCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	b := buf.New()
+	b.Extend(buf.Size)
+	reader := &RateLimitReader{
+		Context:  ctx,
+		Limiters: []*rate.Limiter{rate.NewLimiter(1024, 1024)},
+		Reader:   &buf.SingleReader{Reader: b},
+	}
+	if _, err := reader.ReadMultiBuffer(); err == nil {
+		t.Error("expect error on canceled context")
+	}
+}
@@ -0,0 +1,193 @@
+package dispatcher
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport/pipe"
+)
+
+// Reasons of closed links in the access log.
+const (
+	closeReasonEOF     = "eof"
+	closeReasonTimeout = "timeout"
+	closeReasonError   = "error"
+	closeReasonKilled  = "killed"
+)
+
+// linkCounter is a counter of bytes of a link. Updates are recorded as
+// activities of the link.
+type linkCounter struct {
+	value  int64
+	record *linkRecord
+}
+
+func (c *linkCounter) Value() int64 {
+	return atomic.LoadInt64(&c.value)
+}
+
+func (c *linkCounter) Set(newValue int64) int64 {
+	return atomic.SwapInt64(&c.value, newValue)
+}
+
+func (c *linkCounter) Add(delta int64) int64 {
+	atomic.StoreInt64(&c.record.lastActive, time.Now().UnixNano())
+	return atomic.AddInt64(&c.value, delta)
+}
+
+// linkRecord is the accounting of a dispatched link. It is written to the
+// access log when the outbound finishes the link.
+type linkRecord struct {
+	ctx   context.Context
+	id    uint32
+	start time.Time
+	// idle is the idle timeout of the link, to tell timeouts from errors.
+	idle       time.Duration
+	lastActive int64
+	uplink     linkCounter
+	downlink   linkCounter
+	killed     int32
+	once       sync.Once
+	// pipes are interrupted to kill the link.
+	pipes []*pipe.Writer
+	// onFinish is called with the close reason when the link finishes.
+	onFinish func(r *linkRecord, reason string)
+
+	access      sync.Mutex
+	inboundTag  string
+	email       string
+	source      net.Destination
+	destination net.Destination
+	domain      string
+	outboundTag string
+}
+
+func newLinkRecord(ctx context.Context, idle time.Duration) *linkRecord {
+	now := time.Now()
+	r := &linkRecord{
+		ctx:        ctx,
+		id:         uint32(session.IDFromContext(ctx)),
+		start:      now,
+		idle:       idle,
+		lastActive: now.UnixNano(),
+	}
+	r.uplink.record = r
+	r.downlink.record = r
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		r.inboundTag = inbound.Tag
+		r.source = inbound.Source
+		if inbound.User != nil {
+			r.email = inbound.User.Email
+		}
+	}
+	return r
+}
+
+func (r *linkRecord) setDomain(domain string) {
+	r.access.Lock()
+	r.domain = domain
+	r.access.Unlock()
+}
+
+func (r *linkRecord) setRoute(destination net.Destination, outboundTag string) {
+	r.access.Lock()
+	r.destination = destination
+	r.outboundTag = outboundTag
+	r.access.Unlock()
+}
+
+func (r *linkRecord) getOutbound() string {
+	r.access.Lock()
+	defer r.access.Unlock()
+	return r.outboundTag
+}
+
+// connection returns the current state of the link.
+func (r *linkRecord) connection() routing.Connection {
+	r.access.Lock()
+	defer r.access.Unlock()
+	return routing.Connection{
+		ID:          r.id,
+		InboundTag:  r.inboundTag,
+		Email:       r.email,
+		Source:      r.source,
+		Destination: r.destination,
+		Domain:      r.domain,
+		OutboundTag: r.outboundTag,
+		Uplink:      r.uplink.Value(),
+		Downlink:    r.downlink.Value(),
+		Start:       r.start,
+	}
+}
+
+// kill marks the link to be closed by policy, and interrupts it.
+func (r *linkRecord) kill() {
+	atomic.StoreInt32(&r.killed, 1)
+	for _, p := range r.pipes {
+		p.Interrupt()
+	}
+}
+
+func (r *linkRecord) closeReason(interrupted bool, now time.Time) string {
+	switch {
+	case atomic.LoadInt32(&r.killed) == 1:
+		return closeReasonKilled
+	case !interrupted:
+		return closeReasonEOF
+	case r.idle > 0 && now.Sub(time.Unix(0, atomic.LoadInt64(&r.lastActive))) >= r.idle:
+		return closeReasonTimeout
+	default:
+		return closeReasonError
+	}
+}
+
+// finish reports the completion of the link to onFinish and the access log,
+// once.
+func (r *linkRecord) finish(interrupted bool) {
+	r.once.Do(func() {
+		now := time.Now()
+		reason := r.closeReason(interrupted, now)
+		if r.onFinish != nil {
+			r.onFinish(r, reason)
+		}
+
+		accessMessage := log.AccessMessageFromContext(r.ctx)
+		if accessMessage == nil {
+			return
+		}
+		msg := *accessMessage
+		msg.Status = log.AccessClosed
+		msg.Reason = reason
+		msg.OutboundTag = r.getOutbound()
+		msg.Uplink = r.uplink.Value()
+		msg.Downlink = r.downlink.Value()
+		msg.Duration = now.Sub(r.start)
+		log.Record(&msg)
+	})
+}
+
+// recordWriter is the downlink writer of the outbound, finishing the record
+// when the outbound closes or interrupts it.
+type recordWriter struct {
+	buf.Writer
+	record *linkRecord
+}
+
+func (w *recordWriter) Close() error {
+	err := common.Close(w.Writer)
+	w.record.finish(false)
+	return err
+}
+
+func (w *recordWriter) Interrupt() {
+	common.Interrupt(w.Writer)
+	w.record.finish(true)
+}
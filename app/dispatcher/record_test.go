@@ -0,0 +1,155 @@
+package dispatcher
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/pipe"
+)
+
+type accessRecorder struct {
+	sync.Mutex
+	messages []*log.AccessMessage
+}
+
+func (r *accessRecorder) Handle(msg log.Message) {
+	if m, ok := msg.(*log.AccessMessage); ok {
+		r.Lock()
+		r.messages = append(r.messages, m)
+		r.Unlock()
+	}
+}
+
+func (r *accessRecorder) records() []*log.AccessMessage {
+	r.Lock()
+	defer r.Unlock()
+	return append([]*log.AccessMessage(nil), r.messages...)
+}
+
+func recordLink(t *testing.T, idle time.Duration) (*linkRecord, *accessRecorder, buf.Writer, buf.Writer) {
+	recorder := &accessRecorder{}
+	previous := log.ReplaceHandler(recorder)
+	t.Cleanup(func() {
+		log.ReplaceHandler(previous)
+	})
+
+	ctx := log.ContextWithAccessMessage(context.Background(), &log.AccessMessage{
+		From:   "from",
+		To:     "to",
+		Status: log.AccessAccepted,
+		Email:  "test@example.com",
+	})
+	record := newLinkRecord(ctx, idle)
+	record.setRoute(net.TCPDestination(net.DomainAddress("example.com"), 443), "out")
+
+	_, uplink := pipe.New(pipe.WithoutSizeLimit())
+	_, downlink := pipe.New(pipe.WithoutSizeLimit())
+	return record, recorder, &SizeStatWriter{Counter: &record.uplink, Writer: uplink}, &recordWriter{
+		Writer: &SizeStatWriter{Counter: &record.downlink, Writer: downlink},
+		record: record,
+	}
+}
+
+func writeBytes(w buf.Writer, n int32) {
+	b := buf.New()
+	b.Extend(n)
+	common.Must(w.WriteMultiBuffer(buf.MultiBuffer{b}))
+}
+
+func TestLinkRecordClosed(t *testing.T) {
+	_, recorder, uplink, downlink := recordLink(t, time.Minute)
+	writeBytes(uplink, 100)
+	writeBytes(downlink, 200)
+	common.Must(common.Close(downlink))
+	common.Interrupt(downlink)
+
+	records := recorder.records()
+	if len(records) != 1 {
+		t.Fatal("expect 1 completion record, but got ", len(records))
+	}
+	msg := records[0]
+	if msg.Status != log.AccessClosed || msg.Reason != closeReasonEOF || msg.OutboundTag != "out" ||
+		msg.Uplink != 100 || msg.Downlink != 200 || msg.Email != "test@example.com" || msg.Duration <= 0 {
+		t.Error("unexpected completion record: ", msg)
+	}
+}
+
+func TestLinkRecordReasons(t *testing.T) {
+	_, recorder, _, downlink := recordLink(t, time.Minute)
+	common.Interrupt(downlink)
+	if reason := recorder.records()[0].Reason; reason != closeReasonError {
+		t.Error("expect error, but got ", reason)
+	}
+
+	_, recorder, _, downlink = recordLink(t, time.Millisecond)
+	time.Sleep(time.Millisecond * 10)
+	common.Interrupt(downlink)
+	if reason := recorder.records()[0].Reason; reason != closeReasonTimeout {
+		t.Error("expect timeout, but got ", reason)
+	}
+
+	record, recorder, _, downlink := recordLink(t, time.Millisecond)
+	record.kill()
+	common.Interrupt(downlink)
+	if reason := recorder.records()[0].Reason; reason != closeReasonKilled {
+		t.Error("expect killed, but got ", reason)
+	}
+}
+
+func TestConnections(t *testing.T) {
+	d := &DefaultDispatcher{}
+	user := &protocol.MemoryUser{Email: "test@example.com"}
+	ctx := session.ContextWithID(context.Background(), 42)
+	ctx = session.ContextWithInbound(ctx, &session.Inbound{Tag: "in", User: user})
+
+	var links []*transport.Link
+	for i := 0; i < 2; i++ {
+		uplinkReader, uplinkWriter := pipe.New()
+		_, downlinkWriter := pipe.New()
+		record := newLinkRecord(ctx, time.Minute)
+		record.pipes = []*pipe.Writer{uplinkWriter, downlinkWriter}
+		d.addConnection(record)
+		record.setRoute(net.TCPDestination(net.DomainAddress("example.com"), 443), "out")
+		links = append(links, &transport.Link{
+			Reader: uplinkReader,
+			Writer: &recordWriter{Writer: downlinkWriter, record: record},
+		})
+	}
+
+	conns := d.Connections()
+	if len(conns) != 2 || conns[0].ID != 42 || conns[0].Email != user.Email || conns[0].InboundTag != "in" || conns[0].OutboundTag != "out" {
+		t.Fatal("unexpected connections: ", conns)
+	}
+
+	if n := d.CloseConnectionsByUser("other@example.com"); n != 0 {
+		t.Error("expect no connections of other users closed, but got ", n)
+	}
+	if n := d.CloseConnection(42); n != 2 {
+		t.Error("expect 2 connections closed, but got ", n)
+	}
+	for _, link := range links {
+		// The outbound fails to read the killed link, and interrupts it.
+		if _, err := link.Reader.ReadMultiBuffer(); err == nil {
+			t.Error("expect link to be interrupted")
+		}
+		common.Interrupt(link.Writer)
+	}
+	if conns := d.Connections(); len(conns) != 0 {
+		t.Error("expect no connections, but got ", conns)
+	}
+
+	// Links without session ID can't be closed by ID.
+	d.addConnection(newLinkRecord(context.Background(), time.Minute))
+	if n := d.CloseConnection(0); n != 0 {
+		t.Error("expect no connections closed by ID 0, but got ", n)
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 
 	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol/bittorrent"
+	"github.com/xtls/xray-core/common/protocol/dns"
 	"github.com/xtls/xray-core/common/protocol/http"
+	"github.com/xtls/xray-core/common/protocol/quic"
 	"github.com/xtls/xray-core/common/protocol/tls"
+	"github.com/xtls/xray-core/common/session"
 )
 
 type SniffResult interface {
@@ -14,6 +18,12 @@ type SniffResult interface {
 	Domain() string
 }
 
+// SniffResultWithAttributes is a SniffResult carrying extra metadata of the
+// content, which are set as content attributes for routing.
+type SniffResultWithAttributes interface {
+	Attributes() map[string]string
+}
+
 type protocolSniffer func(context.Context, []byte) (SniffResult, error)
 
 type protocolSnifferWithMetadata struct {
@@ -22,6 +32,8 @@ type protocolSnifferWithMetadata struct {
 	// for both TCP and UDP connections
 	// It will not be shown as a traffic type for routing unless there is no other successful sniffing.
 	metadataSniffer bool
+	// The network of connections this sniffer applies to. Network_Unknown means all networks.
+	network net.Network
 }
 
 type Sniffer struct {
@@ -31,9 +43,12 @@ type Sniffer struct {
 func NewSniffer(ctx context.Context) *Sniffer {
 	ret := &Sniffer{
 		sniffer: []protocolSnifferWithMetadata{
-			{func(c context.Context, b []byte) (SniffResult, error) { return http.SniffHTTP(b) }, false},
-			{func(c context.Context, b []byte) (SniffResult, error) { return tls.SniffTLS(b) }, false},
-			{func(c context.Context, b []byte) (SniffResult, error) { return bittorrent.SniffBittorrent(b) }, false},
+			{func(c context.Context, b []byte) (SniffResult, error) { return http.SniffHTTP(b) }, false, net.Network_TCP},
+			{func(c context.Context, b []byte) (SniffResult, error) { return tls.SniffTLS(b) }, false, net.Network_TCP},
+			{func(c context.Context, b []byte) (SniffResult, error) { return bittorrent.SniffBittorrent(b) }, false, net.Network_TCP},
+			{func(c context.Context, b []byte) (SniffResult, error) { return dns.SniffTCPDNS(b) }, false, net.Network_TCP},
+			{func(c context.Context, b []byte) (SniffResult, error) { return quic.SniffQUIC(b) }, false, net.Network_UDP},
+			{func(c context.Context, b []byte) (SniffResult, error) { return dns.SniffDNS(b) }, false, net.Network_UDP},
 		},
 	}
 	if sniffer, err := newFakeDNSSniffer(ctx); err == nil {
@@ -45,12 +60,19 @@ func NewSniffer(ctx context.Context) *Sniffer {
 var errUnknownContent = newError("unknown content")
 
 func (s *Sniffer) Sniff(c context.Context, payload []byte) (SniffResult, error) {
+	network := net.Network_Unknown
+	if outbound := session.OutboundFromContext(c); outbound != nil {
+		network = outbound.Target.Network
+	}
 	var pendingSniffer []protocolSnifferWithMetadata
 	for _, si := range s.sniffer {
 		s := si.protocolSniffer
 		if si.metadataSniffer {
 			continue
 		}
+		if network != net.Network_Unknown && si.network != net.Network_Unknown && si.network != network {
+			continue
+		}
 		result, err := s(c, payload)
 		if err == common.ErrNoClue {
 			pendingSniffer = append(pendingSniffer, si)
@@ -114,6 +136,13 @@ func (c compositeResult) Domain() string {
 	return c.domainResult.Domain()
 }
 
+func (c compositeResult) Attributes() map[string]string {
+	if r, ok := c.protocolResult.(SniffResultWithAttributes); ok {
+		return r.Attributes()
+	}
+	return nil
+}
+
 func (c compositeResult) ProtocolForDomainResult() string {
 	return c.domainResult.Protocol()
 }
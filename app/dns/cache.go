@@ -0,0 +1,606 @@
+package dns
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/signal/pubsub"
+	"github.com/xtls/xray-core/common/task"
+	dns_feature "github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/features/stats"
+)
+
+const (
+	// defaultStaleTTL is how long an expired record may be served, if serve-stale
+	// is enabled without a limit. RFC8767 suggests 1 to 3 days.
+	defaultStaleTTL = time.Hour * 24
+	// prefetchHits is the number of cache hits for a name to be prefetched.
+	prefetchHits = 2
+	// refreshTimeout limits background refreshing of a name.
+	refreshTimeout = time.Second * 8
+)
+
+// CacheOption is the policy of a Cache.
+type CacheOption struct {
+	// Disabled stops caching of answers. Queries are always sent to name servers.
+	Disabled bool
+	// MinTTL and MaxTTL clamp TTL of cached records, if not zero.
+	MinTTL time.Duration
+	MaxTTL time.Duration
+	// Size is the maximum number of cached names. Least recently used names are
+	// evicted first. Zero means no limit.
+	Size int
+	// ServeStale enables serving expired records for up to StaleTTL, while they
+	// are refreshed in background.
+	ServeStale bool
+	StaleTTL   time.Duration
+	// Prefetch refreshes popular names before they expire.
+	Prefetch bool
+}
+
+// Cache is the cache of DNS answers shared by all name servers of a DNS app.
+type Cache struct {
+	sync.Mutex
+	option  CacheOption
+	entries map[string]*list.Element
+	lru     *list.List
+	cleanup *task.Periodic
+
+	hitCounter   stats.Counter
+	missCounter  stats.Counter
+	statsManager stats.Manager
+	eventChannel stats.Channel
+}
+
+type cacheEntry struct {
+	key  string
+	A    *IPRecord
+	AAAA *IPRecord
+	// ttlA and ttlAAAA are the TTL of the records when they were cached.
+	ttlA    time.Duration
+	ttlAAAA time.Duration
+	hits    int
+	// refreshing is the time when a background refresh of the name started.
+	refreshing time.Time
+	// msgs are the cached responses of other query types.
+	msgs map[dnsmessage.Type]*msgRecord
+}
+
+// msgRecord is a cached response of a query message.
+type msgRecord struct {
+	// raw is the packed response.
+	raw    []byte
+	cached time.Time
+	expire time.Time
+}
+
+func (e *cacheEntry) isEmpty() bool {
+	return e.A == nil && e.AAAA == nil && len(e.msgs) == 0
+}
+
+// NewCache creates a new Cache with the given policy.
+func NewCache(option CacheOption) *Cache {
+	if option.ServeStale && option.StaleTTL == 0 {
+		option.StaleTTL = defaultStaleTTL
+	}
+	if !option.ServeStale {
+		option.StaleTTL = 0
+	}
+	c := &Cache{
+		option:  option,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+	c.cleanup = &task.Periodic{
+		Interval: time.Minute,
+		Execute:  c.Cleanup,
+	}
+	return c
+}
+
+// SetStatsManager registers cache hit and miss counters to the stats manager.
+// Query events are published to its DNS event channel, and queries sent to
+// name servers are counted per server.
+func (c *Cache) SetStatsManager(m stats.Manager) {
+	c.Lock()
+	defer c.Unlock()
+	c.hitCounter, _ = stats.GetOrRegisterCounter(m, "dns>>>cache>>>hit")
+	c.missCounter, _ = stats.GetOrRegisterCounter(m, "dns>>>cache>>>miss")
+	c.eventChannel, _ = stats.GetOrRegisterChannel(m, dns_feature.EventChannel)
+	c.statsManager = m
+}
+
+// Len returns the number of names in the cache.
+func (c *Cache) Len() int {
+	c.Lock()
+	defer c.Unlock()
+	return len(c.entries)
+}
+
+// Cleanup removes expired records from the cache.
+func (c *Cache) Cleanup() error {
+	now := time.Now()
+	c.Lock()
+	defer c.Unlock()
+
+	if len(c.entries) == 0 {
+		return newError("nothing to do. stopping...")
+	}
+
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		entry := e.Value.(*cacheEntry)
+		if entry.A != nil && entry.A.Expire.Add(c.option.StaleTTL).Before(now) {
+			entry.A = nil
+		}
+		if entry.AAAA != nil && entry.AAAA.Expire.Add(c.option.StaleTTL).Before(now) {
+			entry.AAAA = nil
+		}
+		for t, msg := range entry.msgs {
+			if msg.expire.Before(now) {
+				delete(entry.msgs, t)
+			}
+		}
+		if entry.isEmpty() {
+			newError("cleanup ", entry.key).AtDebug().WriteToLog()
+			c.removeElement(e)
+		}
+		e = next
+	}
+	return nil
+}
+
+func (c *Cache) removeElement(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.entries, e.Value.(*cacheEntry).key)
+}
+
+func (c *Cache) clampTTL(ttl time.Duration) time.Duration {
+	if c.option.MinTTL > 0 && ttl < c.option.MinTTL {
+		ttl = c.option.MinTTL
+	}
+	if c.option.MaxTTL > 0 && ttl > c.option.MaxTTL {
+		ttl = c.option.MaxTTL
+	}
+	return ttl
+}
+
+// put caches the record of the given type, with its TTL clamped.
+func (c *Cache) put(key string, reqType dnsmessage.Type, rec *IPRecord) {
+	if c.option.Disabled {
+		return
+	}
+
+	now := time.Now()
+	ttl := c.clampTTL(rec.Expire.Sub(now))
+	cached := *rec
+	cached.Expire = now.Add(ttl)
+
+	c.Lock()
+	entry := c.entryLocked(key)
+	switch reqType {
+	case dnsmessage.TypeA:
+		if isNewer(entry.A, &cached) {
+			entry.A = &cached
+			entry.ttlA = ttl
+		}
+	case dnsmessage.TypeAAAA:
+		if isNewer(entry.AAAA, &cached) {
+			entry.AAAA = &cached
+			entry.ttlAAAA = ttl
+		}
+	}
+	entry.refreshing = time.Time{}
+	c.Unlock()
+
+	common.Must(c.cleanup.Start())
+}
+
+// entryLocked returns the entry of key as the most recently used one, or
+// creates it. It must be called with the lock held.
+func (c *Cache) entryLocked(key string) *cacheEntry {
+	if e, found := c.entries[key]; found {
+		c.lru.MoveToFront(e)
+		return e.Value.(*cacheEntry)
+	}
+	entry := &cacheEntry{key: key}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.option.Size > 0 && c.lru.Len() > c.option.Size {
+		c.removeElement(c.lru.Back())
+	}
+	return entry
+}
+
+// putMessage caches the response of a query message, with its TTL clamped.
+func (c *Cache) putMessage(key string, t dnsmessage.Type, msg *dnsmessage.Message) {
+	if c.option.Disabled {
+		return
+	}
+	raw, err := msg.Pack()
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	c.Lock()
+	entry := c.entryLocked(key)
+	if entry.msgs == nil {
+		entry.msgs = make(map[dnsmessage.Type]*msgRecord)
+	}
+	entry.msgs[t] = &msgRecord{
+		raw:    raw,
+		cached: now,
+		expire: now.Add(c.clampTTL(messageTTL(msg))),
+	}
+	c.Unlock()
+
+	common.Must(c.cleanup.Start())
+}
+
+// getMessage returns the cached response of type t of key. TTL of its records
+// are reduced by the time it has been cached, and limited to the time left.
+func (c *Cache) getMessage(key string, t dnsmessage.Type) *dnsmessage.Message {
+	if c.option.Disabled {
+		return nil
+	}
+
+	now := time.Now()
+	c.Lock()
+	var rec *msgRecord
+	if e, found := c.entries[key]; found {
+		rec = e.Value.(*cacheEntry).msgs[t]
+		if rec != nil && rec.expire.After(now) {
+			c.lru.MoveToFront(e)
+		} else {
+			rec = nil
+		}
+	}
+	if rec == nil {
+		c.count(c.missCounter)
+		c.Unlock()
+		return nil
+	}
+	c.count(c.hitCounter)
+	c.Unlock()
+
+	msg := new(dnsmessage.Message)
+	if err := msg.Unpack(rec.raw); err != nil {
+		return nil
+	}
+	elapsed := uint32(now.Sub(rec.cached) / time.Second)
+	remaining := uint32(rec.expire.Sub(now) / time.Second)
+	for _, section := range [][]dnsmessage.Resource{msg.Answers, msg.Authorities, msg.Additionals} {
+		for i := range section {
+			h := &section[i].Header
+			if h.Type == dnsmessage.TypeOPT {
+				continue
+			}
+			if h.TTL > elapsed {
+				h.TTL -= elapsed
+			} else {
+				h.TTL = 0
+			}
+			if h.TTL > remaining {
+				h.TTL = remaining
+			}
+		}
+	}
+	return msg
+}
+
+type cacheStatus int
+
+const (
+	cacheMiss cacheStatus = iota
+	cacheHit
+	// cacheStale is a hit of expired records. They should be refreshed.
+	cacheStale
+	// cachePrefetch is a hit of records about to expire. They should be refreshed.
+	cachePrefetch
+)
+
+// get returns the cached records of key for the enabled IP versions.
+func (c *Cache) get(key string, option dns_feature.IPOption) (a *IPRecord, aaaa *IPRecord, status cacheStatus) {
+	if c.option.Disabled {
+		return nil, nil, cacheMiss
+	}
+
+	now := time.Now()
+	c.Lock()
+	defer c.Unlock()
+
+	e, found := c.entries[key]
+	if !found {
+		c.count(c.missCounter)
+		return nil, nil, cacheMiss
+	}
+	entry := e.Value.(*cacheEntry)
+
+	status = cacheHit
+	check := func(rec *IPRecord, ttl time.Duration) bool {
+		switch {
+		case rec == nil || rec.Expire.Add(c.option.StaleTTL).Before(now):
+			return false
+		case rec.Expire.Before(now):
+			status = cacheStale
+		case status == cacheHit && c.option.Prefetch && entry.hits+1 >= prefetchHits && rec.Expire.Sub(now) < ttl/10:
+			status = cachePrefetch
+		}
+		return true
+	}
+	if option.IPv4Enable {
+		if !check(entry.A, entry.ttlA) {
+			c.count(c.missCounter)
+			return nil, nil, cacheMiss
+		}
+		a = entry.A
+	}
+	if option.IPv6Enable {
+		if !check(entry.AAAA, entry.ttlAAAA) {
+			c.count(c.missCounter)
+			return nil, nil, cacheMiss
+		}
+		aaaa = entry.AAAA
+	}
+
+	entry.hits++
+	c.lru.MoveToFront(e)
+	c.count(c.hitCounter)
+	return a, aaaa, status
+}
+
+// startRefresh returns true if a background refresh of key should start.
+func (c *Cache) startRefresh(key string) bool {
+	now := time.Now()
+	c.Lock()
+	defer c.Unlock()
+
+	e, found := c.entries[key]
+	if !found {
+		return false
+	}
+	entry := e.Value.(*cacheEntry)
+	if entry.refreshing.Add(refreshTimeout).After(now) {
+		return false
+	}
+	entry.refreshing = now
+	entry.hits = 0
+	return true
+}
+
+func (c *Cache) count(counter stats.Counter) {
+	if counter != nil {
+		counter.Add(1)
+	}
+}
+
+// cacheController resolves IPs of a name server through the cache. Answers of
+// the name server are delivered to waiting queries by updateIP.
+type cacheController struct {
+	name  string
+	cache *Cache
+	pub   *pubsub.Service
+}
+
+// newCacheController creates a cacheController of a name server. A private
+// cache with default policy is used if cache is nil.
+func newCacheController(name string, cache *Cache) *cacheController {
+	if cache == nil {
+		cache = NewCache(CacheOption{})
+	}
+	return &cacheController{
+		name:  name,
+		cache: cache,
+		pub:   pubsub.NewService(),
+	}
+}
+
+func (c *cacheController) cacheKey(domain string) string {
+	return c.name + " " + domain
+}
+
+// updateIP caches the answer of req, and delivers it to waiting queries.
+func (c *cacheController) updateIP(req *dnsRequest, ipRec *IPRecord) {
+	elapsed := time.Since(req.start)
+
+	switch req.reqType {
+	case dnsmessage.TypeA:
+		addr := make([]net.Address, 0, len(ipRec.IP))
+		for _, ip := range ipRec.IP {
+			if len(ip.IP()) == net.IPv4len {
+				addr = append(addr, ip)
+			}
+		}
+		ipRec.IP = addr
+	case dnsmessage.TypeAAAA:
+		addr := make([]net.Address, 0, len(ipRec.IP))
+		for _, ip := range ipRec.IP {
+			if len(ip.IP()) == net.IPv6len {
+				addr = append(addr, ip)
+			}
+		}
+		ipRec.IP = addr
+	default:
+		return
+	}
+	newError(c.name, " got answer: ", req.domain, " ", req.reqType, " -> ", ipRec.IP, " ", elapsed).AtInfo().WriteToLog()
+
+	c.cache.put(c.cacheKey(req.domain), req.reqType, ipRec)
+	switch req.reqType {
+	case dnsmessage.TypeA:
+		c.pub.Publish(req.domain+"4", ipRec)
+	case dnsmessage.TypeAAAA:
+		c.pub.Publish(req.domain+"6", ipRec)
+	}
+}
+
+// failIP delivers a server failure to queries waiting for the answer of req,
+// without caching it.
+func (c *cacheController) failIP(req *dnsRequest) {
+	rec := &IPRecord{ReqID: req.msg.ID, RCode: dnsmessage.RCodeServerFailure}
+	switch req.reqType {
+	case dnsmessage.TypeA:
+		c.pub.Publish(req.domain+"4", rec)
+	case dnsmessage.TypeAAAA:
+		c.pub.Publish(req.domain+"6", rec)
+	}
+}
+
+// mergeIPs returns IPs in the records of the enabled IP versions. Expired
+// records are accepted, as they are only passed here if they are served stale.
+func mergeIPs(a *IPRecord, aaaa *IPRecord, option dns_feature.IPOption) ([]net.IP, error) {
+	var ips []net.Address
+	var lastErr error
+	for _, rec := range []*IPRecord{a, aaaa} {
+		if rec == nil {
+			continue
+		}
+		if rec.RCode != dnsmessage.RCodeSuccess {
+			lastErr = dns_feature.RCodeError(rec.RCode)
+			continue
+		}
+		ips = append(ips, rec.IP...)
+	}
+
+	if len(ips) > 0 {
+		return toNetIP(ips), nil
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, dns_feature.ErrEmptyResponse
+}
+
+// sendFunc sends queries of domain to a name server. Answers should be passed
+// to updateIP of the cacheController.
+type sendFunc func(ctx context.Context, domain string, option dns_feature.IPOption)
+
+// queryIP looks up domain in the cache, and sends queries with send if not
+// cached. Stale or expiring records are refreshed in background.
+func (c *cacheController) queryIP(ctx context.Context, domain string, option dns_feature.IPOption, send sendFunc) ([]net.IP, error) {
+	fqdn := Fqdn(domain)
+	key := c.cacheKey(fqdn)
+
+	a, aaaa, status := c.cache.get(key, option)
+	if status != cacheMiss {
+		ips, err := mergeIPs(a, aaaa, option)
+		logStatus := log.DNSCacheHit
+		if status == cacheStale {
+			logStatus = log.DNSCacheStale
+		}
+		newError(c.name, " cache HIT ", domain, " -> ", ips).Base(err).AtDebug().WriteToLog()
+		log.Record(&log.DNSLog{Server: c.name, Domain: domain, Result: ips, Status: logStatus, Error: err})
+		if a != nil {
+			c.publishIP(ctx, domain, dnsmessage.TypeA, a, 0, true, nil)
+		}
+		if aaaa != nil {
+			c.publishIP(ctx, domain, dnsmessage.TypeAAAA, aaaa, 0, true, nil)
+		}
+		if status != cacheHit && c.cache.startRefresh(key) {
+			c.refresh(ctx, fqdn, option, send)
+		}
+		return ips, err
+	}
+
+	// ipv4 and ipv6 belong to different subscription groups
+	var sub4, sub6 *pubsub.Subscriber
+	if option.IPv4Enable {
+		sub4 = c.pub.Subscribe(fqdn + "4")
+		defer sub4.Close()
+	}
+	if option.IPv6Enable {
+		sub6 = c.pub.Subscribe(fqdn + "6")
+		defer sub6.Close()
+	}
+	send(ctx, fqdn, option)
+	start := time.Now()
+
+	if sub4 != nil {
+		select {
+		case msg := <-sub4.Wait():
+			a = msg.(*IPRecord)
+			c.publishIP(ctx, domain, dnsmessage.TypeA, a, time.Since(start), false, nil)
+		case <-ctx.Done():
+			c.publishIP(ctx, domain, dnsmessage.TypeA, nil, time.Since(start), false, ctx.Err())
+			return nil, ctx.Err()
+		}
+	}
+	if sub6 != nil {
+		select {
+		case msg := <-sub6.Wait():
+			aaaa = msg.(*IPRecord)
+			c.publishIP(ctx, domain, dnsmessage.TypeAAAA, aaaa, time.Since(start), false, nil)
+		case <-ctx.Done():
+			c.publishIP(ctx, domain, dnsmessage.TypeAAAA, nil, time.Since(start), false, ctx.Err())
+			return nil, ctx.Err()
+		}
+	}
+
+	ips, err := mergeIPs(a, aaaa, option)
+	log.Record(&log.DNSLog{Server: c.name, Domain: domain, Result: ips, Status: log.DNSQueried, Elapsed: time.Since(start), Error: err})
+	return ips, err
+}
+
+// exchangeFunc sends a query message to a name server, and returns its response.
+type exchangeFunc func(ctx context.Context, query *dnsmessage.Message) (*dnsmessage.Message, error)
+
+// queryMessage returns the cached response of the query, or the response from
+// exchange if not cached. Successful and NXDOMAIN responses are cached.
+func (c *cacheController) queryMessage(ctx context.Context, query *dnsmessage.Message, exchange exchangeFunc) (*dnsmessage.Message, error) {
+	q := query.Questions[0]
+	domain := q.Name.String()
+	key := c.cacheKey(strings.ToLower(domain))
+
+	if msg := c.cache.getMessage(key, q.Type); msg != nil {
+		newError(c.name, " cache HIT ", domain, " ", q.Type).AtDebug().WriteToLog()
+		log.Record(&log.DNSLog{Server: c.name, Domain: domain, Status: log.DNSCacheHit})
+		c.publishMessage(ctx, q, msg, 0, true, nil)
+		return msg, nil
+	}
+
+	start := time.Now()
+	msg, err := exchange(ctx, query)
+	elapsed := time.Since(start)
+	if err != nil {
+		log.Record(&log.DNSLog{Server: c.name, Domain: domain, Status: log.DNSQueried, Elapsed: elapsed, Error: err})
+		c.publishMessage(ctx, q, nil, elapsed, false, err)
+		return nil, err
+	}
+	// Some servers leave out the question section in responses.
+	if len(msg.Questions) > 0 && (msg.Questions[0].Type != q.Type || !strings.EqualFold(msg.Questions[0].Name.String(), domain)) {
+		return nil, newError(c.name, " answered another question for ", domain)
+	}
+	newError(c.name, " got answer: ", domain, " ", q.Type, " -> ", msg.RCode, " ", len(msg.Answers), " record(s) ", elapsed).AtInfo().WriteToLog()
+	log.Record(&log.DNSLog{Server: c.name, Domain: domain, Status: log.DNSQueried, Elapsed: elapsed})
+	c.publishMessage(ctx, q, msg, elapsed, false, nil)
+
+	if msg.RCode == dnsmessage.RCodeSuccess || msg.RCode == dnsmessage.RCodeNameError {
+		c.cache.putMessage(key, q.Type, msg)
+	}
+	return msg, nil
+}
+
+// refresh sends queries of domain in background, to update cached records.
+func (c *cacheController) refresh(ctx context.Context, domain string, option dns_feature.IPOption, send sendFunc) {
+	newError(c.name, " refreshing ", domain).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+
+	refreshCtx := context.Background()
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		refreshCtx = session.ContextWithInbound(refreshCtx, inbound)
+	}
+	refreshCtx, cancel := context.WithTimeout(refreshCtx, refreshTimeout)
+	send(refreshCtx, domain, option)
+	go func() {
+		<-refreshCtx.Done()
+		cancel()
+	}()
+}
@@ -0,0 +1,257 @@
+package dns
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	dns_feature "github.com/xtls/xray-core/features/dns"
+)
+
+var ipv4Only = dns_feature.IPOption{IPv4Enable: true}
+
+// fakeSender answers A queries with 1.2.3.<n> where n counts the queries.
+type fakeSender struct {
+	controller *cacheController
+	ttl        time.Duration
+	count      uint32
+}
+
+func (f *fakeSender) send(ctx context.Context, domain string, option dns_feature.IPOption) {
+	n := atomic.AddUint32(&f.count, 1)
+	req := &dnsRequest{reqType: dnsmessage.TypeA, domain: domain, start: time.Now()}
+	rec := &IPRecord{
+		IP:     []net.Address{net.IPAddress([]byte{1, 2, 3, byte(n)})},
+		Expire: time.Now().Add(f.ttl),
+		RCode:  dnsmessage.RCodeSuccess,
+	}
+	go f.controller.updateIP(req, rec)
+}
+
+func (f *fakeSender) queries() uint32 {
+	return atomic.LoadUint32(&f.count)
+}
+
+func (f *fakeSender) sendTTL(ttl time.Duration) {
+	f.ttl = ttl
+}
+
+func newFakeSender(option CacheOption, ttl time.Duration) *fakeSender {
+	return &fakeSender{
+		controller: newCacheController("fake", NewCache(option)),
+		ttl:        ttl,
+	}
+}
+
+func (f *fakeSender) query(t *testing.T, domain string) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ips, err := f.controller.queryIP(ctx, domain, ipv4Only, f.send)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ips) != 1 {
+		t.Fatal("expect 1 IP, but got ", ips)
+	}
+	return ips[0].String()
+}
+
+func waitQueries(f *fakeSender, n uint32) bool {
+	for i := 0; i < 100; i++ {
+		if f.queries() >= n {
+			return true
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	return false
+}
+
+func TestCacheHitAndMiss(t *testing.T) {
+	manager, err := stats.NewManager(context.Background(), &stats.Config{})
+	common.Must(err)
+
+	f := newFakeSender(CacheOption{}, time.Minute)
+	f.controller.cache.SetStatsManager(manager)
+
+	if ip := f.query(t, "example.com"); ip != "1.2.3.1" {
+		t.Error("unexpected IP: ", ip)
+	}
+	if ip := f.query(t, "example.com"); ip != "1.2.3.1" {
+		t.Error("expect cached IP, but got ", ip)
+	}
+	if f.queries() != 1 {
+		t.Error("expect 1 query, but got ", f.queries())
+	}
+	if hit := manager.GetCounter("dns>>>cache>>>hit").Value(); hit != 1 {
+		t.Error("expect 1 cache hit, but got ", hit)
+	}
+	if miss := manager.GetCounter("dns>>>cache>>>miss").Value(); miss != 1 {
+		t.Error("expect 1 cache miss, but got ", miss)
+	}
+}
+
+func TestCacheUpstreamCounters(t *testing.T) {
+	manager, err := stats.NewManager(context.Background(), &stats.Config{})
+	common.Must(err)
+	cache := NewCache(CacheOption{})
+	cache.SetStatsManager(manager)
+
+	for _, err := range []error{context.Canceled, newError("canceled").Base(context.Canceled), context.DeadlineExceeded} {
+		cache.publish(context.Background(), &dns_feature.QueryEvent{Server: "test", Error: err})
+	}
+	if counter := manager.GetCounter(dns_feature.UpstreamCounterName("test", "failure")); counter == nil || counter.Value() != 1 {
+		t.Error("expect 1 failure, but got ", counter)
+	}
+	if counter := manager.GetCounter(dns_feature.UpstreamCounterName("test", "success")); counter != nil {
+		t.Error("unexpected success counter: ", counter.Value())
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	f := newFakeSender(CacheOption{Disabled: true}, time.Minute)
+
+	f.query(t, "example.com")
+	if ip := f.query(t, "example.com"); ip != "1.2.3.2" {
+		t.Error("expect uncached IP, but got ", ip)
+	}
+}
+
+func TestCacheTTLClamping(t *testing.T) {
+	f := newFakeSender(CacheOption{MinTTL: time.Minute, MaxTTL: time.Hour}, time.Millisecond)
+	f.query(t, "short.example.com")
+	f.sendTTL(time.Hour * 24)
+	f.query(t, "long.example.com")
+
+	now := time.Now()
+	cache := f.controller.cache
+	short, _, _ := cache.get(f.controller.cacheKey("short.example.com."), ipv4Only)
+	if d := short.Expire.Sub(now); d < time.Second*59 || d > time.Minute {
+		t.Error("expect TTL clamped to minTTL, but got ", d)
+	}
+	long, _, _ := cache.get(f.controller.cacheKey("long.example.com."), ipv4Only)
+	if d := long.Expire.Sub(now); d < time.Minute*59 || d > time.Hour {
+		t.Error("expect TTL clamped to maxTTL, but got ", d)
+	}
+}
+
+func TestCacheSize(t *testing.T) {
+	f := newFakeSender(CacheOption{Size: 2}, time.Minute)
+
+	f.query(t, "a.example.com")
+	f.query(t, "b.example.com")
+	// a is used more recently than b.
+	f.query(t, "a.example.com")
+	f.query(t, "c.example.com")
+
+	if f.queries() != 3 {
+		t.Error("expect 3 queries, but got ", f.queries())
+	}
+	if ip := f.query(t, "a.example.com"); ip != "1.2.3.1" {
+		t.Error("expect a to be cached, but got ", ip)
+	}
+	if ip := f.query(t, "b.example.com"); ip != "1.2.3.4" {
+		t.Error("expect b to be evicted, but got ", ip)
+	}
+}
+
+func TestCacheServeStale(t *testing.T) {
+	f := newFakeSender(CacheOption{ServeStale: true}, -time.Second)
+
+	f.query(t, "example.com")
+	f.sendTTL(time.Minute)
+
+	// The expired record is served, and refreshed in background only once.
+	if ip := f.query(t, "example.com"); ip != "1.2.3.1" {
+		t.Error("expect stale IP, but got ", ip)
+	}
+	if !waitQueries(f, 2) {
+		t.Fatal("expect a background refresh")
+	}
+	time.Sleep(time.Millisecond * 50)
+	if ip := f.query(t, "example.com"); ip != "1.2.3.2" {
+		t.Error("expect refreshed IP, but got ", ip)
+	}
+	if f.queries() != 2 {
+		t.Error("expect 2 queries, but got ", f.queries())
+	}
+
+	// Expired records are not served without serve-stale.
+	f = newFakeSender(CacheOption{}, -time.Second)
+	f.query(t, "example.com")
+	if ip := f.query(t, "example.com"); ip != "1.2.3.2" {
+		t.Error("expect a new query, but got ", ip)
+	}
+}
+
+func TestCachePrefetch(t *testing.T) {
+	f := newFakeSender(CacheOption{Prefetch: true}, time.Minute)
+	f.query(t, "example.com")
+	f.query(t, "example.com")
+
+	// Make the popular record about to expire.
+	key := f.controller.cacheKey("example.com.")
+	cache := f.controller.cache
+	cache.Lock()
+	cache.entries[key].Value.(*cacheEntry).A.Expire = time.Now().Add(time.Second)
+	cache.Unlock()
+
+	if ip := f.query(t, "example.com"); ip != "1.2.3.1" {
+		t.Error("expect cached IP, but got ", ip)
+	}
+	if !waitQueries(f, 2) {
+		t.Fatal("expect a prefetch")
+	}
+	time.Sleep(time.Millisecond * 50)
+	if ip := f.query(t, "example.com"); ip != "1.2.3.2" {
+		t.Error("expect prefetched IP, but got ", ip)
+	}
+}
+
+func TestCacheMessage(t *testing.T) {
+	controller := newCacheController("fake", NewCache(CacheOption{MaxTTL: time.Hour}))
+	name := dnsmessage.MustNewName("example.com.")
+	query := &dnsmessage.Message{
+		Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET}},
+	}
+
+	var queries uint32
+	exchange := func(ctx context.Context, query *dnsmessage.Message) (*dnsmessage.Message, error) {
+		atomic.AddUint32(&queries, 1)
+		resp := newResponse(query, dnsmessage.RCodeSuccess)
+		resp.Answers = []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{Name: name, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET, TTL: 86400},
+			Body:   &dnsmessage.TXTResource{TXT: []string{"hello"}},
+		}}
+		return resp, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := controller.queryMessage(context.Background(), query, exchange)
+		common.Must(err)
+		if len(resp.Answers) != 1 || resp.Answers[0].Body.(*dnsmessage.TXTResource).TXT[0] != "hello" {
+			t.Fatal("unexpected answers: ", resp.Answers)
+		}
+		// Cached records have TTL of the clamped time left.
+		if ttl := resp.Answers[0].Header.TTL; (i == 0 && ttl != 86400) || (i == 1 && ttl > 3600) {
+			t.Error("unexpected TTL: ", ttl)
+		}
+	}
+	if queries != 1 {
+		t.Error("expect 1 query, but got ", queries)
+	}
+
+	// Records of other types are cached separately.
+	query.Questions[0].Type = dnsmessage.TypeMX
+	_, err := controller.queryMessage(context.Background(), query, exchange)
+	common.Must(err)
+	if queries != 2 {
+		t.Error("expect 2 queries, but got ", queries)
+	}
+}
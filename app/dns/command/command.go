@@ -0,0 +1,185 @@
+package command
+
+//go:generate go run github.com/xtls/xray-core/common/errors/errorgen
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+	grpc "google.golang.org/grpc"
+
+	dnsapp "github.com/xtls/xray-core/app/dns"
+	"github.com/xtls/xray-core/app/dns/fakedns"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/features/stats"
+)
+
+// mappingLister is a FakeDNSEngine able to list its mappings.
+type mappingLister interface {
+	Mappings() []fakedns.Mapping
+}
+
+// hostsEditor is a DNS client able to update its static hosts.
+type hostsEditor interface {
+	AddHosts(mappings []*dnsapp.Config_HostMapping) error
+	RemoveHosts(domains []string) int
+}
+
+// dnsServer is an implementation of DNSService.
+type dnsServer struct {
+	v *core.Instance
+}
+
+func NewDNSServer(v *core.Instance) DNSServiceServer {
+	return &dnsServer{v: v}
+}
+
+func (s *dnsServer) fakeDNS() (dns.FakeDNSEngine, error) {
+	engine, ok := s.v.GetFeature((*dns.FakeDNSEngine)(nil)).(dns.FakeDNSEngine)
+	if !ok {
+		return nil, newError("fake DNS is not enabled")
+	}
+	return engine, nil
+}
+
+func (s *dnsServer) GetFakeDNSMapping(ctx context.Context, request *GetFakeDNSMappingRequest) (*GetFakeDNSMappingResponse, error) {
+	engine, err := s.fakeDNS()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetFakeDNSMappingResponse{}
+	if addr := net.ParseAddress(request.Query); request.Query != "" && addr.Family().IsIP() {
+		if domain := engine.GetDomainFromFakeDNS(addr); domain != "" {
+			response.Mappings = append(response.Mappings, &FakeDNSMapping{Domain: domain, Ip: addr.String()})
+		}
+		return response, nil
+	}
+
+	lister, ok := engine.(mappingLister)
+	if !ok {
+		return nil, newError("listing mappings is not supported by the fake DNS engine")
+	}
+	for _, m := range lister.Mappings() {
+		if request.Query == "" || request.Query == m.Domain {
+			response.Mappings = append(response.Mappings, &FakeDNSMapping{Domain: m.Domain, Ip: m.IP})
+		}
+	}
+	return response, nil
+}
+
+func (s *dnsServer) hostsEditor() (hostsEditor, error) {
+	editor, ok := s.v.GetFeature(dns.ClientType()).(hostsEditor)
+	if !ok {
+		return nil, newError("updating hosts is not supported by the DNS client")
+	}
+	return editor, nil
+}
+
+func (s *dnsServer) AddHosts(ctx context.Context, request *AddHostsRequest) (*AddHostsResponse, error) {
+	editor, err := s.hostsEditor()
+	if err != nil {
+		return nil, err
+	}
+	if err := editor.AddHosts(request.Hosts); err != nil {
+		return nil, err
+	}
+	return &AddHostsResponse{}, nil
+}
+
+func (s *dnsServer) RemoveHosts(ctx context.Context, request *RemoveHostsRequest) (*RemoveHostsResponse, error) {
+	editor, err := s.hostsEditor()
+	if err != nil {
+		return nil, err
+	}
+	return &RemoveHostsResponse{Removed: uint32(editor.RemoveHosts(request.Domains))}, nil
+}
+
+func (s *dnsServer) SubscribeDNSEvents(request *SubscribeDNSEventsRequest, stream DNSService_SubscribeDNSEventsServer) error {
+	var channel stats.Channel
+	if manager, ok := s.v.GetFeature(stats.ManagerType()).(stats.Manager); ok {
+		channel = manager.GetChannel(dns.EventChannel)
+	}
+	if channel == nil {
+		return newError("DNS events not enabled")
+	}
+
+	subscriber, err := stats.SubscribeRunnableChannel(channel)
+	if err != nil {
+		return err
+	}
+	defer stats.UnsubscribeClosableChannel(channel, subscriber)
+	for {
+		select {
+		case value, ok := <-subscriber:
+			if !ok {
+				return newError("Upstream closed the subscriber channel.")
+			}
+			event, ok := value.(*dns.QueryEvent)
+			if !ok {
+				return newError("Upstream sent malformed DNS event.")
+			}
+			if err := stream.Send(toDNSEvent(event)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+var rcodeNames = map[dnsmessage.RCode]string{
+	dnsmessage.RCodeSuccess:        "NOERROR",
+	dnsmessage.RCodeFormatError:    "FORMERR",
+	dnsmessage.RCodeServerFailure:  "SERVFAIL",
+	dnsmessage.RCodeNameError:      "NXDOMAIN",
+	dnsmessage.RCodeNotImplemented: "NOTIMP",
+	dnsmessage.RCodeRefused:        "REFUSED",
+}
+
+func toDNSEvent(event *dns.QueryEvent) *DNSEvent {
+	e := &DNSEvent{
+		Timestamp: event.Time.UnixNano() / 1e6,
+		Domain:    event.Domain,
+		Type:      strings.TrimPrefix(event.Type.String(), "Type"),
+		Server:    event.Server,
+		Latency:   event.Latency.Milliseconds(),
+		CacheHit:  event.CacheHit,
+	}
+	if event.Client.IsValid() {
+		e.Client = event.Client.NetAddr()
+	}
+	if event.Error != nil {
+		e.Error = event.Error.Error()
+	} else if name, found := rcodeNames[event.RCode]; found {
+		e.Rcode = name
+	} else {
+		e.Rcode = strconv.Itoa(int(event.RCode))
+	}
+	for _, ip := range event.IPs {
+		e.Ips = append(e.Ips, ip.String())
+	}
+	return e
+}
+
+func (s *dnsServer) mustEmbedUnimplementedDNSServiceServer() {}
+
+type service struct {
+	v *core.Instance
+}
+
+func (s *service) Register(server *grpc.Server) {
+	RegisterDNSServiceServer(server, NewDNSServer(s.v))
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, cfg interface{}) (interface{}, error) {
+		s := core.MustFromContext(ctx)
+		return &service{v: s}, nil
+	}))
+}
@@ -0,0 +1,237 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package command
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// DNSServiceClient is the client API for DNSService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DNSServiceClient interface {
+	GetFakeDNSMapping(ctx context.Context, in *GetFakeDNSMappingRequest, opts ...grpc.CallOption) (*GetFakeDNSMappingResponse, error)
+	AddHosts(ctx context.Context, in *AddHostsRequest, opts ...grpc.CallOption) (*AddHostsResponse, error)
+	RemoveHosts(ctx context.Context, in *RemoveHostsRequest, opts ...grpc.CallOption) (*RemoveHostsResponse, error)
+	SubscribeDNSEvents(ctx context.Context, in *SubscribeDNSEventsRequest, opts ...grpc.CallOption) (DNSService_SubscribeDNSEventsClient, error)
+}
+
+type dNSServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDNSServiceClient(cc grpc.ClientConnInterface) DNSServiceClient {
+	return &dNSServiceClient{cc}
+}
+
+func (c *dNSServiceClient) GetFakeDNSMapping(ctx context.Context, in *GetFakeDNSMappingRequest, opts ...grpc.CallOption) (*GetFakeDNSMappingResponse, error) {
+	out := new(GetFakeDNSMappingResponse)
+	err := c.cc.Invoke(ctx, "/xray.app.dns.command.DNSService/GetFakeDNSMapping", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dNSServiceClient) AddHosts(ctx context.Context, in *AddHostsRequest, opts ...grpc.CallOption) (*AddHostsResponse, error) {
+	out := new(AddHostsResponse)
+	err := c.cc.Invoke(ctx, "/xray.app.dns.command.DNSService/AddHosts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dNSServiceClient) RemoveHosts(ctx context.Context, in *RemoveHostsRequest, opts ...grpc.CallOption) (*RemoveHostsResponse, error) {
+	out := new(RemoveHostsResponse)
+	err := c.cc.Invoke(ctx, "/xray.app.dns.command.DNSService/RemoveHosts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dNSServiceClient) SubscribeDNSEvents(ctx context.Context, in *SubscribeDNSEventsRequest, opts ...grpc.CallOption) (DNSService_SubscribeDNSEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DNSService_ServiceDesc.Streams[0], "/xray.app.dns.command.DNSService/SubscribeDNSEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dNSServiceSubscribeDNSEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DNSService_SubscribeDNSEventsClient interface {
+	Recv() (*DNSEvent, error)
+	grpc.ClientStream
+}
+
+type dNSServiceSubscribeDNSEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *dNSServiceSubscribeDNSEventsClient) Recv() (*DNSEvent, error) {
+	m := new(DNSEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DNSServiceServer is the server API for DNSService service.
+// All implementations must embed UnimplementedDNSServiceServer
+// for forward compatibility
+type DNSServiceServer interface {
+	GetFakeDNSMapping(context.Context, *GetFakeDNSMappingRequest) (*GetFakeDNSMappingResponse, error)
+	AddHosts(context.Context, *AddHostsRequest) (*AddHostsResponse, error)
+	RemoveHosts(context.Context, *RemoveHostsRequest) (*RemoveHostsResponse, error)
+	SubscribeDNSEvents(*SubscribeDNSEventsRequest, DNSService_SubscribeDNSEventsServer) error
+	mustEmbedUnimplementedDNSServiceServer()
+}
+
+// UnimplementedDNSServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedDNSServiceServer struct {
+}
+
+func (UnimplementedDNSServiceServer) GetFakeDNSMapping(context.Context, *GetFakeDNSMappingRequest) (*GetFakeDNSMappingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFakeDNSMapping not implemented")
+}
+func (UnimplementedDNSServiceServer) AddHosts(context.Context, *AddHostsRequest) (*AddHostsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddHosts not implemented")
+}
+func (UnimplementedDNSServiceServer) RemoveHosts(context.Context, *RemoveHostsRequest) (*RemoveHostsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveHosts not implemented")
+}
+func (UnimplementedDNSServiceServer) SubscribeDNSEvents(*SubscribeDNSEventsRequest, DNSService_SubscribeDNSEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeDNSEvents not implemented")
+}
+func (UnimplementedDNSServiceServer) mustEmbedUnimplementedDNSServiceServer() {}
+
+// UnsafeDNSServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DNSServiceServer will
+// result in compilation errors.
+type UnsafeDNSServiceServer interface {
+	mustEmbedUnimplementedDNSServiceServer()
+}
+
+func RegisterDNSServiceServer(s grpc.ServiceRegistrar, srv DNSServiceServer) {
+	s.RegisterService(&DNSService_ServiceDesc, srv)
+}
+
+func _DNSService_GetFakeDNSMapping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFakeDNSMappingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DNSServiceServer).GetFakeDNSMapping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/xray.app.dns.command.DNSService/GetFakeDNSMapping",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DNSServiceServer).GetFakeDNSMapping(ctx, req.(*GetFakeDNSMappingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DNSService_AddHosts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddHostsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DNSServiceServer).AddHosts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/xray.app.dns.command.DNSService/AddHosts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DNSServiceServer).AddHosts(ctx, req.(*AddHostsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DNSService_RemoveHosts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveHostsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DNSServiceServer).RemoveHosts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/xray.app.dns.command.DNSService/RemoveHosts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DNSServiceServer).RemoveHosts(ctx, req.(*RemoveHostsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DNSService_SubscribeDNSEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeDNSEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DNSServiceServer).SubscribeDNSEvents(m, &dNSServiceSubscribeDNSEventsServer{stream})
+}
+
+type DNSService_SubscribeDNSEventsServer interface {
+	Send(*DNSEvent) error
+	grpc.ServerStream
+}
+
+type dNSServiceSubscribeDNSEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *dNSServiceSubscribeDNSEventsServer) Send(m *DNSEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// DNSService_ServiceDesc is the grpc.ServiceDesc for DNSService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DNSService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "xray.app.dns.command.DNSService",
+	HandlerType: (*DNSServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetFakeDNSMapping",
+			Handler:    _DNSService_GetFakeDNSMapping_Handler,
+		},
+		{
+			MethodName: "AddHosts",
+			Handler:    _DNSService_AddHosts_Handler,
+		},
+		{
+			MethodName: "RemoveHosts",
+			Handler:    _DNSService_RemoveHosts_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeDNSEvents",
+			Handler:       _DNSService_SubscribeDNSEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "app/dns/command/command.proto",
+}
@@ -0,0 +1,194 @@
+package command_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/net/dns/dnsmessage"
+	"google.golang.org/grpc"
+
+	dnsapp "github.com/xtls/xray-core/app/dns"
+	. "github.com/xtls/xray-core/app/dns/command"
+	"github.com/xtls/xray-core/app/dns/fakedns"
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/dns"
+	feature_stats "github.com/xtls/xray-core/features/stats"
+)
+
+func TestGetFakeDNSMapping(t *testing.T) {
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&fakedns.FakeDnsPoolMulti{
+				Pools: []*fakedns.FakeDnsPool{
+					{IpPool: "198.18.0.0/16", LruSize: 256},
+					{IpPool: "fc00::/64", LruSize: 256},
+				},
+			}),
+		},
+	})
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	engine := v.GetFeature((*dns.FakeDNSEngine)(nil)).(dns.FakeDNSEngine)
+	addrs := engine.GetFakeIPForDomain("example.com")
+	engine.GetFakeIPForDomain("example.org")
+
+	s := NewDNSServer(v)
+	testCases := []struct {
+		query string
+		count int
+	}{
+		{query: "", count: 4},
+		{query: "example.com", count: 2},
+		{query: addrs[0].String(), count: 1},
+		{query: addrs[1].String(), count: 1},
+		{query: "example.net", count: 0},
+		{query: "198.19.0.1", count: 0},
+	}
+	for _, tc := range testCases {
+		resp, err := s.GetFakeDNSMapping(context.Background(), &GetFakeDNSMappingRequest{Query: tc.query})
+		common.Must(err)
+		if len(resp.Mappings) != tc.count {
+			t.Error("expect ", tc.count, " mappings for query ", tc.query, ", but got ", resp.Mappings)
+		}
+	}
+
+	resp, err := s.GetFakeDNSMapping(context.Background(), &GetFakeDNSMappingRequest{Query: "example.com"})
+	common.Must(err)
+	if r := cmp.Diff(resp.Mappings, []*FakeDNSMapping{
+		{Domain: "example.com", Ip: addrs[0].String()},
+		{Domain: "example.com", Ip: addrs[1].String()},
+	}, cmpopts.IgnoreUnexported(FakeDNSMapping{})); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestGetFakeDNSMappingDisabled(t *testing.T) {
+	v, err := core.New(&core.Config{})
+	common.Must(err)
+
+	s := NewDNSServer(v)
+	if _, err := s.GetFakeDNSMapping(context.Background(), &GetFakeDNSMappingRequest{}); err == nil {
+		t.Error("expect error if fake DNS is not enabled")
+	}
+}
+
+func TestAddRemoveHosts(t *testing.T) {
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dnsapp.Config{}),
+		},
+	})
+	common.Must(err)
+
+	s := NewDNSServer(v)
+	_, err = s.AddHosts(context.Background(), &AddHostsRequest{
+		Hosts: []*dnsapp.Config_HostMapping{
+			{
+				Type:   dnsapp.DomainMatchingType_Full,
+				Domain: "service.test",
+				Ip:     [][]byte{{10, 0, 0, 1}},
+			},
+		},
+	})
+	common.Must(err)
+
+	client := v.GetFeature(dns.ClientType()).(dns.Client)
+	ips, err := client.LookupIP("service.test", dns.IPOption{IPv4Enable: true})
+	common.Must(err)
+	if r := cmp.Diff(ips, []net.IP{{10, 0, 0, 1}}); r != "" {
+		t.Error(r)
+	}
+
+	if _, err := s.AddHosts(context.Background(), &AddHostsRequest{
+		Hosts: []*dnsapp.Config_HostMapping{{Domain: "invalid.test"}},
+	}); err == nil {
+		t.Error("expect error for host without IP or proxied domain")
+	}
+
+	resp, err := s.RemoveHosts(context.Background(), &RemoveHostsRequest{Domains: []string{"service.test"}})
+	common.Must(err)
+	if resp.Removed != 1 {
+		t.Error("expect 1 removed host, but got ", resp.Removed)
+	}
+}
+
+type eventStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *DNSEvent
+}
+
+func (s *eventStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *eventStream) Send(event *DNSEvent) error {
+	s.events <- event
+	return nil
+}
+
+func TestSubscribeDNSEvents(t *testing.T) {
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dnsapp.Config{}),
+			serial.ToTypedMessage(&stats.Config{}),
+		},
+	})
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &eventStream{ctx: ctx, events: make(chan *DNSEvent, 1)}
+	s := NewDNSServer(v)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- s.SubscribeDNSEvents(&SubscribeDNSEventsRequest{}, stream)
+	}()
+
+	manager := v.GetFeature(feature_stats.ManagerType()).(feature_stats.Manager)
+	channel := manager.GetChannel(dns.EventChannel)
+	for len(channel.Subscribers()) == 0 {
+		time.Sleep(time.Millisecond * 10)
+	}
+	channel.Publish(context.Background(), &dns.QueryEvent{
+		Time:    time.Unix(1, 0),
+		Client:  net.UDPDestination(net.ParseAddress("192.168.1.2"), 5353),
+		Domain:  "example.com",
+		Type:    dnsmessage.TypeAAAA,
+		Server:  "UDP:1.1.1.1:53",
+		Latency: time.Millisecond * 20,
+		RCode:   dnsmessage.RCodeNameError,
+	})
+
+	select {
+	case event := <-stream.events:
+		if r := cmp.Diff(event, &DNSEvent{
+			Timestamp: 1000,
+			Client:    "192.168.1.2:5353",
+			Domain:    "example.com",
+			Type:      "AAAA",
+			Server:    "UDP:1.1.1.1:53",
+			Latency:   20,
+			Rcode:     "NXDOMAIN",
+		}, cmpopts.IgnoreUnexported(DNSEvent{})); r != "" {
+			t.Error(r)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatal("timeout waiting for DNS event")
+	}
+
+	cancel()
+	if err := <-errChan; err != context.Canceled {
+		t.Error("unexpected error: ", err)
+	}
+}
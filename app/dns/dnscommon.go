@@ -2,12 +2,15 @@ package dns
 
 import (
 	"encoding/binary"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol/dns"
 	dns_feature "github.com/xtls/xray-core/features/dns"
 	"golang.org/x/net/dns/dnsmessage"
 )
@@ -63,6 +66,8 @@ type dnsRequest struct {
 	start   time.Time
 	expire  time.Time
 	msg     *dnsmessage.Message
+	// resp receives the raw response of a message query, if not nil.
+	resp chan []byte
 }
 
 func genEDNS0Options(clientIP net.IP) *dnsmessage.Resource {
@@ -227,3 +232,96 @@ L:
 
 	return ipRecord, nil
 }
+
+// packQuery packs query with the given ID and the EDNS0 client subnet option
+// of clientIP. query itself is not modified.
+func packQuery(query *dnsmessage.Message, id uint16, clientIP net.IP) (*buf.Buffer, error) {
+	msg := *query
+	msg.Header.ID = id
+	msg.Additionals = nil
+	if opt := genEDNS0Options(clientIP); opt != nil {
+		msg.Additionals = []dnsmessage.Resource{*opt}
+	}
+	return dns.PackMessage(&msg)
+}
+
+// unpackResponse parses the response of a query with the given ID.
+func unpackResponse(payload []byte, id uint16) (*dnsmessage.Message, error) {
+	msg := new(dnsmessage.Message)
+	if err := msg.Unpack(payload); err != nil {
+		return nil, newError("failed to parse DNS response").Base(err).AtWarning()
+	}
+	if msg.ID != id {
+		return nil, newError("unexpected DNS response ID ", msg.ID, ", expecting ", id)
+	}
+	return msg, nil
+}
+
+// newResponse creates an empty response to query with the given rcode.
+func newResponse(query *dnsmessage.Message, rcode dnsmessage.RCode) *dnsmessage.Message {
+	return &dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 query.ID,
+			Response:           true,
+			RecursionDesired:   query.RecursionDesired,
+			RecursionAvailable: true,
+			RCode:              rcode,
+		},
+		Questions: query.Questions,
+	}
+}
+
+// messageTTL returns the minimum TTL of the answer and authority records of
+// msg, or 600 seconds if there is none.
+func messageTTL(msg *dnsmessage.Message) time.Duration {
+	var ttl uint32 = 600
+	found := false
+	for _, sections := range [][]dnsmessage.Resource{msg.Answers, msg.Authorities} {
+		for _, r := range sections {
+			if !found || r.Header.TTL < ttl {
+				ttl = r.Header.TTL
+				found = true
+			}
+		}
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// parsePTRName returns the IP address of a reverse lookup name in in-addr.arpa
+// or ip6.arpa, or nil if name is not one.
+func parsePTRName(name string) net.IP {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	switch {
+	case strings.HasSuffix(name, ".in-addr.arpa"):
+		labels := strings.Split(strings.TrimSuffix(name, ".in-addr.arpa"), ".")
+		if len(labels) != net.IPv4len {
+			return nil
+		}
+		ip := make(net.IP, net.IPv4len)
+		for i, label := range labels {
+			n, err := strconv.ParseUint(label, 10, 8)
+			if err != nil {
+				return nil
+			}
+			ip[net.IPv4len-1-i] = byte(n)
+		}
+		return ip
+	case strings.HasSuffix(name, ".ip6.arpa"):
+		labels := strings.Split(strings.TrimSuffix(name, ".ip6.arpa"), ".")
+		if len(labels) != net.IPv6len*2 {
+			return nil
+		}
+		ip := make(net.IP, net.IPv6len)
+		for i, label := range labels {
+			n, err := strconv.ParseUint(label, 16, 4)
+			if err != nil || len(label) != 1 {
+				return nil
+			}
+			// Nibbles are in reverse order, starting from the lowest one.
+			pos := net.IPv6len*2 - 1 - i
+			ip[pos/2] |= byte(n) << (4 * uint(1-pos%2))
+		}
+		return ip
+	}
+	return nil
+}
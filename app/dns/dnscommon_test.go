@@ -173,3 +173,25 @@ func TestFqdn(t *testing.T) {
 		})
 	}
 }
+
+func Test_parsePTRName(t *testing.T) {
+	tests := []struct {
+		name string
+		want net.IP
+	}{
+		{"4.3.2.1.in-addr.arpa.", net.IP{1, 2, 3, 4}},
+		{"4.3.2.1.IN-ADDR.ARPA", net.IP{1, 2, 3, 4}},
+		{"b.a.9.8.7.6.5.0.4.0.0.0.3.0.0.0.2.0.0.0.1.0.0.0.0.0.0.0.1.2.3.4.ip6.arpa.", net.ParseIP("4321:0:1:2:3:4:567:89ab")},
+		{"3.2.1.in-addr.arpa.", nil},
+		{"256.3.2.1.in-addr.arpa.", nil},
+		{"ba.9.8.7.6.5.0.4.0.0.0.3.0.0.0.2.0.0.0.1.0.0.0.0.0.0.0.1.2.3.4.ip6.arpa.", nil},
+		{"example.com.", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parsePTRName(tt.name); !got.Equal(tt.want) {
+				t.Errorf("parsePTRName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
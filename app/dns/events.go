@@ -0,0 +1,91 @@
+package dns
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	dns_feature "github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/features/stats"
+)
+
+// publish publishes a query event to the event channel, and counts queries
+// sent to the name server in the stats manager. Queries canceled by the caller
+// are counted as neither success nor failure.
+func (c *Cache) publish(ctx context.Context, event *dns_feature.QueryEvent) {
+	c.Lock()
+	manager, channel := c.statsManager, c.eventChannel
+	c.Unlock()
+	if manager == nil {
+		return
+	}
+
+	if !event.CacheHit && errors.Cause(event.Error) != context.Canceled {
+		if event.Error == nil && event.RCode != dnsmessage.RCodeServerFailure && event.RCode != dnsmessage.RCodeRefused {
+			if counter, _ := stats.GetOrRegisterCounter(manager, dns_feature.UpstreamCounterName(event.Server, "success")); counter != nil {
+				counter.Add(1)
+			}
+			if counter, _ := stats.GetOrRegisterCounter(manager, dns_feature.UpstreamCounterName(event.Server, "latency")); counter != nil {
+				counter.Add(event.Latency.Milliseconds())
+			}
+		} else if counter, _ := stats.GetOrRegisterCounter(manager, dns_feature.UpstreamCounterName(event.Server, "failure")); counter != nil {
+			counter.Add(1)
+		}
+	}
+
+	if channel == nil || len(channel.Subscribers()) == 0 {
+		return
+	}
+	event.Time = time.Now()
+	event.Domain = strings.TrimSuffix(event.Domain, ".")
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		event.Client = inbound.Source
+	}
+	channel.Publish(context.Background(), event)
+}
+
+// publishIP publishes the event of an IP query of type t.
+func (c *cacheController) publishIP(ctx context.Context, domain string, t dnsmessage.Type, rec *IPRecord, latency time.Duration, cacheHit bool, err error) {
+	event := &dns_feature.QueryEvent{
+		Domain:   domain,
+		Type:     t,
+		Server:   c.name,
+		Latency:  latency,
+		CacheHit: cacheHit,
+		Error:    err,
+	}
+	if rec != nil {
+		event.RCode = rec.RCode
+		event.IPs = toNetIP(rec.IP)
+	}
+	c.cache.publish(ctx, event)
+}
+
+// publishMessage publishes the event of a query message.
+func (c *cacheController) publishMessage(ctx context.Context, q dnsmessage.Question, msg *dnsmessage.Message, latency time.Duration, cacheHit bool, err error) {
+	event := &dns_feature.QueryEvent{
+		Domain:   q.Name.String(),
+		Type:     q.Type,
+		Server:   c.name,
+		Latency:  latency,
+		CacheHit: cacheHit,
+		Error:    err,
+	}
+	if msg != nil {
+		event.RCode = msg.RCode
+		for _, r := range msg.Answers {
+			switch body := r.Body.(type) {
+			case *dnsmessage.AResource:
+				event.IPs = append(event.IPs, net.IP(body.A[:]))
+			case *dnsmessage.AAAAResource:
+				event.IPs = append(event.IPs, net.IP(body.AAAA[:]))
+			}
+		}
+	}
+	c.cache.publish(ctx, event)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/cache"
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/task"
 	"github.com/xtls/xray-core/features/dns"
 )
 
@@ -89,7 +90,7 @@ func (fkdns *Holder) GetFakeIPForDomain(domain string) []net.Address {
 	bigIntIP = bigIntIP.Add(bigIntIP, new(big.Int).SetUint64(currentTimeMillis))
 	var ip net.Address
 	for {
-		ip = net.IPAddress(bigIntIP.Bytes())
+		ip = fkdns.toAddress(bigIntIP)
 
 		// if we run for a long time, we may go back to beginning and start seeing the IP in use
 		if _, ok := fkdns.domainToIP.PeekKeyFromValue(ip); !ok {
@@ -105,6 +106,32 @@ func (fkdns *Holder) GetFakeIPForDomain(domain string) []net.Address {
 	return []net.Address{ip}
 }
 
+// toAddress returns the IP address of n in the IP pool.
+func (fkdns *Holder) toAddress(n *big.Int) net.Address {
+	b := n.Bytes()
+	ip := make([]byte, len(fkdns.ipRange.IP))
+	copy(ip[len(ip)-len(b):], b)
+	return net.IPAddress(ip)
+}
+
+// IsIPv6 returns true if the IP pool is of IPv6 addresses.
+func (fkdns *Holder) IsIPv6() bool {
+	return fkdns.ipRange.IP.To4() == nil
+}
+
+// IsIPInIPPool implements dns.FakeDNSEngineRev0.
+func (fkdns *Holder) IsIPInIPPool(ip net.Address) bool {
+	return ip.Family().IsIP() && fkdns.ipRange.Contains(ip.IP())
+}
+
+// GetFakeIPForDomain3 implements dns.FakeDNSEngineRev0.
+func (fkdns *Holder) GetFakeIPForDomain3(domain string, ipv4, ipv6 bool) []net.Address {
+	if (fkdns.IsIPv6() && ipv6) || (!fkdns.IsIPv6() && ipv4) {
+		return fkdns.GetFakeIPForDomain(domain)
+	}
+	return nil
+}
+
 // GetDomainFromFakeDNS check if an IP is a fake IP and have corresponding domain name
 func (fkdns *Holder) GetDomainFromFakeDNS(ip net.Address) string {
 	if !ip.Family().IsIP() || !fkdns.ipRange.Contains(ip.IP()) {
@@ -122,6 +149,115 @@ func (fkdns *Holder) GetFakeIPRange() *gonet.IPNet {
 	return fkdns.ipRange
 }
 
+// HolderMulti is a FakeDNSEngine of multiple IP pools. Mappings of domain
+// names and IP addresses are persisted to a file, if configured.
+type HolderMulti struct {
+	holders []*Holder
+	config  *FakeDnsPoolMulti
+	persist *task.Periodic
+}
+
+func (*HolderMulti) Type() interface{} {
+	return (*dns.FakeDNSEngine)(nil)
+}
+
+func (h *HolderMulti) Start() error {
+	for _, holder := range h.holders {
+		if err := holder.Start(); err != nil {
+			return newError("Cannot start all fake dns pools").Base(err)
+		}
+	}
+	if h.config.PersistPath != "" {
+		if err := h.load(); err != nil {
+			newError("failed to load fake dns mappings from ", h.config.PersistPath).Base(err).AtWarning().WriteToLog()
+		}
+		interval := defaultPersistInterval
+		if h.config.PersistInterval > 0 {
+			interval = time.Duration(h.config.PersistInterval) * time.Second
+		}
+		h.persist = &task.Periodic{
+			Interval: interval,
+			Execute: func() error {
+				if err := h.save(); err != nil {
+					newError("failed to save fake dns mappings to ", h.config.PersistPath).Base(err).AtWarning().WriteToLog()
+				}
+				return nil
+			},
+		}
+		// The first execution saves the mappings just loaded.
+		return h.persist.Start()
+	}
+	return nil
+}
+
+func (h *HolderMulti) Close() error {
+	if h.persist != nil {
+		h.persist.Close()
+		if err := h.save(); err != nil {
+			newError("failed to save fake dns mappings to ", h.config.PersistPath).Base(err).AtWarning().WriteToLog()
+		}
+	}
+	for _, holder := range h.holders {
+		if err := holder.Close(); err != nil {
+			return newError("Cannot close all fake dns pools").Base(err)
+		}
+	}
+	return nil
+}
+
+func (h *HolderMulti) GetFakeIPForDomain(domain string) []net.Address {
+	return h.GetFakeIPForDomain3(domain, true, true)
+}
+
+// GetFakeIPForDomain3 implements dns.FakeDNSEngineRev0.
+func (h *HolderMulti) GetFakeIPForDomain3(domain string, ipv4, ipv6 bool) []net.Address {
+	var ret []net.Address
+	for _, holder := range h.holders {
+		ret = append(ret, holder.GetFakeIPForDomain3(domain, ipv4, ipv6)...)
+	}
+	return ret
+}
+
+func (h *HolderMulti) GetDomainFromFakeDNS(ip net.Address) string {
+	for _, holder := range h.holders {
+		if holder.IsIPInIPPool(ip) {
+			return holder.GetDomainFromFakeDNS(ip)
+		}
+	}
+	return ""
+}
+
+// GetFakeIPRange returns the IP range of the first pool. Use IsIPInIPPool to
+// check IPs of all pools.
+func (h *HolderMulti) GetFakeIPRange() *gonet.IPNet {
+	if len(h.holders) == 0 {
+		return nil
+	}
+	return h.holders[0].GetFakeIPRange()
+}
+
+// IsIPInIPPool implements dns.FakeDNSEngineRev0.
+func (h *HolderMulti) IsIPInIPPool(ip net.Address) bool {
+	for _, holder := range h.holders {
+		if holder.IsIPInIPPool(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func NewFakeDNSHolderMulti(conf *FakeDnsPoolMulti) (*HolderMulti, error) {
+	h := &HolderMulti{config: conf}
+	for _, pool := range conf.Pools {
+		holder, err := NewFakeDNSHolderConfigOnly(pool)
+		if err != nil {
+			return nil, err
+		}
+		h.holders = append(h.holders, holder)
+	}
+	return h, nil
+}
+
 func init() {
 	common.Must(common.RegisterConfig((*FakeDnsPool)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
 		var f *Holder
@@ -131,4 +267,8 @@ func init() {
 		}
 		return f, nil
 	}))
+
+	common.Must(common.RegisterConfig((*FakeDnsPoolMulti)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		return NewFakeDNSHolderMulti(config.(*FakeDnsPoolMulti))
+	}))
 }
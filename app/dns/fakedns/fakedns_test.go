@@ -1,6 +1,7 @@
 package fakedns
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -103,3 +104,89 @@ func TestFakeDnsHolderCreateMappingAndRollOver(t *testing.T) {
 		}
 	}
 }
+
+func TestFakeDNSMulti(t *testing.T) {
+	fakeMulti, err := NewFakeDNSHolderMulti(&FakeDnsPoolMulti{
+		Pools: []*FakeDnsPool{
+			{IpPool: "240.0.0.0/12", LruSize: 256},
+			{IpPool: "fddd:c5b4:ff5f:f4f0::/64", LruSize: 256},
+		},
+	})
+	common.Must(err)
+	common.Must(fakeMulti.Start())
+
+	addrs := fakeMulti.GetFakeIPForDomain("fakednstest.example.com")
+	assert.Len(t, addrs, 2)
+	assert.True(t, addrs[0].Family().IsIPv4())
+	assert.True(t, addrs[1].Family().IsIPv6())
+	for _, addr := range addrs {
+		assert.True(t, fakeMulti.IsIPInIPPool(addr))
+		assert.Equal(t, "fakednstest.example.com", fakeMulti.GetDomainFromFakeDNS(addr))
+	}
+
+	v6 := fakeMulti.GetFakeIPForDomain3("fakednstest.example.com", false, true)
+	assert.Len(t, v6, 1)
+	assert.Equal(t, addrs[1], v6[0])
+
+	assert.False(t, fakeMulti.IsIPInIPPool(net.ParseAddress("198.18.0.1")))
+	assert.Equal(t, "", fakeMulti.GetDomainFromFakeDNS(net.ParseAddress("198.18.0.1")))
+}
+
+func TestFakeDNSPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fakedns.json")
+	config := &FakeDnsPoolMulti{
+		Pools: []*FakeDnsPool{
+			{IpPool: "198.18.0.0/16", LruSize: 256},
+			{IpPool: "fc00::/64", LruSize: 256},
+		},
+		PersistPath: path,
+	}
+
+	fakeMulti, err := NewFakeDNSHolderMulti(config)
+	common.Must(err)
+	common.Must(fakeMulti.Start())
+	addrs := fakeMulti.GetFakeIPForDomain("persist.example.com")
+	fakeMulti.GetFakeIPForDomain("other.example.com")
+	common.Must(fakeMulti.Close())
+
+	// Mappings are restored after restarting.
+	fakeMulti, err = NewFakeDNSHolderMulti(config)
+	common.Must(err)
+	common.Must(fakeMulti.Start())
+	for _, addr := range addrs {
+		assert.Equal(t, "persist.example.com", fakeMulti.GetDomainFromFakeDNS(addr))
+	}
+	assert.Equal(t, addrs, fakeMulti.GetFakeIPForDomain("persist.example.com"))
+	assert.Len(t, fakeMulti.Mappings(), 4)
+	common.Must(fakeMulti.Close())
+
+	// Mappings of pools no longer configured are dropped.
+	config.Pools = config.Pools[1:]
+	fakeMulti, err = NewFakeDNSHolderMulti(config)
+	common.Must(err)
+	common.Must(fakeMulti.Start())
+	assert.Len(t, fakeMulti.Mappings(), 2)
+	assert.Equal(t, "", fakeMulti.GetDomainFromFakeDNS(addrs[0]))
+	assert.Equal(t, "persist.example.com", fakeMulti.GetDomainFromFakeDNS(addrs[1]))
+	common.Must(fakeMulti.Close())
+}
+
+func TestFakeDNSRestore(t *testing.T) {
+	fkdns, err := NewFakeDNSHolderConfigOnly(&FakeDnsPool{IpPool: "198.18.0.0/16", LruSize: 2})
+	common.Must(err)
+	common.Must(fkdns.Start())
+	addr := fkdns.GetFakeIPForDomain("taken.example.com")[0]
+
+	n := fkdns.restore([]Mapping{
+		{Domain: "old.example.com", IP: "198.18.1.1"},
+		{Domain: "dup.example.com", IP: addr.String()},
+		{Domain: "new.example.com", IP: "198.18.1.2"},
+		{Domain: "outside.example.com", IP: "10.0.0.1"},
+	})
+	// The IP already mapped is skipped, and the restored mappings evict the
+	// existing one from the full pool.
+	assert.Equal(t, 2, n)
+	assert.Equal(t, "", fkdns.GetDomainFromFakeDNS(addr))
+	assert.Equal(t, "old.example.com", fkdns.GetDomainFromFakeDNS(net.ParseAddress("198.18.1.1")))
+	assert.Equal(t, "new.example.com", fkdns.GetDomainFromFakeDNS(net.ParseAddress("198.18.1.2")))
+}
@@ -0,0 +1,105 @@
+package fakedns
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/platform/filesystem"
+)
+
+const defaultPersistInterval = time.Minute * 5
+
+// Mapping is a domain name and its fake IP address.
+type Mapping struct {
+	Domain string `json:"domain"`
+	IP     string `json:"ip"`
+}
+
+// Mappings returns mappings of the pool from the least recently used one.
+func (fkdns *Holder) Mappings() []Mapping {
+	var mappings []Mapping
+	fkdns.domainToIP.Range(func(key, value interface{}) bool {
+		mappings = append(mappings, Mapping{
+			Domain: key.(string),
+			IP:     value.(net.Address).String(),
+		})
+		return true
+	})
+	return mappings
+}
+
+// restore adds mappings in the pool, and returns the number of them added.
+// Mappings whose domain or IP is already taken are skipped, and at most the
+// most recently used ones that fit in the pool are added.
+func (fkdns *Holder) restore(mappings []Mapping) int {
+	capacity := int(fkdns.config.GetLruSize())
+	domains := make(map[string]bool)
+	ips := make(map[net.Address]bool)
+	var selected []Mapping
+	for i := len(mappings) - 1; i >= 0 && len(selected) < capacity; i-- {
+		m := mappings[i]
+		ip := net.ParseAddress(m.IP)
+		if m.Domain == "" || !fkdns.IsIPInIPPool(ip) || domains[m.Domain] || ips[ip] {
+			continue
+		}
+		if _, found := fkdns.domainToIP.PeekKeyFromValue(ip); found {
+			continue
+		}
+		domains[m.Domain] = true
+		ips[ip] = true
+		selected = append(selected, m)
+	}
+	for i := len(selected) - 1; i >= 0; i-- {
+		fkdns.domainToIP.Put(selected[i].Domain, net.ParseAddress(selected[i].IP))
+	}
+	return len(selected)
+}
+
+// Mappings returns mappings of all pools.
+func (h *HolderMulti) Mappings() []Mapping {
+	var mappings []Mapping
+	for _, holder := range h.holders {
+		mappings = append(mappings, holder.Mappings()...)
+	}
+	return mappings
+}
+
+// load restores mappings of pools from the persist file. Mappings of pools no
+// longer configured are dropped.
+func (h *HolderMulti) load() error {
+	b, err := ioutil.ReadFile(h.config.PersistPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	pools := make(map[string][]Mapping)
+	if err := json.Unmarshal(b, &pools); err != nil {
+		return newError("invalid persist file").Base(err)
+	}
+	for _, holder := range h.holders {
+		cidr := holder.GetFakeIPRange().String()
+		if n := holder.restore(pools[cidr]); n > 0 {
+			newError("restored ", n, " fake dns mappings of ", cidr).AtInfo().WriteToLog()
+		}
+	}
+	return nil
+}
+
+// save writes mappings of all pools to the persist file.
+func (h *HolderMulti) save() error {
+	pools := make(map[string][]Mapping)
+	for _, holder := range h.holders {
+		pools[holder.GetFakeIPRange().String()] = holder.Mappings()
+	}
+	b, err := json.Marshal(pools)
+	if err != nil {
+		return err
+	}
+
+	return filesystem.WriteFileAtomic(h.config.PersistPath, b)
+}
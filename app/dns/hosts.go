@@ -1,6 +1,8 @@
 package dns
 
 import (
+	"sync"
+
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/strmatcher"
@@ -8,8 +10,22 @@ import (
 	"github.com/xtls/xray-core/features/dns"
 )
 
-// StaticHosts represents static domain-ip mapping in DNS server.
+// StaticHosts represents static domain-ip mapping in DNS server. Mappings come
+// from the config, from hosts files and from runtime updates. Mappings added
+// at runtime override those from hosts files, which override those from the
+// config.
 type StaticHosts struct {
+	sync.RWMutex
+	static  *hostGroup
+	files   *hostGroup
+	dynamic *hostGroup
+
+	// dynamicMappings are the mappings added at runtime.
+	dynamicMappings []*Config_HostMapping
+}
+
+// hostGroup is the matcher of mappings from one source.
+type hostGroup struct {
 	ips      [][]net.Address
 	matchers *strmatcher.MatcherGroup
 }
@@ -35,62 +51,177 @@ func toStrMatcher(t DomainMatchingType, domain string) (strmatcher.Matcher, erro
 
 // NewStaticHosts creates a new StaticHosts instance.
 func NewStaticHosts(hosts []*Config_HostMapping, legacy map[string]*net.IPOrDomain) (*StaticHosts, error) {
-	g := new(strmatcher.MatcherGroup)
-	sh := &StaticHosts{
-		ips:      make([][]net.Address, len(hosts)+len(legacy)+16),
-		matchers: g,
-	}
+	static := make([]*Config_HostMapping, 0, len(legacy)+len(hosts))
 
 	if legacy != nil {
 		features.PrintDeprecatedFeatureWarning("simple host mapping")
 
 		for domain, ip := range legacy {
-			matcher, err := strmatcher.Full.New(domain)
-			common.Must(err)
-			id := g.Add(matcher)
-
 			address := ip.AsAddress()
 			if address.Family().IsDomain() {
 				return nil, newError("invalid domain address in static hosts: ", address.Domain()).AtWarning()
 			}
-
-			sh.ips[id] = []net.Address{address}
+			static = append(static, &Config_HostMapping{
+				Type:   DomainMatchingType_Full,
+				Domain: domain,
+				Ip:     [][]byte{address.IP()},
+			})
 		}
 	}
+	static = append(static, hosts...)
+
+	g, err := newHostGroup(static)
+	if err != nil {
+		return nil, err
+	}
+	empty := &hostGroup{matchers: new(strmatcher.MatcherGroup)}
+	return &StaticHosts{
+		static:  g,
+		files:   empty,
+		dynamic: empty,
+	}, nil
+}
+
+func newHostGroup(mappings []*Config_HostMapping) (*hostGroup, error) {
+	g := new(strmatcher.MatcherGroup)
+	ips := make([][]net.Address, 1, len(mappings)+1)
 
-	for _, mapping := range hosts {
+	for _, mapping := range mappings {
 		matcher, err := toStrMatcher(mapping.Type, mapping.Domain)
 		if err != nil {
 			return nil, newError("failed to create domain matcher").Base(err)
 		}
+		addrs, err := mappingAddresses(mapping)
+		if err != nil {
+			return nil, err
+		}
 		id := g.Add(matcher)
-		ips := make([]net.Address, 0, len(mapping.Ip)+1)
-		switch {
-		case len(mapping.Ip) > 0:
-			for _, ip := range mapping.Ip {
-				addr := net.IPAddress(ip)
-				if addr == nil {
-					return nil, newError("invalid IP address in static hosts: ", ip).AtWarning()
-				}
-				ips = append(ips, addr)
-			}
+		for uint32(len(ips)) <= id {
+			ips = append(ips, nil)
+		}
+		ips[id] = addrs
+	}
 
-		case len(mapping.ProxiedDomain) > 0:
-			ips = append(ips, net.DomainAddress(mapping.ProxiedDomain))
+	return &hostGroup{
+		ips:      ips,
+		matchers: g,
+	}, nil
+}
 
-		default:
-			return nil, newError("neither IP address nor proxied domain specified for domain: ", mapping.Domain).AtWarning()
-		}
+// lookup returns addresses of all mappings matching the domain, or nil if
+// none matches.
+func (g *hostGroup) lookup(domain string) []net.Address {
+	indices := g.matchers.Match(domain)
+	if len(indices) == 0 {
+		return nil
+	}
+	ips := []net.Address{}
+	for _, id := range indices {
+		ips = append(ips, g.ips[id]...)
+	}
+	return ips
+}
 
-		// Special handling for localhost IPv6. This is a dirty workaround as JSON config supports only single IP mapping.
-		if len(ips) == 1 && ips[0] == net.LocalHostIP {
-			ips = append(ips, net.LocalHostIPv6)
+func mappingAddresses(mapping *Config_HostMapping) ([]net.Address, error) {
+	ips := make([]net.Address, 0, len(mapping.Ip)+1)
+	switch {
+	case len(mapping.Ip) > 0:
+		for _, ip := range mapping.Ip {
+			addr := net.IPAddress(ip)
+			if addr == nil {
+				return nil, newError("invalid IP address in static hosts: ", ip).AtWarning()
+			}
+			ips = append(ips, addr)
 		}
 
-		sh.ips[id] = ips
+	case len(mapping.ProxiedDomain) > 0:
+		ips = append(ips, net.DomainAddress(mapping.ProxiedDomain))
+
+	default:
+		return nil, newError("neither IP address nor proxied domain specified for domain: ", mapping.Domain).AtWarning()
 	}
 
-	return sh, nil
+	// Special handling for localhost IPv6. This is a dirty workaround as JSON config supports only single IP mapping.
+	if len(ips) == 1 && ips[0] == net.LocalHostIP {
+		ips = append(ips, net.LocalHostIPv6)
+	}
+	return ips, nil
+}
+
+// SetFileHosts replaces the mappings loaded from hosts files. The current
+// mappings are kept on error.
+func (h *StaticHosts) SetFileHosts(mappings []*Config_HostMapping) error {
+	g, err := newHostGroup(mappings)
+	if err != nil {
+		return err
+	}
+	h.Lock()
+	h.files = g
+	h.Unlock()
+	return nil
+}
+
+// setDynamicHosts replaces the mappings added at runtime. The current mappings
+// are kept on error.
+func (h *StaticHosts) setDynamicHosts(mappings []*Config_HostMapping) error {
+	g, err := newHostGroup(mappings)
+	if err != nil {
+		return err
+	}
+	h.dynamic = g
+	h.dynamicMappings = mappings
+	return nil
+}
+
+// AddHosts adds mappings at runtime. A mapping replaces the previously added
+// one of the same domain and matching type.
+func (h *StaticHosts) AddHosts(mappings []*Config_HostMapping) error {
+	h.Lock()
+	defer h.Unlock()
+
+	dynamic := make([]*Config_HostMapping, 0, len(h.dynamicMappings)+len(mappings))
+	for _, m := range h.dynamicMappings {
+		replaced := false
+		for _, n := range mappings {
+			if m.Type == n.Type && m.Domain == n.Domain {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			dynamic = append(dynamic, m)
+		}
+	}
+	dynamic = append(dynamic, mappings...)
+	return h.setDynamicHosts(dynamic)
+}
+
+// RemoveHosts removes mappings of the given domains added at runtime, and
+// returns the number of removed mappings. Mappings from the config and hosts
+// files are not affected.
+func (h *StaticHosts) RemoveHosts(domains []string) int {
+	h.Lock()
+	defer h.Unlock()
+
+	dynamic := make([]*Config_HostMapping, 0, len(h.dynamicMappings))
+	for _, m := range h.dynamicMappings {
+		removed := false
+		for _, domain := range domains {
+			if m.Domain == domain {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			dynamic = append(dynamic, m)
+		}
+	}
+	removed := len(h.dynamicMappings) - len(dynamic)
+	if removed > 0 {
+		// Mappings were valid when added, so rebuilding with fewer of them can't fail.
+		common.Must(h.setDynamicHosts(dynamic))
+	}
+	return removed
 }
 
 func filterIP(ips []net.Address, option dns.IPOption) []net.Address {
@@ -107,14 +238,19 @@ func filterIP(ips []net.Address, option dns.IPOption) []net.Address {
 }
 
 // LookupIP returns IP address for the given domain, if exists in this StaticHosts.
+// Only the mappings of the first source matching the domain are used.
 func (h *StaticHosts) LookupIP(domain string, option dns.IPOption) []net.Address {
-	indices := h.matchers.Match(domain)
-	if len(indices) == 0 {
-		return nil
+	h.RLock()
+	defer h.RUnlock()
+
+	var ips []net.Address
+	for _, g := range []*hostGroup{h.dynamic, h.files, h.static} {
+		if ips = g.lookup(domain); ips != nil {
+			break
+		}
 	}
-	ips := []net.Address{}
-	for _, id := range indices {
-		ips = append(ips, h.ips[id]...)
+	if ips == nil {
+		return nil
 	}
 	if len(ips) == 1 && ips[0].Family().IsDomain() {
 		return ips
@@ -78,3 +78,138 @@ func TestStaticHosts(t *testing.T) {
 		}
 	}
 }
+
+func TestStaticHostsUpdate(t *testing.T) {
+	hosts, err := NewStaticHosts([]*Config_HostMapping{
+		{
+			Type:   DomainMatchingType_Full,
+			Domain: "static.test",
+			Ip:     [][]byte{{1, 1, 1, 1}},
+		},
+	}, nil)
+	common.Must(err)
+
+	option := dns.IPOption{IPv4Enable: true, IPv6Enable: true}
+	lookup := func(domain string) []net.Address {
+		return hosts.LookupIP(domain, option)
+	}
+
+	common.Must(hosts.SetFileHosts([]*Config_HostMapping{
+		{
+			Type:   DomainMatchingType_Full,
+			Domain: "file.test",
+			Ip:     [][]byte{{2, 2, 2, 2}},
+		},
+	}))
+	common.Must(hosts.AddHosts([]*Config_HostMapping{
+		{
+			Type:   DomainMatchingType_Subdomain,
+			Domain: "dynamic.test",
+			Ip:     [][]byte{{3, 3, 3, 3}},
+		},
+	}))
+	if ips := lookup("a.dynamic.test"); len(ips) != 1 || ips[0].String() != "3.3.3.3" {
+		t.Error("unexpected IPs of added host: ", ips)
+	}
+	if ips := lookup("file.test"); len(ips) != 1 || ips[0].String() != "2.2.2.2" {
+		t.Error("unexpected IPs of file host: ", ips)
+	}
+
+	// Replace the added host.
+	common.Must(hosts.AddHosts([]*Config_HostMapping{
+		{
+			Type:          DomainMatchingType_Subdomain,
+			Domain:        "dynamic.test",
+			ProxiedDomain: "static.test",
+		},
+	}))
+	if ips := lookup("a.dynamic.test"); len(ips) != 1 || ips[0].String() != "static.test" {
+		t.Error("unexpected IPs of replaced host: ", ips)
+	}
+
+	// Invalid hosts are rejected without changing the current ones.
+	if err := hosts.AddHosts([]*Config_HostMapping{{Domain: "invalid.test"}}); err == nil {
+		t.Error("expect error for host without IP or proxied domain")
+	}
+	if ips := lookup("invalid.test"); ips != nil {
+		t.Error("unexpected IPs of invalid host: ", ips)
+	}
+	if ips := lookup("a.dynamic.test"); len(ips) != 1 {
+		t.Error("expect added host to be kept, but got ", ips)
+	}
+
+	if n := hosts.RemoveHosts([]string{"dynamic.test", "static.test", "file.test"}); n != 1 {
+		t.Error("expect 1 removed host, but got ", n)
+	}
+	if ips := lookup("a.dynamic.test"); ips != nil {
+		t.Error("unexpected IPs of removed host: ", ips)
+	}
+	if ips := lookup("static.test"); len(ips) != 1 {
+		t.Error("expect static host to be kept, but got ", ips)
+	}
+	if ips := lookup("file.test"); len(ips) != 1 {
+		t.Error("expect file host to be kept, but got ", ips)
+	}
+}
+
+func TestStaticHostsOverride(t *testing.T) {
+	hosts, err := NewStaticHosts([]*Config_HostMapping{
+		{
+			Type:   DomainMatchingType_Subdomain,
+			Domain: "example.com",
+			Ip:     [][]byte{{1, 1, 1, 1}},
+		},
+	}, nil)
+	common.Must(err)
+
+	option := dns.IPOption{IPv4Enable: true, IPv6Enable: true}
+	lookup := func(domain string) []net.Address {
+		return hosts.LookupIP(domain, option)
+	}
+
+	// Hosts files override the config.
+	common.Must(hosts.SetFileHosts([]*Config_HostMapping{
+		{
+			Type:   DomainMatchingType_Full,
+			Domain: "www.example.com",
+			Ip:     [][]byte{{2, 2, 2, 2}},
+		},
+	}))
+	if ips := lookup("www.example.com"); len(ips) != 1 || ips[0].String() != "2.2.2.2" {
+		t.Error("unexpected IPs of host overridden by file: ", ips)
+	}
+	if ips := lookup("api.example.com"); len(ips) != 1 || ips[0].String() != "1.1.1.1" {
+		t.Error("unexpected IPs of host from config: ", ips)
+	}
+
+	// Hosts added at runtime override both, even with a proxied domain.
+	common.Must(hosts.AddHosts([]*Config_HostMapping{
+		{
+			Type:          DomainMatchingType_Full,
+			Domain:        "www.example.com",
+			ProxiedDomain: "proxied.test",
+		},
+		{
+			Type:   DomainMatchingType_Full,
+			Domain: "api.example.com",
+			Ip:     [][]byte{{3, 3, 3, 3}},
+		},
+	}))
+	if ips := lookup("www.example.com"); len(ips) != 1 || ips[0].String() != "proxied.test" {
+		t.Error("unexpected IPs of host overridden at runtime: ", ips)
+	}
+	if ips := lookup("api.example.com"); len(ips) != 1 || ips[0].String() != "3.3.3.3" {
+		t.Error("unexpected IPs of host overridden at runtime: ", ips)
+	}
+
+	// Removing the added hosts restores the previous ones.
+	if n := hosts.RemoveHosts([]string{"www.example.com", "api.example.com"}); n != 2 {
+		t.Error("expect 2 hosts removed, but got ", n)
+	}
+	if ips := lookup("www.example.com"); len(ips) != 1 || ips[0].String() != "2.2.2.2" {
+		t.Error("unexpected IPs of host after removal: ", ips)
+	}
+	if ips := lookup("api.example.com"); len(ips) != 1 || ips[0].String() != "1.1.1.1" {
+		t.Error("unexpected IPs of host after removal: ", ips)
+	}
+}
@@ -0,0 +1,133 @@
+package dns
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/platform/filesystem"
+)
+
+var hostsPrefixMap = map[string]DomainMatchingType{
+	"full:":    DomainMatchingType_Full,
+	"domain:":  DomainMatchingType_Subdomain,
+	"keyword:": DomainMatchingType_Keyword,
+	"regexp:":  DomainMatchingType_Regex,
+}
+
+// parseHostsLine parses a line of a hosts file. Lines starting with an IP are
+// in the format of /etc/hosts, listing names of the IP. Other lines start with
+// a domain, optionally prefixed by its matching type, followed by its IPs or a
+// single domain it is proxied to.
+func parseHostsLine(line string) (ip net.IP, names []string, mapping *Config_HostMapping, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return nil, nil, nil, newError("expecting at least 2 fields")
+	}
+
+	// Strip zone of link-local IPv6 addresses.
+	if ip := net.ParseIP(strings.SplitN(fields[0], "%", 2)[0]); ip != nil {
+		names := make([]string, 0, len(fields)-1)
+		for _, name := range fields[1:] {
+			names = append(names, strings.ToLower(strings.TrimSuffix(name, ".")))
+		}
+		return ip, names, nil, nil
+	}
+
+	mapping = &Config_HostMapping{
+		Type:   DomainMatchingType_Full,
+		Domain: fields[0],
+	}
+	for prefix, t := range hostsPrefixMap {
+		if strings.HasPrefix(fields[0], prefix) {
+			mapping.Type = t
+			mapping.Domain = fields[0][len(prefix):]
+			break
+		}
+	}
+	if mapping.Type != DomainMatchingType_Regex {
+		mapping.Domain = strings.ToLower(strings.TrimSuffix(mapping.Domain, "."))
+	}
+	if mapping.Domain == "" {
+		return nil, nil, nil, newError("empty domain")
+	}
+
+	for _, target := range fields[1:] {
+		if ip := net.ParseIP(target); ip != nil {
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			mapping.Ip = append(mapping.Ip, ip)
+		} else if len(fields) == 2 {
+			mapping.ProxiedDomain = target
+		} else {
+			return nil, nil, nil, newError("invalid IP: ", target)
+		}
+	}
+	return nil, nil, mapping, nil
+}
+
+// parseHostsFile parses the content of a hosts file. IPs of the same name in
+// the /etc/hosts format are merged into one mapping.
+func parseHostsFile(content []byte) ([]*Config_HostMapping, error) {
+	var mappings []*Config_HostMapping
+	names := make(map[string]*Config_HostMapping)
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if idx := strings.IndexByte(line, '#'); idx >= 0 {
+			line = line[:idx]
+		}
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		ip, hostNames, mapping, err := parseHostsLine(line)
+		if err != nil {
+			return nil, newError("invalid hosts line ", lineNum, ": ", line).Base(err)
+		}
+		if mapping != nil {
+			mappings = append(mappings, mapping)
+			continue
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		for _, name := range hostNames {
+			m, found := names[name]
+			if !found {
+				m = &Config_HostMapping{
+					Type:   DomainMatchingType_Full,
+					Domain: name,
+				}
+				names[name] = m
+				mappings = append(mappings, m)
+			}
+			m.Ip = append(m.Ip, ip)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
+
+// loadHostsFiles loads mappings from the given hosts files.
+func loadHostsFiles(files []string) ([]*Config_HostMapping, error) {
+	var mappings []*Config_HostMapping
+	for _, file := range files {
+		content, err := filesystem.ReadFile(router.SourceLocation(file))
+		if err != nil {
+			return nil, newError("failed to read hosts file: ", file).Base(err)
+		}
+		m, err := parseHostsFile(content)
+		if err != nil {
+			return nil, newError("failed to parse hosts file: ", file).Base(err)
+		}
+		mappings = append(mappings, m...)
+	}
+	return mappings, nil
+}
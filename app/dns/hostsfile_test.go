@@ -0,0 +1,54 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func Test_parseHostsFile(t *testing.T) {
+	content := `
+# comment
+127.0.0.1	localhost
+::1		localhost ip6-localhost  # trailing comment
+fe80::1%lo0	link.local
+10.0.0.1 Foo.Example.com. bar.example.com
+
+service.test 10.0.0.2 10.0.0.3
+domain:internal.test 10.0.0.4
+keyword:blocked 0.0.0.0
+regexp:^api\.[a-z]+\.test$ 10.0.0.5
+alias.test service.test
+`
+	mappings, err := parseHostsFile([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*Config_HostMapping{
+		{Type: DomainMatchingType_Full, Domain: "localhost", Ip: [][]byte{{127, 0, 0, 1}, {0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}}},
+		{Type: DomainMatchingType_Full, Domain: "ip6-localhost", Ip: [][]byte{{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}}},
+		{Type: DomainMatchingType_Full, Domain: "link.local", Ip: [][]byte{{0xfe, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}}},
+		{Type: DomainMatchingType_Full, Domain: "foo.example.com", Ip: [][]byte{{10, 0, 0, 1}}},
+		{Type: DomainMatchingType_Full, Domain: "bar.example.com", Ip: [][]byte{{10, 0, 0, 1}}},
+		{Type: DomainMatchingType_Full, Domain: "service.test", Ip: [][]byte{{10, 0, 0, 2}, {10, 0, 0, 3}}},
+		{Type: DomainMatchingType_Subdomain, Domain: "internal.test", Ip: [][]byte{{10, 0, 0, 4}}},
+		{Type: DomainMatchingType_Keyword, Domain: "blocked", Ip: [][]byte{{0, 0, 0, 0}}},
+		{Type: DomainMatchingType_Regex, Domain: `^api\.[a-z]+\.test$`, Ip: [][]byte{{10, 0, 0, 5}}},
+		{Type: DomainMatchingType_Full, Domain: "alias.test", ProxiedDomain: "service.test"},
+	}
+	if r := cmp.Diff(mappings, expected, cmpopts.IgnoreUnexported(Config_HostMapping{})); r != "" {
+		t.Error(r)
+	}
+
+	for _, invalid := range []string{
+		"localhost",
+		"example.com 10.0.0.1 not-an-ip",
+		"domain: 10.0.0.1",
+	} {
+		if _, err := parseHostsFile([]byte(invalid)); err == nil {
+			t.Error("expect error for line: ", invalid)
+		}
+	}
+}
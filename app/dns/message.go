@@ -0,0 +1,135 @@
+package dns
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/features/dns"
+)
+
+// LookupMessage implements dns.MessageClient. A and AAAA queries are answered
+// by LookupIP. Queries of other types are sent to the name servers selected for
+// the domain, skipping those not able to answer them.
+func (s *Server) LookupMessage(ctx context.Context, query *dnsmessage.Message) (*dnsmessage.Message, error) {
+	if len(query.Questions) != 1 {
+		return nil, newError("expecting 1 question, but got ", len(query.Questions))
+	}
+	q := query.Questions[0]
+	domain := strings.TrimSuffix(q.Name.String(), ".")
+
+	if q.Type == dnsmessage.TypeA || q.Type == dnsmessage.TypeAAAA {
+		return s.lookupIPMessage(ctx, query, domain)
+	}
+
+	name := domain
+	if domain != "" {
+		ips := s.lookupStatic(domain, dns.IPOption{IPv4Enable: true, IPv6Enable: true}, 0)
+		if ips != nil && ips[0].Family().IsIP() {
+			// Domains in hosts have no records other than their IPs.
+			newError("returning empty ", q.Type, " answer for domain ", domain, " in hosts").WriteToLog()
+			return newResponse(query, dnsmessage.RCodeSuccess), nil
+		}
+		if ips != nil && ips[0].Family().IsDomain() {
+			name = ips[0].Domain()
+			newError("domain replaced: ", domain, " -> ", name).WriteToLog()
+		}
+	}
+	upstreamName, err := dnsmessage.NewName(Fqdn(name))
+	if err != nil {
+		return nil, newError("invalid domain ", name).Base(err)
+	}
+	upstream := &dnsmessage.Message{
+		Header:    dnsmessage.Header{RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: upstreamName, Type: q.Type, Class: q.Class}},
+	}
+
+	s.Lock()
+	domainMatcher, matcherInfos, allDomainRules := s.domainMatcher, s.matcherInfos, s.domainRules
+	s.Unlock()
+
+	// FakeDNS only answers PTR queries of fake IPs.
+	option := dns.IPOption{IPv4Enable: true, IPv6Enable: true, FakeEnable: q.Type == dnsmessage.TypePTR}
+	var lastErr error = dns.ErrMessageNotSupported
+	for _, c := range s.sortClients(name, option, domainMatcher, matcherInfos, allDomainRules) {
+		client, ok := c.client.(messageClient)
+		if !ok {
+			newError("skip ", q.Type, " query for domain ", name, " at server ", c.client.Name()).AtDebug().WriteToLog()
+			continue
+		}
+		resp, err := s.queryMessageTimeout(ctx, c.idx, client, name, upstream)
+		if err == nil && resp.RCode != dnsmessage.RCodeServerFailure && resp.RCode != dnsmessage.RCodeRefused {
+			return finishResponse(query, resp, upstreamName), nil
+		}
+		if err == nil {
+			err = dns.RCodeError(resp.RCode)
+		}
+		newError("failed to lookup ", q.Type, " for domain ", name, " at server ", client.Name()).Base(err).WriteToLog()
+		lastErr = err
+	}
+
+	return nil, newError("returning nil for ", q.Type, " query of domain ", name).Base(lastErr)
+}
+
+func (s *Server) queryMessageTimeout(ctx context.Context, idx int, client messageClient, domain string, query *dnsmessage.Message) (*dnsmessage.Message, error) {
+	ctx, cancel := context.WithTimeout(s.requestContext(ctx), s.clientOption(idx).timeout)
+	defer cancel()
+	return client.QueryMessage(s.queryContext(ctx, domain), query)
+}
+
+// finishResponse returns resp as the response to query. Records of the name
+// queried upstream are renamed to the name in query, in case the domain is
+// replaced by hosts.
+func finishResponse(query *dnsmessage.Message, resp *dnsmessage.Message, upstreamName dnsmessage.Name) *dnsmessage.Message {
+	msg := newResponse(query, resp.RCode)
+	msg.Authoritative = resp.Authoritative
+	name := query.Questions[0].Name
+	rename := func(records []dnsmessage.Resource) []dnsmessage.Resource {
+		renamed := make([]dnsmessage.Resource, 0, len(records))
+		for _, r := range records {
+			if r.Header.Type == dnsmessage.TypeOPT {
+				continue
+			}
+			if strings.EqualFold(r.Header.Name.String(), upstreamName.String()) {
+				r.Header.Name = name
+			}
+			renamed = append(renamed, r)
+		}
+		return renamed
+	}
+	msg.Answers = rename(resp.Answers)
+	msg.Authorities = rename(resp.Authorities)
+	msg.Additionals = rename(resp.Additionals)
+	return msg
+}
+
+// lookupIPMessage answers A or AAAA queries with IPs from LookupIP.
+func (s *Server) lookupIPMessage(ctx context.Context, query *dnsmessage.Message, domain string) (*dnsmessage.Message, error) {
+	q := query.Questions[0]
+	ips, err := s.lookupIP(ctx, domain, dns.IPOption{
+		IPv4Enable: q.Type == dnsmessage.TypeA,
+		IPv6Enable: q.Type == dnsmessage.TypeAAAA,
+		FakeEnable: true,
+	})
+	rcode := dns.RCodeFromError(err)
+	if rcode == 0 && len(ips) == 0 && errors.Cause(err) != dns.ErrEmptyResponse {
+		return nil, err
+	}
+
+	resp := newResponse(query, dnsmessage.RCode(rcode))
+	header := dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: dnsmessage.ClassINET, TTL: 600}
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil && q.Type == dnsmessage.TypeA {
+			r := &dnsmessage.AResource{}
+			copy(r.A[:], ip4)
+			resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: r})
+		} else if len(ip) == 16 && q.Type == dnsmessage.TypeAAAA {
+			r := &dnsmessage.AAAAResource{}
+			copy(r.AAAA[:], ip)
+			resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: r})
+		}
+	}
+	return resp, nil
+}
@@ -3,6 +3,8 @@ package dns
 import (
 	"context"
 
+	"golang.org/x/net/dns/dnsmessage"
+
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/features/dns"
 	"github.com/xtls/xray-core/features/dns/localdns"
@@ -17,6 +19,14 @@ type Client interface {
 	QueryIP(ctx context.Context, domain string, option dns.IPOption) ([]net.IP, error)
 }
 
+// messageClient is a Client able to query DNS records of any type.
+type messageClient interface {
+	Client
+
+	// QueryMessage sends the query message to its configured server, and returns the response.
+	QueryMessage(ctx context.Context, query *dnsmessage.Message) (*dnsmessage.Message, error)
+}
+
 type LocalNameServer struct {
 	client *localdns.Client
 }
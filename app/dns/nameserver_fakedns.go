@@ -3,6 +3,8 @@ package dns
 import (
 	"context"
 
+	"golang.org/x/net/dns/dnsmessage"
+
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/dns"
@@ -20,22 +22,81 @@ func (FakeDNSServer) Name() string {
 	return "FakeDNS"
 }
 
-func (f *FakeDNSServer) QueryIP(ctx context.Context, domain string, _ dns.IPOption) ([]net.IP, error) {
+func (f *FakeDNSServer) engine(ctx context.Context) error {
 	if f.fakeDNSEngine == nil {
 		if err := core.RequireFeatures(ctx, func(fd dns.FakeDNSEngine) {
 			f.fakeDNSEngine = fd
 		}); err != nil {
-			return nil, newError("Unable to locate a fake DNS Engine").Base(err).AtError()
+			return newError("Unable to locate a fake DNS Engine").Base(err).AtError()
 		}
 	}
-	ips := f.fakeDNSEngine.GetFakeIPForDomain(domain)
+	return nil
+}
 
-	netIP := toNetIP(ips)
-	if netIP == nil {
-		return nil, newError("Unable to convert IP to net ip").AtError()
+func (f *FakeDNSServer) QueryIP(ctx context.Context, domain string, option dns.IPOption) ([]net.IP, error) {
+	if err := f.engine(ctx); err != nil {
+		return nil, err
+	}
+	var ips []net.Address
+	if fkr0, ok := f.fakeDNSEngine.(dns.FakeDNSEngineRev0); ok {
+		ips = fkr0.GetFakeIPForDomain3(domain, option.IPv4Enable, option.IPv6Enable)
+	} else {
+		ips = filterIP(f.fakeDNSEngine.GetFakeIPForDomain(domain), option)
+	}
+
+	// There is no pool of the queried IP version.
+	if len(ips) == 0 {
+		return nil, dns.ErrEmptyResponse
 	}
+	netIP := toNetIP(ips)
 
 	newError(f.Name(), " got answer: ", domain, " -> ", ips).AtInfo().WriteToLog()
 
 	return netIP, nil
 }
+
+// QueryMessage answers PTR queries of IPs in the fake IP pools.
+func (f *FakeDNSServer) QueryMessage(ctx context.Context, query *dnsmessage.Message) (*dnsmessage.Message, error) {
+	q := query.Questions[0]
+	if q.Type != dnsmessage.TypePTR {
+		return nil, newError(f.Name(), " only answers PTR queries")
+	}
+	ip := parsePTRName(q.Name.String())
+	if ip == nil {
+		return nil, newError(f.Name(), " invalid PTR name: ", q.Name)
+	}
+	if err := f.engine(ctx); err != nil {
+		return nil, err
+	}
+	if !isFakeIP(f.fakeDNSEngine, net.IPAddress(ip)) {
+		return nil, newError(f.Name(), " ", ip, " is not a fake IP")
+	}
+
+	domain := f.fakeDNSEngine.GetDomainFromFakeDNS(net.IPAddress(ip))
+	if domain == "" {
+		newError(f.Name(), " got answer: ", q.Name, " PTR -> NXDOMAIN").AtInfo().WriteToLog()
+		return newResponse(query, dnsmessage.RCodeNameError), nil
+	}
+	name, err := dnsmessage.NewName(Fqdn(domain))
+	if err != nil {
+		return nil, newError(f.Name(), " invalid domain ", domain).Base(err)
+	}
+	newError(f.Name(), " got answer: ", q.Name, " PTR -> ", domain).AtInfo().WriteToLog()
+
+	resp := newResponse(query, dnsmessage.RCodeSuccess)
+	resp.Answers = []dnsmessage.Resource{{
+		// Mappings of fake IPs may change at any time.
+		Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET, TTL: 1},
+		Body:   &dnsmessage.PTRResource{PTR: name},
+	}}
+	return resp, nil
+}
+
+// isFakeIP returns true if ip is in the IP pools of the engine.
+func isFakeIP(engine dns.FakeDNSEngine, ip net.Address) bool {
+	if fkr0, ok := engine.(dns.FakeDNSEngineRev0); ok {
+		return fkr0.IsIPInIPPool(ip)
+	}
+	ipRange := engine.GetFakeIPRange()
+	return ipRange != nil && ip.Family().IsIP() && ipRange.Contains(ip.IP())
+}
@@ -2,12 +2,17 @@ package dns_test
 
 import (
 	"context"
+	"net/url"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/miekg/dns"
+
 	. "github.com/xtls/xray-core/app/dns"
 	"github.com/xtls/xray-core/common"
 	dns_feature "github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/testing/servers/tcp"
 )
 
 func TestLocalNameServer(t *testing.T) {
@@ -24,3 +29,53 @@ func TestLocalNameServer(t *testing.T) {
 		t.Error("expect some ips, but got 0")
 	}
 }
+
+func TestTCPLocalNameServer(t *testing.T) {
+	port := tcp.PickPort()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "tcp",
+		Handler: &staticHandler{},
+	}
+	go dnsServer.ListenAndServe()
+	defer dnsServer.Shutdown()
+	time.Sleep(time.Second)
+
+	u, err := url.Parse("tcp+local://127.0.0.1:" + port.String())
+	common.Must(err)
+	s, err := NewTCPLocalNameServer(u, nil, nil)
+	common.Must(err)
+	if s.Name() != "TCPL//127.0.0.1:"+port.String() {
+		t.Error("unexpected name: ", s.Name())
+	}
+
+	// Queries are pipelined on the same connection.
+	var wg sync.WaitGroup
+	for _, c := range []struct {
+		domain string
+		ip     string
+	}{
+		{"google.com", "8.8.8.8"},
+		{"api.google.com", "8.8.7.7"},
+		{"v2.api.google.com", "8.8.7.8"},
+	} {
+		wg.Add(1)
+		go func(domain, ip string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+			defer cancel()
+			ips, err := s.QueryIP(ctx, domain, dns_feature.IPOption{
+				IPv4Enable: true,
+			})
+			if err != nil {
+				t.Error("failed to query ", domain, ": ", err)
+				return
+			}
+			if len(ips) != 1 || ips[0].String() != ip {
+				t.Error("expect ", ip, " for ", domain, " but got ", ips)
+			}
+		}(c.domain, c.ip)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,92 @@
+package dns
+
+import (
+	"context"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/features/dns"
+)
+
+type queryResult struct {
+	ips []net.IP
+	err error
+}
+
+// queryParallel queries all candidates concurrently. It returns the first
+// answer, or the answer of a candidate of higher priority if it arrives within
+// the preference window. Other queries are canceled once an answer is chosen.
+func (s *Server) queryParallel(ctx context.Context, domain string, candidates []queryCandidate) ([]net.IP, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type indexedResult struct {
+		queryResult
+		index int
+	}
+	resultChan := make(chan indexedResult, len(candidates))
+	for i, c := range candidates {
+		go func(i int, c queryCandidate) {
+			ips, err := s.queryIPTimeout(ctx, c.idx, c.client, domain, c.option)
+			resultChan <- indexedResult{
+				queryResult: queryResult{ips: ips, err: err},
+				index:       i,
+			}
+		}(i, c)
+	}
+
+	results := make([]*queryResult, len(candidates))
+	best := -1
+	var window <-chan time.Time
+
+	// done returns true if no pending candidate has higher priority than best.
+	done := func() bool {
+		for i := 0; i < best; i++ {
+			if results[i] == nil {
+				return false
+			}
+		}
+		return true
+	}
+
+	for pending := len(candidates); pending > 0; {
+		select {
+		case r := <-resultChan:
+			pending--
+			results[r.index] = &r.queryResult
+			if len(r.ips) > 0 && (best < 0 || r.index < best) {
+				best = r.index
+				if window == nil && s.preferenceWindow > 0 {
+					timer := time.NewTimer(s.preferenceWindow)
+					defer timer.Stop()
+					window = timer.C
+				}
+			}
+			if r.err != nil {
+				newError("failed to lookup ip for domain ", domain, " at server ", candidates[r.index].client.Name()).Base(r.err).WriteToLog()
+			}
+		case <-window:
+			newError("domain ", domain, " answered by server ", candidates[best].client.Name(), " after preference window").AtDebug().WriteToLog()
+			return results[best].ips, nil
+		}
+
+		if best >= 0 && (s.preferenceWindow == 0 || done()) {
+			newError("domain ", domain, " answered by server ", candidates[best].client.Name()).AtDebug().WriteToLog()
+			return results[best].ips, nil
+		}
+	}
+
+	// An empty response of a server matching the domain is authoritative.
+	if first := results[0]; !candidates[0].fallback && first.err == dns.ErrEmptyResponse {
+		return nil, first.err
+	}
+
+	errs := make([]error, 0, len(results))
+	for i, r := range results {
+		if r.err != nil {
+			errs = append(errs, newError(candidates[i].client.Name()).Base(r.err))
+		}
+	}
+	return nil, newError("returning nil for domain ", domain).Base(errors.Combine(errs...))
+}
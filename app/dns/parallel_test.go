@@ -0,0 +1,137 @@
+package dns
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/features/dns"
+)
+
+// delayedClient answers with ips or err after delay.
+type delayedClient struct {
+	name     string
+	delay    time.Duration
+	ips      []net.IP
+	err      error
+	canceled int32
+}
+
+func (c *delayedClient) Name() string {
+	return c.name
+}
+
+func (c *delayedClient) QueryIP(ctx context.Context, domain string, option dns.IPOption) ([]net.IP, error) {
+	select {
+	case <-time.After(c.delay):
+		return c.ips, c.err
+	case <-ctx.Done():
+		atomic.StoreInt32(&c.canceled, 1)
+		return nil, ctx.Err()
+	}
+}
+
+func newParallelServer(window time.Duration, clients ...Client) *Server {
+	hosts, err := NewStaticHosts(nil, nil)
+	common.Must(err)
+	s := &Server{
+		ctx:              context.Background(),
+		hosts:            hosts,
+		clients:          clients,
+		parallelQuery:    true,
+		preferenceWindow: window,
+	}
+	for range clients {
+		s.ipIndexMap = append(s.ipIndexMap, nil)
+		s.clientOptions = append(s.clientOptions, clientOption{timeout: time.Second})
+	}
+	return s
+}
+
+var dualStack = dns.IPOption{IPv4Enable: true, IPv6Enable: true}
+
+func TestParallelQueryFirstAnswer(t *testing.T) {
+	slow := &delayedClient{name: "slow", delay: time.Second * 3, ips: []net.IP{{1, 1, 1, 1}}}
+	fast := &delayedClient{name: "fast", delay: time.Millisecond * 10, ips: []net.IP{{2, 2, 2, 2}}}
+	s := newParallelServer(0, slow, fast)
+
+	start := time.Now()
+	ips, err := s.LookupIP("example.com", dualStack)
+	common.Must(err)
+	if len(ips) != 1 || !ips[0].Equal(net.IP{2, 2, 2, 2}) {
+		t.Error("expect answer of the fast server, but got ", ips)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Error("expect not waiting for the slow server, but took ", elapsed)
+	}
+
+	// The slow query is canceled after an answer is chosen.
+	time.Sleep(time.Millisecond * 100)
+	if atomic.LoadInt32(&slow.canceled) != 1 {
+		t.Error("expect the slow query to be canceled")
+	}
+}
+
+func TestParallelQueryPreferenceWindow(t *testing.T) {
+	preferred := &delayedClient{name: "preferred", delay: time.Millisecond * 100, ips: []net.IP{{1, 1, 1, 1}}}
+	fast := &delayedClient{name: "fast", delay: time.Millisecond * 10, ips: []net.IP{{2, 2, 2, 2}}}
+
+	// The preferred server answers within the window.
+	s := newParallelServer(time.Millisecond*500, preferred, fast)
+	ips, err := s.LookupIP("example.com", dualStack)
+	common.Must(err)
+	if len(ips) != 1 || !ips[0].Equal(net.IP{1, 1, 1, 1}) {
+		t.Error("expect answer of the preferred server, but got ", ips)
+	}
+
+	// The preferred server is too slow for the window.
+	preferred = &delayedClient{name: "preferred", delay: time.Second * 3, ips: []net.IP{{1, 1, 1, 1}}}
+	s = newParallelServer(time.Millisecond*50, preferred, fast)
+	start := time.Now()
+	ips, err = s.LookupIP("example.com", dualStack)
+	common.Must(err)
+	if len(ips) != 1 || !ips[0].Equal(net.IP{2, 2, 2, 2}) {
+		t.Error("expect answer of the fast server, but got ", ips)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Error("expect answer after the preference window, but took ", elapsed)
+	}
+
+	// A failed preferred server does not hold the answer.
+	failed := &delayedClient{name: "failed", delay: time.Millisecond * 20, err: dns.RCodeError(2)}
+	s = newParallelServer(time.Second*3, failed, fast)
+	start = time.Now()
+	ips, err = s.LookupIP("example.com", dualStack)
+	common.Must(err)
+	if len(ips) != 1 || !ips[0].Equal(net.IP{2, 2, 2, 2}) {
+		t.Error("expect answer of the fast server, but got ", ips)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Error("expect answer once the preferred server failed, but took ", elapsed)
+	}
+}
+
+func TestParallelQueryFailures(t *testing.T) {
+	refused := &delayedClient{name: "refused", delay: time.Millisecond * 10, err: dns.RCodeError(5)}
+	slow := &delayedClient{name: "slow", delay: time.Second * 3, ips: []net.IP{{1, 1, 1, 1}}}
+	s := newParallelServer(0, refused, slow)
+
+	start := time.Now()
+	_, err := s.LookupIP("example.com", dualStack)
+	if err == nil {
+		t.Fatal("expect error, but got nil")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second*2 {
+		t.Error("expect the query timeout, but took ", elapsed)
+	}
+	// Errors of all servers are reported.
+	for _, name := range []string{"refused", "slow"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Error("expect error of ", name, " in: ", err)
+		}
+	}
+}
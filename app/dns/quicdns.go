@@ -0,0 +1,430 @@
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol/dns"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/signal/done"
+	dns_feature "github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+// NextProtoDQ is the ALPN token of DNS over QUIC, defined in RFC9250. Tokens
+// of drafts are also offered for compatibility.
+var NextProtoDQ = []string{"doq", "doq-i02", "doq-i00", "dq"}
+
+const handshakeTimeout = time.Second * 8
+
+// doqNoError is the error code of closing a session without error, defined
+// in RFC9250.
+const doqNoError quic.ErrorCode = 0
+
+// QUICNameServer implements DNS over QUIC (RFC9250). Each query is sent on a
+// new stream of a shared QUIC session.
+type QUICNameServer struct {
+	cacheController *cacheController
+	name            string
+	destination     net.Destination
+	serverName      string
+	clientIP        net.IP
+	tlsConfig       *tls.Config
+	listen          func(context.Context) (net.PacketConn, net.Addr, error)
+
+	sessionAccess sync.Mutex
+	session       quic.EarlySession
+	packetConn    net.PacketConn
+	closed        bool
+}
+
+// NewQUICNameServer creates DNS over QUIC client object for remote resolving.
+func NewQUICNameServer(url *url.URL, dispatcher routing.Dispatcher, cache *Cache, clientIP net.IP) (*QUICNameServer, error) {
+	s, err := baseQUICNameServer(url, "DOQ", cache, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	s.listen = func(ctx context.Context) (net.PacketConn, net.Addr, error) {
+		dispatcherCtx := context.Background()
+		if inbound := session.InboundFromContext(ctx); inbound != nil {
+			dispatcherCtx = session.ContextWithInbound(dispatcherCtx, inbound)
+		}
+		dispatcherCtx = session.ContextWithContent(dispatcherCtx, &session.Content{
+			Protocol: "quic",
+		})
+		dispatcherCtx = log.ContextWithAccessMessage(dispatcherCtx, &log.AccessMessage{
+			From:   "DNS",
+			To:     s.destination,
+			Status: log.AccessAccepted,
+			Reason: "",
+		})
+
+		link, err := dispatcher.Dispatch(dispatcherCtx, s.destination)
+		if err != nil {
+			return nil, nil, err
+		}
+		remote := &net.UDPAddr{Port: int(s.destination.Port)}
+		if s.destination.Address.Family().IsIP() {
+			remote.IP = s.destination.Address.IP()
+		}
+		return &linkPacketConn{
+			link:   link,
+			remote: remote,
+			done:   done.New(),
+		}, remote, nil
+	}
+	newError("DNS: created Remote DNS over QUIC client for ", url.String()).AtInfo().WriteToLog()
+	return s, nil
+}
+
+// NewQUICLocalNameServer creates DNS over QUIC client object for local resolving.
+func NewQUICLocalNameServer(url *url.URL, cache *Cache, clientIP net.IP) (*QUICNameServer, error) {
+	s, err := baseQUICNameServer(url, "DOQL", cache, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	s.listen = func(ctx context.Context) (net.PacketConn, net.Addr, error) {
+		var remote *net.UDPAddr
+		if s.destination.Address.Family().IsIP() {
+			remote = &net.UDPAddr{
+				IP:   s.destination.Address.IP(),
+				Port: int(s.destination.Port),
+			}
+		} else {
+			addr, err := net.ResolveUDPAddr("udp", s.destination.NetAddr())
+			if err != nil {
+				return nil, nil, err
+			}
+			remote = addr
+		}
+		conn, err := internet.ListenSystemPacket(ctx, &net.UDPAddr{
+			IP:   []byte{0, 0, 0, 0},
+			Port: 0,
+		}, nil)
+		log.Record(&log.AccessMessage{
+			From:   "DNS",
+			To:     s.destination,
+			Status: log.AccessAccepted,
+			Detour: "local",
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return conn, remote, nil
+	}
+	newError("DNS: created Local DNS over QUIC client for ", url.String()).AtInfo().WriteToLog()
+	return s, nil
+}
+
+func baseQUICNameServer(url *url.URL, prefix string, cache *Cache, clientIP net.IP) (*QUICNameServer, error) {
+	port := net.Port(853)
+	if url.Port() != "" {
+		var err error
+		if port, err = net.PortFromString(url.Port()); err != nil {
+			return nil, err
+		}
+	}
+	s := &QUICNameServer{
+		clientIP:    clientIP,
+		serverName:  url.Hostname(),
+		destination: net.UDPDestination(net.ParseAddress(url.Hostname()), port),
+	}
+	s.name = prefix + "//" + s.destination.NetAddr()
+	s.cacheController = newCacheController(s.name, cache)
+	s.tlsConfig = &tls.Config{
+		ServerName:         s.serverName,
+		NextProtos:         NextProtoDQ,
+		ClientSessionCache: tls.NewLRUClientSessionCache(16),
+	}
+	return s, nil
+}
+
+// Name returns client name
+func (s *QUICNameServer) Name() string {
+	return s.name
+}
+
+// RFC9250 requires the message ID of queries to be 0.
+func zeroReqID() uint16 {
+	return 0
+}
+
+func isActiveSession(sess quic.EarlySession) bool {
+	select {
+	case <-sess.Context().Done():
+		return false
+	default:
+		return true
+	}
+}
+
+// getSession returns the session in use, or establishes a new one. Sessions
+// resume with 0-RTT if the server supports it.
+func (s *QUICNameServer) getSession(ctx context.Context) (quic.EarlySession, error) {
+	s.sessionAccess.Lock()
+	defer s.sessionAccess.Unlock()
+
+	if s.closed {
+		return nil, newError(s.name, " is closed")
+	}
+	if s.session != nil && isActiveSession(s.session) {
+		return s.session, nil
+	}
+	s.closeSession()
+
+	conn, remote, err := s.listen(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := quic.DialEarlyContext(ctx, conn, remote, s.serverName, s.tlsConfig, &quic.Config{
+		HandshakeIdleTimeout: handshakeTimeout,
+		MaxIdleTimeout:       time.Second * 30,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, newError("failed to dial ", s.name).Base(err)
+	}
+	s.session = sess
+	s.packetConn = conn
+	return sess, nil
+}
+
+// closeSession closes the session in use. It must be called with
+// sessionAccess held.
+func (s *QUICNameServer) closeSession() {
+	if s.session != nil {
+		s.session.CloseWithError(doqNoError, "")
+		s.session = nil
+	}
+	if s.packetConn != nil {
+		s.packetConn.Close()
+		s.packetConn = nil
+	}
+}
+
+// Close implements common.Closable. The session and its packet connection
+// are closed, and later queries fail.
+func (s *QUICNameServer) Close() error {
+	s.sessionAccess.Lock()
+	defer s.sessionAccess.Unlock()
+
+	s.closed = true
+	s.closeSession()
+	return nil
+}
+
+func (s *QUICNameServer) openStream(ctx context.Context) (quic.Stream, error) {
+	for attempt := 0; ; attempt++ {
+		sess, err := s.getSession(ctx)
+		if err != nil {
+			return nil, err
+		}
+		stream, err := sess.OpenStreamSync(ctx)
+		if err == nil {
+			return stream, nil
+		}
+		if attempt > 0 || ctx.Err() != nil {
+			return nil, err
+		}
+		newError(s.name, " failed to open stream, reconnecting").Base(err).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+		s.sessionAccess.Lock()
+		if s.session == sess {
+			s.closeSession()
+		}
+		s.sessionAccess.Unlock()
+	}
+}
+
+func (s *QUICNameServer) exchange(ctx context.Context, b []byte) ([]byte, error) {
+	stream, err := s.openStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CancelRead(0)
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetDeadline(deadline)
+	}
+
+	query := make([]byte, 2+len(b))
+	binary.BigEndian.PutUint16(query, uint16(len(b)))
+	copy(query[2:], b)
+	if _, err := stream.Write(query); err != nil {
+		return nil, err
+	}
+	// The client indicates the end of the query by closing the sending side.
+	if err := stream.Close(); err != nil {
+		return nil, err
+	}
+
+	var lengthBytes [2]byte
+	if _, err := io.ReadFull(stream, lengthBytes[:]); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(lengthBytes[:]))
+	if _, err := io.ReadFull(stream, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *QUICNameServer) sendQuery(ctx context.Context, domain string, option dns_feature.IPOption) {
+	newError(s.name, " querying: ", domain).AtInfo().WriteToLog(session.ExportIDToError(ctx))
+
+	if s.serverName+"." == domain {
+		newError(s.name, " tries to resolve itself! Use IP or set \"hosts\" instead.").AtError().WriteToLog(session.ExportIDToError(ctx))
+		return
+	}
+
+	reqs := buildReqMsgs(domain, option, zeroReqID, genEDNS0Options(s.clientIP))
+
+	var deadline time.Time
+	if d, ok := ctx.Deadline(); ok {
+		deadline = d
+	} else {
+		deadline = time.Now().Add(time.Second * 5)
+	}
+
+	for _, req := range reqs {
+		go func(r *dnsRequest) {
+			// generate new context for each req, using same context
+			// may cause reqs all aborted if any one encounter an error
+			dnsCtx := context.Background()
+
+			// reserve internal dns server requested Inbound
+			if inbound := session.InboundFromContext(ctx); inbound != nil {
+				dnsCtx = session.ContextWithInbound(dnsCtx, inbound)
+			}
+
+			var cancel context.CancelFunc
+			dnsCtx, cancel = context.WithDeadline(dnsCtx, deadline)
+			defer cancel()
+
+			b, err := dns.PackMessage(r.msg)
+			if err != nil {
+				newError("failed to pack dns query for ", domain).Base(err).AtError().WriteToLog()
+				return
+			}
+			resp, err := s.exchange(dnsCtx, b.Bytes())
+			b.Release()
+			if err != nil {
+				newError("failed to retrieve response for ", domain).Base(err).AtError().WriteToLog()
+				return
+			}
+			rec, err := parseResponse(resp)
+			if err != nil {
+				newError("failed to handle DOQ response for ", domain).Base(err).AtError().WriteToLog()
+				return
+			}
+			s.cacheController.updateIP(r, rec)
+		}(req)
+	}
+}
+
+// QueryIP implements Server.
+func (s *QUICNameServer) QueryIP(ctx context.Context, domain string, option dns_feature.IPOption) ([]net.IP, error) {
+	return s.cacheController.queryIP(ctx, domain, option, s.sendQuery)
+}
+
+func (s *QUICNameServer) exchangeMessage(ctx context.Context, query *dnsmessage.Message) (*dnsmessage.Message, error) {
+	q := query.Questions[0]
+	newError(s.name, " querying: ", q.Name, " ", q.Type).AtInfo().WriteToLog(session.ExportIDToError(ctx))
+
+	b, err := packQuery(query, zeroReqID(), s.clientIP)
+	if err != nil {
+		return nil, newError("failed to pack dns query").Base(err)
+	}
+	resp, err := s.exchange(ctx, b.Bytes())
+	b.Release()
+	if err != nil {
+		return nil, newError("failed to retrieve response").Base(err)
+	}
+	return unpackResponse(resp, zeroReqID())
+}
+
+// QueryMessage implements messageClient.
+func (s *QUICNameServer) QueryMessage(ctx context.Context, query *dnsmessage.Message) (*dnsmessage.Message, error) {
+	return s.cacheController.queryMessage(ctx, query, s.exchangeMessage)
+}
+
+// linkPacketConn is a net.PacketConn exchanging packets with a single
+// destination through a dispatched link.
+type linkPacketConn struct {
+	link   *transport.Link
+	remote net.Addr
+	cache  buf.MultiBuffer
+	done   *done.Instance
+}
+
+func (c *linkPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	for c.cache.IsEmpty() {
+		mb, err := c.link.Reader.ReadMultiBuffer()
+		if err != nil {
+			return 0, nil, err
+		}
+		c.cache = mb
+	}
+	var b *buf.Buffer
+	c.cache, b = buf.SplitFirst(c.cache)
+	n := copy(p, b.Bytes())
+	b.Release()
+	return n, c.remote, nil
+}
+
+func (c *linkPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if c.done.Done() {
+		return 0, io.ErrClosedPipe
+	}
+	b := buf.New()
+	if _, err := b.Write(p); err != nil {
+		b.Release()
+		return 0, err
+	}
+	if err := c.link.Writer.WriteMultiBuffer(buf.MultiBuffer{b}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *linkPacketConn) Close() error {
+	if c.done.Done() {
+		return nil
+	}
+	c.done.Close()
+	common.Close(c.link.Writer)
+	common.Interrupt(c.link.Reader)
+	buf.ReleaseMulti(c.cache)
+	return nil
+}
+
+func (c *linkPacketConn) LocalAddr() net.Addr {
+	return &net.UDPAddr{
+		IP:   []byte{0, 0, 0, 0},
+		Port: 0,
+	}
+}
+
+func (c *linkPacketConn) SetDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *linkPacketConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *linkPacketConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
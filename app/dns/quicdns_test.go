@@ -0,0 +1,243 @@
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	dns_feature "github.com/xtls/xray-core/features/dns"
+)
+
+// doqServer is a minimal DNS over QUIC server answering A queries from a
+// static table.
+type doqServer struct {
+	listener quic.EarlyListener
+	answers  map[string][4]byte
+
+	sync.Mutex
+	sessions []quic.EarlySession
+	badIDs   int
+}
+
+func newDoQServer(t *testing.T, ca *cert.Certificate) *doqServer {
+	serverCert := cert.MustGenerate(ca, cert.DNSNames("dns.example.com"))
+	certPEM, keyPEM := serverCert.ToPEM()
+	keyPair, err := tls.X509KeyPair(certPEM, keyPEM)
+	common.Must(err)
+
+	listener, err := quic.ListenAddrEarly("127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{keyPair},
+		NextProtos:   []string{"doq"},
+	}, &quic.Config{})
+	common.Must(err)
+
+	s := &doqServer{
+		listener: listener,
+		answers: map[string][4]byte{
+			"google.com.":        {8, 8, 8, 8},
+			"api.google.com.":    {8, 8, 7, 7},
+			"v2.api.google.com.": {8, 8, 7, 8},
+		},
+	}
+	go func() {
+		for {
+			sess, err := listener.Accept(context.Background())
+			if err != nil {
+				return
+			}
+			s.Lock()
+			s.sessions = append(s.sessions, sess)
+			s.Unlock()
+			go s.serveSession(sess)
+		}
+	}()
+	return s
+}
+
+func (s *doqServer) serveSession(sess quic.EarlySession) {
+	for {
+		stream, err := sess.AcceptStream(context.Background())
+		if err != nil {
+			return
+		}
+		go s.serveStream(stream)
+	}
+}
+
+func (s *doqServer) serveStream(stream quic.Stream) {
+	defer stream.Close()
+
+	var lengthBytes [2]byte
+	if _, err := io.ReadFull(stream, lengthBytes[:]); err != nil {
+		return
+	}
+	b := make([]byte, binary.BigEndian.Uint16(lengthBytes[:]))
+	if _, err := io.ReadFull(stream, b); err != nil {
+		return
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(b); err != nil || len(msg.Questions) != 1 {
+		return
+	}
+	if msg.ID != 0 {
+		s.Lock()
+		s.badIDs++
+		s.Unlock()
+	}
+	out, err := answerQuery(s.answers, &msg)
+	if err != nil {
+		return
+	}
+	stream.Write(out)
+}
+
+// answerQuery returns the length-prefixed response to the query with answers
+// of A records from the table.
+func answerQuery(answers map[string][4]byte, msg *dnsmessage.Message) ([]byte, error) {
+	q := msg.Questions[0]
+	msg.Header.Response = true
+	msg.Header.RecursionAvailable = true
+	msg.Additionals = nil
+	if ip, found := answers[q.Name.String()]; found && q.Type == dnsmessage.TypeA {
+		msg.Answers = []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 600},
+			Body:   &dnsmessage.AResource{A: ip},
+		}}
+	}
+	resp, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 2+len(resp))
+	binary.BigEndian.PutUint16(out, uint16(len(resp)))
+	copy(out[2:], resp)
+	return out, nil
+}
+
+// closeSessions closes all sessions accepted so far, and returns their count.
+func (s *doqServer) closeSessions() int {
+	s.Lock()
+	defer s.Unlock()
+	for _, sess := range s.sessions {
+		sess.CloseWithError(0, "")
+	}
+	n := len(s.sessions)
+	s.sessions = nil
+	return n
+}
+
+func TestQUICLocalNameServer(t *testing.T) {
+	ca := cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign))
+	server := newDoQServer(t, ca)
+	defer server.listener.Close()
+
+	u, err := url.Parse("quic+local://" + server.listener.Addr().String())
+	common.Must(err)
+	s, err := NewQUICLocalNameServer(u, nil, nil)
+	common.Must(err)
+	if s.Name() != "DOQL//"+server.listener.Addr().String() {
+		t.Error("unexpected name: ", s.Name())
+	}
+
+	caCert, err := x509.ParseCertificate(ca.Certificate)
+	common.Must(err)
+	s.tlsConfig.RootCAs = x509.NewCertPool()
+	s.tlsConfig.RootCAs.AddCert(caCert)
+	s.tlsConfig.ServerName = "dns.example.com"
+
+	query := func(domain, ip string) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		ips, err := s.QueryIP(ctx, domain, dns_feature.IPOption{
+			IPv4Enable: true,
+		})
+		if err != nil {
+			t.Error("failed to query ", domain, ": ", err)
+			return
+		}
+		if len(ips) != 1 || ips[0].String() != ip {
+			t.Error("expect ", ip, " for ", domain, " but got ", ips)
+		}
+	}
+
+	// Concurrent queries are sent as streams of the same session.
+	var wg sync.WaitGroup
+	for domain, ip := range map[string]string{
+		"google.com":     "8.8.8.8",
+		"api.google.com": "8.8.7.7",
+	} {
+		wg.Add(1)
+		go func(domain, ip string) {
+			defer wg.Done()
+			query(domain, ip)
+		}(domain, ip)
+	}
+	wg.Wait()
+
+	if n := server.closeSessions(); n != 1 {
+		t.Error("expect 1 session, but got ", n)
+	}
+	time.Sleep(time.Millisecond * 200)
+
+	// The client reconnects after the session is closed by the server.
+	query("v2.api.google.com", "8.8.7.8")
+	if n := server.closeSessions(); n != 1 {
+		t.Error("expect 1 session after reconnecting, but got ", n)
+	}
+
+	server.Lock()
+	if server.badIDs != 0 {
+		t.Error("expect message ID 0 in all queries, but got ", server.badIDs, " other")
+	}
+	server.Unlock()
+}
+
+func TestQUICNameServerClose(t *testing.T) {
+	ca := cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign))
+	server := newDoQServer(t, ca)
+	defer server.listener.Close()
+
+	u, err := url.Parse("quic+local://" + server.listener.Addr().String())
+	common.Must(err)
+	s, err := NewQUICLocalNameServer(u, nil, nil)
+	common.Must(err)
+	caCert, err := x509.ParseCertificate(ca.Certificate)
+	common.Must(err)
+	s.tlsConfig.RootCAs = x509.NewCertPool()
+	s.tlsConfig.RootCAs.AddCert(caCert)
+	s.tlsConfig.ServerName = "dns.example.com"
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if _, err := s.QueryIP(ctx, "google.com", ipv4Only); err != nil {
+		t.Fatal("failed to query: ", err)
+	}
+	s.sessionAccess.Lock()
+	sess, conn := s.session, s.packetConn
+	s.sessionAccess.Unlock()
+
+	common.Must(s.Close())
+	select {
+	case <-sess.Context().Done():
+	case <-time.After(time.Second):
+		t.Error("expect the session closed")
+	}
+	if _, err := conn.WriteTo([]byte{0}, conn.LocalAddr()); err == nil {
+		t.Error("expect the packet connection closed")
+	}
+	if _, err := s.exchange(ctx, []byte{0}); err == nil {
+		t.Error("expect queries to fail after closing")
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/xtls/xray-core/features"
 	"github.com/xtls/xray-core/features/dns"
 	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/transport/internet"
 )
 
@@ -37,6 +38,49 @@ type Server struct {
 	domainMatcher strmatcher.IndexMatcher
 	matcherInfos  []DomainMatcherInfo // matcherIdx -> DomainMatcherInfo
 	tag           string
+	nameServers   []*NameServer // nameServerIdx -> NameServer
+	clientIndices []int         // nameServerIdx -> clientIdx
+	watcher       *router.SourceWatcher
+	hostsFiles    []string
+	cache         *Cache
+	clientOptions []clientOption // clientIdx -> clientOption
+
+	disableFallback  bool
+	parallelQuery    bool
+	preferenceWindow time.Duration
+}
+
+const defaultQueryTimeout = time.Second * 4
+
+// clientOption is the per-server query option.
+type clientOption struct {
+	queryStrategy QueryStrategy
+	timeout       time.Duration
+	skipFallback  bool
+}
+
+func newClientOption(ns *NameServer) clientOption {
+	opt := clientOption{
+		queryStrategy: ns.QueryStrategy,
+		timeout:       time.Duration(ns.Timeout) * time.Millisecond,
+		skipFallback:  ns.SkipFallback,
+	}
+	if opt.timeout == 0 {
+		opt.timeout = defaultQueryTimeout
+	}
+	return opt
+}
+
+// ipOption returns the IP option to query with, restricted by the query
+// strategy. It returns false if no address family is left to query.
+func (o clientOption) ipOption(option dns.IPOption) (dns.IPOption, bool) {
+	switch o.queryStrategy {
+	case QueryStrategy_USE_IP4:
+		option.IPv6Enable = false
+	case QueryStrategy_USE_IP6:
+		option.IPv4Enable = false
+	}
+	return option, option.IPv4Enable || option.IPv6Enable
 }
 
 // DomainMatcherInfo contains information attached to index returned by Server.domainMatcher
@@ -78,6 +122,10 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 		clients: make([]Client, 0, len(config.NameServers)+len(config.NameServer)),
 		ctx:     ctx,
 		tag:     config.Tag,
+
+		disableFallback:  config.DisableFallback,
+		parallelQuery:    config.ParallelQuery,
+		preferenceWindow: time.Duration(config.PreferenceWindow) * time.Millisecond,
 	}
 	if server.tag == "" {
 		server.tag = generateRandomTag()
@@ -94,11 +142,42 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 		return nil, newError("failed to create hosts").Base(err)
 	}
 	server.hosts = hosts
+	server.hostsFiles = config.HostsFiles
+	if len(server.hostsFiles) > 0 {
+		mappings, err := loadHostsFiles(server.hostsFiles)
+		if err != nil {
+			return nil, err
+		}
+		if err := hosts.SetFileHosts(mappings); err != nil {
+			return nil, newError("failed to create hosts from files").Base(err)
+		}
+	}
+
+	server.cache = NewCache(CacheOption{
+		Disabled:   config.DisableCache,
+		MinTTL:     time.Duration(config.MinTtl) * time.Second,
+		MaxTTL:     time.Duration(config.MaxTtl) * time.Second,
+		Size:       int(config.CacheSize),
+		ServeStale: config.ServeStale,
+		StaleTTL:   time.Duration(config.StaleTtl) * time.Second,
+		Prefetch:   config.Prefetch,
+	})
+	common.Must(core.RequireFeatures(ctx, func(sm stats.Manager) {
+		server.cache.SetStatsManager(sm)
+	}))
 
 	addNameServer := func(ns *NameServer) int {
 		endpoint := ns.Address
 		address := endpoint.Address.AsAddress()
 
+		clientIP := server.clientIP
+		if len(ns.ClientIp) > 0 {
+			if len(ns.ClientIp) != net.IPv4len && len(ns.ClientIp) != net.IPv6len {
+				log.Fatalln(newError("DNS config error").Base(newError("unexpected IP length ", len(ns.ClientIp))))
+			}
+			clientIP = net.IP(ns.ClientIp)
+		}
+
 		switch {
 		case address.Family().IsDomain() && address.Domain() == "localhost":
 			server.clients = append(server.clients, NewLocalNameServer())
@@ -126,7 +205,7 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 			if err != nil {
 				log.Fatalln(newError("DNS config error").Base(err))
 			}
-			server.clients = append(server.clients, NewDoHLocalNameServer(u, server.clientIP))
+			server.clients = append(server.clients, NewDoHLocalNameServer(u, server.cache, clientIP))
 
 		case address.Family().IsDomain() && strings.HasPrefix(address.Domain(), "https://"):
 			// DOH Remote mode
@@ -139,7 +218,65 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 
 			// need the core dispatcher, register DOHClient at callback
 			common.Must(core.RequireFeatures(ctx, func(d routing.Dispatcher) {
-				c, err := NewDoHNameServer(u, d, server.clientIP)
+				c, err := NewDoHNameServer(u, d, server.cache, clientIP)
+				if err != nil {
+					log.Fatalln(newError("DNS config error").Base(err))
+				}
+				server.clients[idx] = c
+			}))
+
+		case address.Family().IsDomain() && (strings.HasPrefix(address.Domain(), "tcp+local://") || strings.HasPrefix(address.Domain(), "tls+local://")):
+			// DNS over TCP or TLS Local mode
+			u, err := url.Parse(address.Domain())
+			if err != nil {
+				log.Fatalln(newError("DNS config error").Base(err))
+			}
+			c, err := NewTCPLocalNameServer(u, server.cache, clientIP)
+			if err != nil {
+				log.Fatalln(newError("DNS config error").Base(err))
+			}
+			server.clients = append(server.clients, c)
+
+		case address.Family().IsDomain() && (strings.HasPrefix(address.Domain(), "tcp://") || strings.HasPrefix(address.Domain(), "tls://")):
+			// DNS over TCP or TLS Remote mode
+			u, err := url.Parse(address.Domain())
+			if err != nil {
+				log.Fatalln(newError("DNS config error").Base(err))
+			}
+			idx := len(server.clients)
+			server.clients = append(server.clients, nil)
+
+			common.Must(core.RequireFeatures(ctx, func(d routing.Dispatcher) {
+				c, err := NewTCPNameServer(u, d, server.cache, clientIP)
+				if err != nil {
+					log.Fatalln(newError("DNS config error").Base(err))
+				}
+				server.clients[idx] = c
+			}))
+
+		case address.Family().IsDomain() && strings.HasPrefix(address.Domain(), "quic+local://"):
+			// DNS over QUIC Local mode
+			u, err := url.Parse(address.Domain())
+			if err != nil {
+				log.Fatalln(newError("DNS config error").Base(err))
+			}
+			c, err := NewQUICLocalNameServer(u, server.cache, clientIP)
+			if err != nil {
+				log.Fatalln(newError("DNS config error").Base(err))
+			}
+			server.clients = append(server.clients, c)
+
+		case address.Family().IsDomain() && strings.HasPrefix(address.Domain(), "quic://"):
+			// DNS over QUIC Remote mode
+			u, err := url.Parse(address.Domain())
+			if err != nil {
+				log.Fatalln(newError("DNS config error").Base(err))
+			}
+			idx := len(server.clients)
+			server.clients = append(server.clients, nil)
+
+			common.Must(core.RequireFeatures(ctx, func(d routing.Dispatcher) {
+				c, err := NewQUICNameServer(u, d, server.cache, clientIP)
 				if err != nil {
 					log.Fatalln(newError("DNS config error").Base(err))
 				}
@@ -160,11 +297,12 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 				server.clients = append(server.clients, nil)
 
 				common.Must(core.RequireFeatures(ctx, func(d routing.Dispatcher) {
-					server.clients[idx] = NewClassicNameServer(dest, d, server.clientIP)
+					server.clients[idx] = NewClassicNameServer(dest, d, server.cache, clientIP)
 				}))
 			}
 		}
 		server.ipIndexMap = append(server.ipIndexMap, nil)
+		server.clientOptions = append(server.clientOptions, newClientOption(ns))
 		return len(server.clients) - 1
 	}
 
@@ -176,80 +314,196 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 	}
 
 	if len(config.NameServer) > 0 {
-		clientIndices := []int{}
-		domainRuleCount := 0
 		for _, ns := range config.NameServer {
 			idx := addNameServer(ns)
-			clientIndices = append(clientIndices, idx)
-			domainRuleCount += len(ns.PrioritizedDomain)
-		}
-
-		domainRules := make([][]string, len(server.clients))
-		domainMatcher := &strmatcher.MatcherGroup{}
-		matcherInfos := make([]DomainMatcherInfo, domainRuleCount+1) // matcher index starts from 1
-		var geoIPMatcherContainer router.GeoIPMatcherContainer
-		for nidx, ns := range config.NameServer {
-			idx := clientIndices[nidx]
-
-			// Establish domain rule matcher
-			rules := []string{}
-			ruleCurr := 0
-			ruleIter := 0
-			for _, domain := range ns.PrioritizedDomain {
-				matcher, err := toStrMatcher(domain.Type, domain.Domain)
-				if err != nil {
-					return nil, newError("failed to create prioritized domain").Base(err).AtWarning()
+			server.clientIndices = append(server.clientIndices, idx)
+		}
+		server.nameServers = config.NameServer
+		if err := server.buildMatchers(); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.ReloadInterval > 0 {
+		var sources [][]*router.RuleSource
+		for _, ns := range config.NameServer {
+			sources = append(sources, ns.DomainSource, ns.GeoipSource)
+		}
+		files := router.SourceFiles(sources...)
+		files = append(files, server.hostsFiles...)
+		if len(files) > 0 {
+			server.watcher = router.NewSourceWatcher(time.Duration(config.ReloadInterval)*time.Second, files, server.reload)
+		}
+	}
+
+	if len(server.clients) == 0 {
+		server.clients = append(server.clients, NewLocalNameServer())
+		server.ipIndexMap = append(server.ipIndexMap, nil)
+		server.clientOptions = append(server.clientOptions, clientOption{timeout: defaultQueryTimeout})
+	}
+
+	return server, nil
+}
+
+var sourceTypeMap = map[router.Domain_Type]DomainMatchingType{
+	router.Domain_Full:   DomainMatchingType_Full,
+	router.Domain_Domain: DomainMatchingType_Subdomain,
+	router.Domain_Plain:  DomainMatchingType_Keyword,
+	router.Domain_Regex:  DomainMatchingType_Regex,
+}
+
+// buildMatchers builds domain and expected IP matchers of name servers, and
+// replaces the current ones only if all of them are built successfully.
+func (s *Server) buildMatchers() error {
+	domainRuleCount := 0
+	for _, ns := range s.nameServers {
+		domainRuleCount += len(ns.PrioritizedDomain)
+	}
+
+	ipIndexMap := make([]*MultiGeoIPMatcher, len(s.clients))
+	domainRules := make([][]string, len(s.clients))
+	domainMatcher := &strmatcher.MatcherGroup{}
+	matcherInfos := make([]DomainMatcherInfo, domainRuleCount+1) // matcher index starts from 1
+	var geoIPMatcherContainer router.GeoIPMatcherContainer
+	loader := router.NewSourceLoader()
+	defer loader.Release()
+
+	addMatcher := func(matcher strmatcher.Matcher, clientIdx int, domainRuleIdx int) {
+		midx := domainMatcher.Add(matcher)
+		if midx >= uint32(len(matcherInfos)) { // This rarely happens according to current matcher's implementation
+			newError("expanding domain matcher info array to size ", midx, " when adding ", matcher).AtDebug().WriteToLog()
+			matcherInfos = append(matcherInfos, make([]DomainMatcherInfo, midx-uint32(len(matcherInfos))+1)...)
+		}
+		info := &matcherInfos[midx]
+		info.clientIdx = uint16(clientIdx)
+		info.domainRuleIdx = uint16(domainRuleIdx)
+	}
+
+	for nidx, ns := range s.nameServers {
+		idx := s.clientIndices[nidx]
+
+		// Establish domain rule matcher
+		rules := []string{}
+		ruleCurr := 0
+		ruleIter := 0
+		for _, domain := range ns.PrioritizedDomain {
+			matcher, err := toStrMatcher(domain.Type, domain.Domain)
+			if err != nil {
+				return newError("failed to create prioritized domain").Base(err).AtWarning()
+			}
+			if ruleCurr < len(ns.OriginalRules) {
+				addMatcher(matcher, idx, ruleCurr)
+				rule := ns.OriginalRules[ruleCurr]
+				if ruleCurr >= len(rules) {
+					rules = append(rules, rule.Rule)
 				}
-				midx := domainMatcher.Add(matcher)
-				if midx >= uint32(len(matcherInfos)) { // This rarely happens according to current matcher's implementation
-					newError("expanding domain matcher info array to size ", midx, " when adding ", matcher).AtDebug().WriteToLog()
-					matcherInfos = append(matcherInfos, make([]DomainMatcherInfo, midx-uint32(len(matcherInfos))+1)...)
+				ruleIter++
+				if ruleIter >= int(rule.Size) {
+					ruleIter = 0
+					ruleCurr++
 				}
-				info := &matcherInfos[midx]
-				info.clientIdx = uint16(idx)
-				if ruleCurr < len(ns.OriginalRules) {
-					info.domainRuleIdx = uint16(ruleCurr)
-					rule := ns.OriginalRules[ruleCurr]
-					if ruleCurr >= len(rules) {
-						rules = append(rules, rule.Rule)
-					}
-					ruleIter++
-					if ruleIter >= int(rule.Size) {
-						ruleIter = 0
-						ruleCurr++
-					}
-				} else { // No original rule, generate one according to current domain matcher (majorly for compatibility with tests)
-					info.domainRuleIdx = uint16(len(rules))
-					rules = append(rules, matcher.String())
+			} else { // No original rule, generate one according to current domain matcher (majorly for compatibility with tests)
+				addMatcher(matcher, idx, len(rules))
+				rules = append(rules, matcher.String())
+			}
+		}
+		for _, source := range ns.DomainSource {
+			domains, err := loader.LoadDomains(source)
+			if err != nil {
+				return newError("failed to load prioritized domains from ", source.ConfigString()).Base(err).AtWarning()
+			}
+			ruleIdx := len(rules)
+			rules = append(rules, source.ConfigString())
+			for _, domain := range domains {
+				matcher, err := toStrMatcher(sourceTypeMap[domain.Type], domain.Value)
+				if err != nil {
+					return newError("failed to create prioritized domain").Base(err).AtWarning()
 				}
+				addMatcher(matcher, idx, ruleIdx)
 			}
-			domainRules[idx] = rules
-
-			// only add to ipIndexMap if GeoIP is configured
-			if len(ns.Geoip) > 0 {
-				var matchers []*router.GeoIPMatcher
-				for _, geoip := range ns.Geoip {
-					matcher, err := geoIPMatcherContainer.Add(geoip)
-					if err != nil {
-						return nil, newError("failed to create ip matcher").Base(err).AtWarning()
-					}
-					matchers = append(matchers, matcher)
+		}
+		domainRules[idx] = rules
+
+		// only add to ipIndexMap if GeoIP is configured
+		if len(ns.Geoip) > 0 || len(ns.GeoipSource) > 0 {
+			var matchers []*router.GeoIPMatcher
+			for _, geoip := range ns.Geoip {
+				matcher, err := geoIPMatcherContainer.Add(geoip)
+				if err != nil {
+					return newError("failed to create ip matcher").Base(err).AtWarning()
 				}
-				matcher := &MultiGeoIPMatcher{matchers: matchers}
-				server.ipIndexMap[idx] = matcher
+				matchers = append(matchers, matcher)
 			}
+			for _, source := range ns.GeoipSource {
+				cidrs, err := loader.LoadCIDRs(source)
+				if err != nil {
+					return newError("failed to load expected IPs from ", source.ConfigString()).Base(err).AtWarning()
+				}
+				matcher, err := geoIPMatcherContainer.Add(&router.GeoIP{Cidr: cidrs})
+				if err != nil {
+					return newError("failed to create ip matcher").Base(err).AtWarning()
+				}
+				matchers = append(matchers, matcher)
+			}
+			ipIndexMap[idx] = &MultiGeoIPMatcher{matchers: matchers}
 		}
-		server.domainRules = domainRules
-		server.domainMatcher = domainMatcher
-		server.matcherInfos = matcherInfos
 	}
 
-	if len(server.clients) == 0 {
-		server.clients = append(server.clients, NewLocalNameServer())
-		server.ipIndexMap = append(server.ipIndexMap, nil)
+	s.Lock()
+	s.ipIndexMap = ipIndexMap
+	s.domainRules = domainRules
+	s.domainMatcher = domainMatcher
+	s.matcherInfos = matcherInfos
+	s.Unlock()
+	return nil
+}
+
+func (s *Server) reload(changed map[string]bool) {
+	hostsChanged := false
+	for _, file := range s.hostsFiles {
+		if changed[file] {
+			hostsChanged = true
+			delete(changed, file)
+		}
+	}
+	if hostsChanged {
+		s.reloadHosts()
 	}
+	if len(changed) == 0 {
+		return
+	}
+	if err := s.buildMatchers(); err != nil {
+		newError("failed to reload DNS rules, keeping the previous ones").Base(err).AtWarning().WriteToLog()
+		return
+	}
+	newError("reloaded DNS rules from ", len(changed), " changed file(s)").AtInfo().WriteToLog()
+}
 
-	return server, nil
+func (s *Server) reloadHosts() {
+	mappings, err := loadHostsFiles(s.hostsFiles)
+	if err == nil {
+		err = s.hosts.SetFileHosts(mappings)
+	}
+	if err != nil {
+		newError("failed to reload hosts files, keeping the previous hosts").Base(err).AtWarning().WriteToLog()
+		return
+	}
+	newError("reloaded ", len(mappings), " hosts from files").AtInfo().WriteToLog()
+}
+
+// AddHosts adds static hosts at runtime. They are merged with hosts from the
+// config and hosts files.
+func (s *Server) AddHosts(mappings []*Config_HostMapping) error {
+	if err := s.hosts.AddHosts(mappings); err != nil {
+		return newError("failed to add hosts").Base(err)
+	}
+	return nil
+}
+
+// RemoveHosts removes static hosts of the given domains added by AddHosts, and
+// returns the number of removed mappings.
+func (s *Server) RemoveHosts(domains []string) int {
+	return s.hosts.RemoveHosts(domains)
 }
 
 // Type implements common.HasType.
@@ -259,14 +513,41 @@ func (*Server) Type() interface{} {
 
 // Start implements common.Runnable.
 func (s *Server) Start() error {
+	if s.watcher != nil {
+		return s.watcher.Start()
+	}
 	return nil
 }
 
 // Close implements common.Closable.
 func (s *Server) Close() error {
+	for _, client := range s.clients {
+		common.Close(client)
+	}
+	if s.watcher != nil {
+		return s.watcher.Close()
+	}
 	return nil
 }
 
+// Metrics implements stats.MetricsProvider.
+func (s *Server) Metrics() []stats.Metric {
+	return []stats.Metric{
+		{
+			Name:  "xray_dns_cache_entries",
+			Help:  "Number of names in the DNS cache.",
+			Value: float64(s.cache.Len()),
+			Gauge: true,
+		},
+		{
+			Name:  "xray_dns_name_servers",
+			Help:  "Number of configured name servers.",
+			Value: float64(len(s.clients)),
+			Gauge: true,
+		},
+	}
+}
+
 func (s *Server) IsOwnLink(ctx context.Context) bool {
 	inbound := session.InboundFromContext(ctx)
 	return inbound != nil && inbound.Tag == s.tag
@@ -275,9 +556,11 @@ func (s *Server) IsOwnLink(ctx context.Context) bool {
 // Match check dns ip match geoip
 func (s *Server) Match(idx int, client Client, domain string, ips []net.IP) ([]net.IP, error) {
 	var matcher *MultiGeoIPMatcher
+	s.Lock()
 	if idx < len(s.ipIndexMap) {
 		matcher = s.ipIndexMap[idx]
 	}
+	s.Unlock()
 	if matcher == nil {
 		return ips, nil
 	}
@@ -300,15 +583,43 @@ func (s *Server) Match(idx int, client Client, domain string, ips []net.IP) ([]n
 	return newIps, nil
 }
 
-func (s *Server) queryIPTimeout(idx int, client Client, domain string, option dns.IPOption) ([]net.IP, error) {
-	ctx, cancel := context.WithTimeout(s.ctx, time.Second*4)
+func (s *Server) clientOption(idx int) clientOption {
+	if idx < len(s.clientOptions) {
+		return s.clientOptions[idx]
+	}
+	return clientOption{timeout: defaultQueryTimeout}
+}
+
+// requestContext returns a context of the server for queries of a DNS
+// request. Only the source of the inbound in ctx is kept. Note that DoH and
+// QUIC servers reuse connections, which are routed by the first request.
+func (s *Server) requestContext(ctx context.Context) context.Context {
+	if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.Source.IsValid() {
+		return session.ContextWithInbound(s.ctx, &session.Inbound{
+			Source: inbound.Source,
+		})
+	}
+	return s.ctx
+}
+
+// queryContext returns ctx with the inbound of queries to name servers, so that
+// they are routed by the tag of the DNS client and the source of the request.
+func (s *Server) queryContext(ctx context.Context, domain string) context.Context {
 	if len(s.tag) > 0 {
-		ctx = session.ContextWithInbound(ctx, &session.Inbound{
+		inbound := &session.Inbound{
 			Tag: s.tag,
-		})
+		}
+		if r := session.InboundFromContext(ctx); r != nil {
+			inbound.Source = r.Source
+		}
+		ctx = session.ContextWithInbound(ctx, inbound)
 	}
-	ctx = internet.ContextWithLookupDomain(ctx, domain)
-	ips, err := client.QueryIP(ctx, domain, option)
+	return internet.ContextWithLookupDomain(ctx, domain)
+}
+
+func (s *Server) queryIPTimeout(ctx context.Context, idx int, client Client, domain string, option dns.IPOption) ([]net.IP, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.clientOption(idx).timeout)
+	ips, err := client.QueryIP(s.queryContext(ctx, domain), domain, option)
 	cancel()
 
 	if err != nil {
@@ -345,6 +656,10 @@ func toNetIP(ips []net.Address) []net.IP {
 
 // LookupIP implements dns.Client.
 func (s *Server) LookupIP(domain string, option dns.IPOption) ([]net.IP, error) {
+	return s.lookupIP(s.ctx, domain, option)
+}
+
+func (s *Server) lookupIP(ctx context.Context, domain string, option dns.IPOption) ([]net.IP, error) {
 	if domain == "" {
 		return nil, newError("empty domain name")
 	}
@@ -366,15 +681,84 @@ func (s *Server) LookupIP(domain string, option dns.IPOption) ([]net.IP, error)
 		domain = newdomain
 	}
 
+	s.Lock()
+	domainMatcher, matcherInfos, allDomainRules := s.domainMatcher, s.matcherInfos, s.domainRules
+	s.Unlock()
+
+	candidates := s.sortClients(domain, option, domainMatcher, matcherInfos, allDomainRules)
+	if s.parallelQuery && len(candidates) > 1 {
+		return s.queryParallel(s.requestContext(ctx), domain, candidates)
+	}
+
 	var lastErr error
-	var matchedClient Client
-	if s.domainMatcher != nil {
-		indices := s.domainMatcher.Match(domain)
+	for _, c := range candidates {
+		ips, err := s.queryIPTimeout(s.requestContext(ctx), c.idx, c.client, domain, c.option)
+		if len(ips) > 0 {
+			return ips, nil
+		}
+		if !c.fallback && err == dns.ErrEmptyResponse {
+			return nil, err
+		}
+		if err != nil {
+			newError("failed to lookup ip for domain ", domain, " at server ", c.client.Name()).Base(err).WriteToLog()
+			lastErr = err
+		}
+		if c.fallback && err != context.Canceled && err != context.DeadlineExceeded && err != errExpectedIPNonMatch {
+			return nil, err
+		}
+	}
+
+	return nil, newError("returning nil for domain ", domain).Base(lastErr)
+}
+
+// queryCandidate is a name server to query for a domain.
+type queryCandidate struct {
+	idx    int
+	client Client
+	option dns.IPOption
+	// fallback is true if the domain does not match the server.
+	fallback bool
+}
+
+// sortClients returns the name servers to query for domain in order of
+// priority. Servers matching the domain come first, and the others follow as
+// fallback.
+func (s *Server) sortClients(domain string, option dns.IPOption, domainMatcher strmatcher.IndexMatcher, matcherInfos []DomainMatcherInfo, allDomainRules [][]string) []queryCandidate {
+	var candidates []queryCandidate
+	used := make(map[int]bool)
+
+	addCandidate := func(idx int, fallback bool) {
+		client := s.clients[idx]
+		used[idx] = true
+		if !option.FakeEnable && strings.EqualFold(client.Name(), "FakeDNS") {
+			newError("skip DNS resolution for domain ", domain, " at server ", client.Name()).AtDebug().WriteToLog()
+			return
+		}
+		clientOption := s.clientOption(idx)
+		if fallback && clientOption.skipFallback {
+			newError("skip fallback DNS resolution for domain ", domain, " at server ", client.Name()).AtDebug().WriteToLog()
+			return
+		}
+		queryOption, ok := clientOption.ipOption(option)
+		if !ok {
+			newError("skip DNS resolution for domain ", domain, " at server ", client.Name(), " by query strategy").AtDebug().WriteToLog()
+			return
+		}
+		candidates = append(candidates, queryCandidate{
+			idx:      idx,
+			client:   client,
+			option:   queryOption,
+			fallback: fallback,
+		})
+	}
+
+	if domainMatcher != nil {
+		indices := domainMatcher.Match(domain)
 		domainRules := []string{}
 		matchingDNS := []string{}
 		for _, idx := range indices {
-			info := s.matcherInfos[idx]
-			rule := s.domainRules[info.clientIdx][info.domainRuleIdx]
+			info := matcherInfos[idx]
+			rule := allDomainRules[info.clientIdx][info.domainRuleIdx]
 			domainRules = append(domainRules, fmt.Sprintf("%s(DNS idx:%d)", rule, info.clientIdx))
 			matchingDNS = append(matchingDNS, s.clients[info.clientIdx].Name())
 		}
@@ -385,50 +769,29 @@ func (s *Server) LookupIP(domain string, option dns.IPOption) ([]net.IP, error)
 			newError("domain ", domain, " uses following DNS first: ", matchingDNS).AtDebug().WriteToLog()
 		}
 		for _, idx := range indices {
-			clientIdx := int(s.matcherInfos[idx].clientIdx)
-			matchedClient = s.clients[clientIdx]
-			if !option.FakeEnable && strings.EqualFold(matchedClient.Name(), "FakeDNS") {
-				newError("skip DNS resolution for domain ", domain, " at server ", matchedClient.Name()).AtDebug().WriteToLog()
-				continue
-			}
-			ips, err := s.queryIPTimeout(clientIdx, matchedClient, domain, option)
-			if len(ips) > 0 {
-				return ips, nil
-			}
-			if err == dns.ErrEmptyResponse {
-				return nil, err
-			}
-			if err != nil {
-				newError("failed to lookup ip for domain ", domain, " at server ", matchedClient.Name()).Base(err).WriteToLog()
-				lastErr = err
+			clientIdx := int(matcherInfos[idx].clientIdx)
+			if !used[clientIdx] {
+				addCandidate(clientIdx, false)
 			}
 		}
 	}
 
+	if s.disableFallback && len(used) > 0 {
+		return candidates
+	}
+
 	for idx, client := range s.clients {
-		if client == matchedClient {
-			newError("domain ", domain, " at server ", client.Name(), " idx:", idx, " already lookup failed, just ignore").AtDebug().WriteToLog()
-			continue
-		}
-		if !option.FakeEnable && strings.EqualFold(client.Name(), "FakeDNS") {
-			newError("skip DNS resolution for domain ", domain, " at server ", client.Name()).AtDebug().WriteToLog()
+		if used[idx] {
+			newError("domain ", domain, " at server ", client.Name(), " idx:", idx, " already queried, just ignore").AtDebug().WriteToLog()
 			continue
 		}
-		ips, err := s.queryIPTimeout(idx, client, domain, option)
-		if len(ips) > 0 {
-			return ips, nil
-		}
-
-		if err != nil {
-			newError("failed to lookup ip for domain ", domain, " at server ", client.Name()).Base(err).WriteToLog()
-			lastErr = err
-		}
-		if err != context.Canceled && err != context.DeadlineExceeded && err != errExpectedIPNonMatch {
-			return nil, err
+		addCandidate(idx, true)
+		if s.disableFallback && len(candidates) > 0 {
+			// Unmatched domains are only queried at the first server.
+			break
 		}
 	}
-
-	return nil, newError("returning nil for domain ", domain).Base(lastErr)
+	return candidates
 }
 
 func init() {
@@ -1,23 +1,31 @@
 package dns_test
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/miekg/dns"
+	"golang.org/x/net/dns/dnsmessage"
 
 	"github.com/xtls/xray-core/app/dispatcher"
 	. "github.com/xtls/xray-core/app/dns"
+	"github.com/xtls/xray-core/app/dns/fakedns"
 	"github.com/xtls/xray-core/app/policy"
 	"github.com/xtls/xray-core/app/proxyman"
 	_ "github.com/xtls/xray-core/app/proxyman/outbound"
 	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/app/stats"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/core"
 	feature_dns "github.com/xtls/xray-core/features/dns"
+	feature_stats "github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/proxy/freedom"
 	"github.com/xtls/xray-core/testing/servers/udp"
 )
@@ -101,6 +109,10 @@ func (*staticHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 			rr, _ := dns.NewRR("localhost-b. IN A 127.0.0.4")
 			ans.Answer = append(ans.Answer, rr)
 
+		case q.Name == "google.com." && q.Qtype == dns.TypeTXT:
+			rr, _ := dns.NewRR("google.com. IN TXT \"v=spf1 -all\"")
+			ans.Answer = append(ans.Answer, rr)
+
 		case q.Name == "Mijia\\ Cloud." && q.Qtype == dns.TypeA:
 			rr, _ := dns.NewRR("Mijia\\ Cloud. IN A 127.0.0.1")
 			ans.Answer = append(ans.Answer, rr)
@@ -502,6 +514,70 @@ func TestStaticHostDomain(t *testing.T) {
 	dnsServer.Shutdown()
 }
 
+func TestHostsFiles(t *testing.T) {
+	hostsFile := filepath.Join(t.TempDir(), "hosts")
+	common.Must(os.WriteFile(hostsFile, []byte("10.0.0.1 service.test\nalias.test service.test\n"), 0o600))
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				HostsFiles:     []string{hostsFile},
+				ReloadInterval: 1,
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	client := v.GetFeature(feature_dns.ClientType()).(*Server)
+	lookup := func(domain string) []net.IP {
+		ips, err := client.LookupIP(domain, feature_dns.IPOption{
+			IPv4Enable: true,
+			IPv6Enable: true,
+		})
+		if err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+		return ips
+	}
+
+	if r := cmp.Diff(lookup("alias.test"), []net.IP{{10, 0, 0, 1}}); r != "" {
+		t.Error(r)
+	}
+
+	// Added hosts are merged with hosts from files, and proxied domains are
+	// resolved across them.
+	common.Must(client.AddHosts([]*Config_HostMapping{
+		{
+			Type:          DomainMatchingType_Subdomain,
+			Domain:        "discovered.test",
+			ProxiedDomain: "alias.test",
+		},
+	}))
+	if r := cmp.Diff(lookup("a.discovered.test"), []net.IP{{10, 0, 0, 1}}); r != "" {
+		t.Error(r)
+	}
+
+	common.Must(os.WriteFile(hostsFile, []byte("10.0.0.2 service.test\nalias.test service.test\n"), 0o600))
+	future := time.Now().Add(time.Minute)
+	common.Must(os.Chtimes(hostsFile, future, future))
+	time.Sleep(time.Second * 2)
+
+	if r := cmp.Diff(lookup("a.discovered.test"), []net.IP{{10, 0, 0, 2}}); r != "" {
+		t.Error(r)
+	}
+
+	if n := client.RemoveHosts([]string{"discovered.test"}); n != 1 {
+		t.Error("expect 1 removed host, but got ", n)
+	}
+}
+
 func TestIPMatch(t *testing.T) {
 	port := udp.PickPort()
 
@@ -1054,3 +1130,357 @@ func TestMultiMatchPrioritizedDomain(t *testing.T) {
 		t.Error("DNS query doesn't finish in 2 seconds.")
 	}
 }
+
+func TestNameServerOptions(t *testing.T) {
+	port := udp.PickPort()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &staticHandler{},
+		UDPSize: 1200,
+	}
+
+	go dnsServer.ListenAndServe()
+	time.Sleep(time.Second)
+
+	deadPort := udp.PickPort()
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				NameServer: []*NameServer{
+					{
+						Address: &net.Endpoint{
+							Network: net.Network_UDP,
+							Address: &net.IPOrDomain{
+								Address: &net.IPOrDomain_Ip{
+									Ip: []byte{127, 0, 0, 1},
+								},
+							},
+							Port: uint32(port),
+						},
+						PrioritizedDomain: []*NameServer_PriorityDomain{
+							{Type: DomainMatchingType_Full, Domain: "google.com"},
+						},
+						QueryStrategy: QueryStrategy_USE_IP4,
+						ClientIp:      []byte{7, 8, 9, 10},
+					},
+					{
+						Address: &net.Endpoint{
+							Network: net.Network_UDP,
+							Address: &net.IPOrDomain{
+								Address: &net.IPOrDomain_Ip{
+									Ip: []byte{127, 0, 0, 1},
+								},
+							},
+							Port: uint32(deadPort),
+						},
+						Timeout: 200,
+					},
+					{
+						Address: &net.Endpoint{
+							Network: net.Network_UDP,
+							Address: &net.IPOrDomain{
+								Address: &net.IPOrDomain_Ip{
+									Ip: []byte{127, 0, 0, 1},
+								},
+							},
+							Port: uint32(port),
+						},
+						SkipFallback: true,
+					},
+				},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.Client)
+
+	{
+		// Client IP of the first server is sent.
+		ips, err := client.LookupIP("google.com", feature_dns.IPOption{
+			IPv4Enable: true,
+			IPv6Enable: true,
+			FakeEnable: false,
+		})
+		if err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+
+		if r := cmp.Diff(ips, []net.IP{{8, 8, 4, 4}}); r != "" {
+			t.Fatal(r)
+		}
+	}
+
+	{
+		// The first server is skipped by its query strategy, the second one times
+		// out early, and the third one is skipped for fallback.
+		start := time.Now()
+		_, err := client.LookupIP("ipv6.google.com", feature_dns.IPOption{
+			IPv4Enable: false,
+			IPv6Enable: true,
+			FakeEnable: false,
+		})
+		if err == nil {
+			t.Fatal("expect error, but got nil")
+		}
+		if elapsed := time.Since(start); elapsed > time.Second*2 {
+			t.Error("expect the timeout of the second server, but took ", elapsed)
+		}
+	}
+
+	{
+		ips, err := client.LookupIP("ipv6.google.com", feature_dns.IPOption{
+			IPv4Enable: true,
+			IPv6Enable: true,
+			FakeEnable: false,
+		})
+		if err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+
+		if r := cmp.Diff(ips, []net.IP{{8, 8, 8, 7}}); r != "" {
+			t.Fatal(r)
+		}
+	}
+
+	dnsServer.Shutdown()
+}
+
+func TestMessageQuery(t *testing.T) {
+	port := udp.PickPort()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &staticHandler{},
+		UDPSize: 1200,
+	}
+
+	go dnsServer.ListenAndServe()
+	time.Sleep(time.Second)
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				NameServer: []*NameServer{
+					{
+						Address: &net.Endpoint{
+							Network: net.Network_UDP,
+							Address: net.NewIPOrDomain(net.DomainAddress("fakedns")),
+							Port:    53,
+						},
+					},
+					{
+						Address: &net.Endpoint{
+							Network: net.Network_UDP,
+							Address: &net.IPOrDomain{
+								Address: &net.IPOrDomain_Ip{
+									Ip: []byte{127, 0, 0, 1},
+								},
+							},
+							Port: uint32(port),
+						},
+					},
+				},
+				StaticHosts: []*Config_HostMapping{
+					{
+						Type:          DomainMatchingType_Full,
+						Domain:        "example.com",
+						ProxiedDomain: "google.com",
+					},
+					{
+						Type:   DomainMatchingType_Full,
+						Domain: "static.example.com",
+						Ip:     [][]byte{{127, 0, 0, 1}},
+					},
+				},
+			}),
+			serial.ToTypedMessage(&fakedns.FakeDnsPool{
+				IpPool:  "198.18.0.0/16",
+				LruSize: 16,
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.MessageClient)
+
+	query := func(name string, qType dnsmessage.Type) *dnsmessage.Message {
+		t.Helper()
+		resp, err := client.LookupMessage(context.Background(), &dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: 1234, RecursionDesired: true},
+			Questions: []dnsmessage.Question{{Name: dnsmessage.MustNewName(name), Type: qType, Class: dnsmessage.ClassINET}},
+		})
+		if err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+		if resp.ID != 1234 || !resp.Response || resp.Questions[0].Name.String() != name {
+			t.Fatal("unexpected response header: ", resp.Header, resp.Questions)
+		}
+		return resp
+	}
+	txt := func(resp *dnsmessage.Message) string {
+		t.Helper()
+		if len(resp.Answers) != 1 {
+			t.Fatal("expect 1 answer, but got ", resp.Answers)
+		}
+		r, ok := resp.Answers[0].Body.(*dnsmessage.TXTResource)
+		if !ok || len(r.TXT) != 1 {
+			t.Fatal("unexpected answer: ", resp.Answers[0])
+		}
+		return r.TXT[0]
+	}
+
+	// Queries of other types skip FakeDNS, and are answered by the name server.
+	if r := txt(query("google.com.", dnsmessage.TypeTXT)); r != "v=spf1 -all" {
+		t.Error("unexpected TXT: ", r)
+	}
+	if r := query("facebook.com.", dnsmessage.TypeTXT); r.RCode != dnsmessage.RCodeSuccess || len(r.Answers) != 0 {
+		t.Error("expect empty answer, but got ", r.RCode, r.Answers)
+	}
+
+	// Domains replaced by hosts are answered under the queried name.
+	resp := query("example.com.", dnsmessage.TypeTXT)
+	if r := txt(resp); r != "v=spf1 -all" {
+		t.Error("unexpected TXT: ", r)
+	}
+	if name := resp.Answers[0].Header.Name.String(); name != "example.com." {
+		t.Error("expect answer of example.com., but got ", name)
+	}
+
+	// Domains with IPs in hosts have no other records.
+	if r := query("static.example.com.", dnsmessage.TypeTXT); r.RCode != dnsmessage.RCodeSuccess || len(r.Answers) != 0 {
+		t.Error("expect empty answer, but got ", r.RCode, r.Answers)
+	}
+
+	// A and AAAA queries are answered by LookupIP.
+	resp = query("google.com.", dnsmessage.TypeA)
+	if len(resp.Answers) != 1 {
+		t.Fatal("expect 1 answer, but got ", resp.Answers)
+	}
+	fakeIP := resp.Answers[0].Body.(*dnsmessage.AResource).A
+	if fakeIP[0] != 198 || fakeIP[1] != 18 {
+		t.Fatal("expect a fake IP, but got ", fakeIP)
+	}
+
+	// PTR queries of fake IPs are answered by FakeDNS.
+	ptrName := fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", fakeIP[3], fakeIP[2], fakeIP[1], fakeIP[0])
+	resp = query(ptrName, dnsmessage.TypePTR)
+	if len(resp.Answers) != 1 {
+		t.Fatal("expect 1 answer, but got ", resp.Answers)
+	}
+	if r := resp.Answers[0].Body.(*dnsmessage.PTRResource).PTR.String(); r != "google.com." {
+		t.Error("expect PTR google.com., but got ", r)
+	}
+	unused := fmt.Sprintf("%d.%d.18.198.in-addr.arpa.", fakeIP[3]+1, fakeIP[2])
+	if r := query(unused, dnsmessage.TypePTR); r.RCode != dnsmessage.RCodeNameError {
+		t.Error("expect NXDOMAIN for unused fake IP, but got ", r.RCode)
+	}
+
+	dnsServer.Shutdown()
+}
+
+func TestDNSEvents(t *testing.T) {
+	port := udp.PickPort()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &staticHandler{},
+		UDPSize: 1200,
+	}
+	go dnsServer.ListenAndServe()
+	defer dnsServer.Shutdown()
+	time.Sleep(time.Second)
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				NameServers: []*net.Endpoint{
+					{
+						Network: net.Network_UDP,
+						Address: net.NewIPOrDomain(net.LocalHostIP),
+						Port:    uint32(port),
+					},
+				},
+			}),
+			serial.ToTypedMessage(&stats.Config{}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	manager := v.GetFeature(feature_stats.ManagerType()).(feature_stats.Manager)
+	channel := manager.GetChannel(feature_dns.EventChannel)
+	if channel == nil {
+		t.Fatal("DNS event channel not registered")
+	}
+	sub, err := feature_stats.SubscribeRunnableChannel(channel)
+	common.Must(err)
+	defer feature_stats.UnsubscribeClosableChannel(channel, sub)
+
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.Client)
+	for i := 0; i < 2; i++ {
+		_, err := client.LookupIP("google.com", feature_dns.IPOption{IPv4Enable: true})
+		common.Must(err)
+	}
+
+	server := "UDP:127.0.0.1:" + port.String()
+	for _, cacheHit := range []bool{false, true} {
+		select {
+		case msg := <-sub:
+			event := msg.(*feature_dns.QueryEvent)
+			if event.Domain != "google.com" || event.Type != dnsmessage.TypeA || event.Server != server || event.CacheHit != cacheHit || event.Error != nil {
+				t.Error("unexpected event: ", event)
+			}
+			if r := cmp.Diff(event.IPs, []net.IP{{8, 8, 8, 8}}); r != "" {
+				t.Error(r)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for DNS event")
+		}
+	}
+
+	if counter := manager.GetCounter(feature_dns.UpstreamCounterName(server, "success")); counter == nil || counter.Value() != 1 {
+		t.Error("unexpected success counter: ", counter)
+	}
+	if counter := manager.GetCounter(feature_dns.UpstreamCounterName(server, "failure")); counter != nil {
+		t.Error("unexpected failure counter: ", counter.Value())
+	}
+}
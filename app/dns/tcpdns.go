@@ -0,0 +1,485 @@
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/net/cnc"
+	"github.com/xtls/xray-core/common/protocol/dns"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/task"
+	dns_feature "github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+// TCPNameServer implements DNS over TCP (RFC7766) and DNS over TLS (RFC7858).
+// Queries are pipelined on a reused connection, and responses are matched to
+// queries by their IDs.
+type TCPNameServer struct {
+	sync.RWMutex
+	cacheController *cacheController
+	name            string
+	destination     net.Destination
+	serverName      string
+	useTLS          bool
+	tlsConfig       *tls.Config
+	requests        map[uint16]*tcpRequest
+	cleanup         *task.Periodic
+	reqID           uint32
+	clientIP        net.IP
+	dial            func(context.Context, net.Destination) (net.Conn, error)
+	closed          bool
+
+	connAccess sync.Mutex
+	conn       net.Conn
+}
+
+type tcpRequest struct {
+	dnsRequest
+	ctx     context.Context
+	conn    net.Conn
+	retried bool
+}
+
+// NewTCPNameServer creates DNS over TCP or TLS client object for remote resolving.
+func NewTCPNameServer(url *url.URL, dispatcher routing.Dispatcher, cache *Cache, clientIP net.IP) (*TCPNameServer, error) {
+	s, err := baseTCPNameServer(url, "", cache, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	s.dial = func(ctx context.Context, dest net.Destination) (net.Conn, error) {
+		dispatcherCtx := context.Background()
+		if inbound := session.InboundFromContext(ctx); inbound != nil {
+			dispatcherCtx = session.ContextWithInbound(dispatcherCtx, inbound)
+		}
+		protocol := "dns"
+		if s.useTLS {
+			protocol = "tls"
+		}
+		dispatcherCtx = session.ContextWithContent(dispatcherCtx, &session.Content{
+			Protocol: protocol,
+		})
+		dispatcherCtx = log.ContextWithAccessMessage(dispatcherCtx, &log.AccessMessage{
+			From:   "DNS",
+			To:     s.destination,
+			Status: log.AccessAccepted,
+			Reason: "",
+		})
+
+		link, err := dispatcher.Dispatch(dispatcherCtx, dest)
+		if err != nil {
+			return nil, err
+		}
+
+		cc := common.ChainedClosable{}
+		if cw, ok := link.Writer.(common.Closable); ok {
+			cc = append(cc, cw)
+		}
+		if cr, ok := link.Reader.(common.Closable); ok {
+			cc = append(cc, cr)
+		}
+		return cnc.NewConnection(
+			cnc.ConnectionInputMulti(link.Writer),
+			cnc.ConnectionOutputMulti(link.Reader),
+			cnc.ConnectionOnClose(cc),
+		), nil
+	}
+	newError("DNS: created ", s.name, " client for ", url.String()).AtInfo().WriteToLog()
+	return s, nil
+}
+
+// NewTCPLocalNameServer creates DNS over TCP or TLS client object for local resolving.
+func NewTCPLocalNameServer(url *url.URL, cache *Cache, clientIP net.IP) (*TCPNameServer, error) {
+	s, err := baseTCPNameServer(url, "L", cache, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	s.dial = func(ctx context.Context, dest net.Destination) (net.Conn, error) {
+		conn, err := internet.DialSystem(ctx, dest, nil)
+		log.Record(&log.AccessMessage{
+			From:   "DNS",
+			To:     s.destination,
+			Status: log.AccessAccepted,
+			Detour: "local",
+		})
+		if err != nil {
+			return nil, err
+		}
+		return conn, nil
+	}
+	newError("DNS: created ", s.name, " client for ", url.String()).AtInfo().WriteToLog()
+	return s, nil
+}
+
+func baseTCPNameServer(url *url.URL, suffix string, cache *Cache, clientIP net.IP) (*TCPNameServer, error) {
+	s := &TCPNameServer{
+		requests: make(map[uint16]*tcpRequest),
+		clientIP: clientIP,
+	}
+
+	var prefix string
+	var port net.Port
+	switch strings.TrimSuffix(url.Scheme, "+local") {
+	case "tcp":
+		prefix, port = "TCP", net.Port(53)
+	case "tls":
+		prefix, port = "DOT", net.Port(853)
+		s.useTLS = true
+	default:
+		return nil, newError("unsupported DNS scheme: ", url.Scheme)
+	}
+	if url.Port() != "" {
+		var err error
+		if port, err = net.PortFromString(url.Port()); err != nil {
+			return nil, err
+		}
+	}
+	s.serverName = url.Hostname()
+	if s.useTLS {
+		s.tlsConfig = &tls.Config{
+			ServerName: s.serverName,
+		}
+	}
+	s.destination = net.TCPDestination(net.ParseAddress(s.serverName), port)
+	s.name = prefix + suffix + "//" + s.destination.NetAddr()
+	s.cacheController = newCacheController(s.name, cache)
+	s.cleanup = &task.Periodic{
+		Interval: time.Minute,
+		Execute:  s.Cleanup,
+	}
+	return s, nil
+}
+
+// Name returns client name
+func (s *TCPNameServer) Name() string {
+	return s.name
+}
+
+// Cleanup removes expired pending requests.
+func (s *TCPNameServer) Cleanup() error {
+	now := time.Now()
+	s.Lock()
+	defer s.Unlock()
+
+	if len(s.requests) == 0 {
+		return newError(s.name, " nothing to do. stopping...")
+	}
+
+	for id, req := range s.requests {
+		if req.expire.Before(now) {
+			delete(s.requests, id)
+		}
+	}
+
+	if len(s.requests) == 0 {
+		s.requests = make(map[uint16]*tcpRequest)
+	}
+
+	return nil
+}
+
+// Close implements common.Closable. The connection is closed, and pending
+// queries fail.
+func (s *TCPNameServer) Close() error {
+	s.Lock()
+	s.closed = true
+	requests := s.requests
+	s.requests = make(map[uint16]*tcpRequest)
+	s.Unlock()
+
+	for _, req := range requests {
+		s.failRequest(req)
+	}
+	s.cleanup.Close()
+
+	s.connAccess.Lock()
+	conn := s.conn
+	s.conn = nil
+	s.connAccess.Unlock()
+	if conn != nil {
+		return conn.Close()
+	}
+	return nil
+}
+
+// failRequest fails the query of req.
+func (s *TCPNameServer) failRequest(req *tcpRequest) {
+	if req.resp != nil {
+		close(req.resp)
+		return
+	}
+	if len(req.domain) > 0 {
+		s.cacheController.failIP(&req.dnsRequest)
+	}
+}
+
+// addRequest registers the pending request, and returns false if the server
+// is closed.
+func (s *TCPNameServer) addRequest(req *tcpRequest) bool {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		return false
+	}
+	s.requests[req.msg.ID] = req
+	s.Unlock()
+	common.Must(s.cleanup.Start())
+	return true
+}
+
+// getConn returns the connection in use, or dials a new one. It must be
+// called with connAccess held.
+func (s *TCPNameServer) getConn(ctx context.Context) (net.Conn, error) {
+	if s.conn != nil {
+		return s.conn, nil
+	}
+	s.RLock()
+	closed := s.closed
+	s.RUnlock()
+	if closed {
+		return nil, newError(s.name, " is closed")
+	}
+
+	conn, err := s.dial(ctx, s.destination)
+	if err != nil {
+		return nil, err
+	}
+	if s.useTLS {
+		tlsConn := tls.Client(conn, s.tlsConfig)
+		if deadline, ok := ctx.Deadline(); ok {
+			tlsConn.SetDeadline(deadline)
+		}
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, newError("failed to handshake with ", s.name).Base(err)
+		}
+		tlsConn.SetDeadline(time.Time{})
+		conn = tlsConn
+	}
+
+	s.conn = conn
+	go s.readResponses(conn)
+	return conn, nil
+}
+
+// closeConn closes conn and clears it if it is still in use.
+func (s *TCPNameServer) closeConn(conn net.Conn) {
+	conn.Close()
+	s.connAccess.Lock()
+	if s.conn == conn {
+		s.conn = nil
+	}
+	s.connAccess.Unlock()
+}
+
+func (s *TCPNameServer) readResponses(conn net.Conn) {
+	defer func() {
+		s.closeConn(conn)
+
+		// The server may close an idle connection while queries are being
+		// written. Send them again on a new connection.
+		var retries []*tcpRequest
+		s.Lock()
+		for _, req := range s.requests {
+			if req.conn == conn && !req.retried {
+				req.retried = true
+				retries = append(retries, req)
+			}
+		}
+		s.Unlock()
+		for _, req := range retries {
+			go s.writeQuery(req)
+		}
+	}()
+
+	var lengthBytes [2]byte
+	for {
+		if _, err := io.ReadFull(conn, lengthBytes[:]); err != nil {
+			if err != io.EOF {
+				newError(s.name, " failed to read response").Base(err).AtDebug().WriteToLog()
+			}
+			return
+		}
+		payload := make([]byte, binary.BigEndian.Uint16(lengthBytes[:]))
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			newError(s.name, " failed to read response").Base(err).AtDebug().WriteToLog()
+			return
+		}
+		s.handleResponse(payload)
+	}
+}
+
+func (s *TCPNameServer) handleResponse(payload []byte) {
+	ipRec, err := parseResponse(payload)
+	if err != nil {
+		newError(s.name, " fail to parse responded DNS message").AtError().WriteToLog()
+		return
+	}
+
+	s.Lock()
+	id := ipRec.ReqID
+	req, ok := s.requests[id]
+	if ok {
+		// remove the pending request
+		delete(s.requests, id)
+	}
+	s.Unlock()
+	if !ok {
+		newError(s.name, " cannot find the pending request").AtError().WriteToLog()
+		return
+	}
+
+	if req.resp != nil {
+		req.resp <- payload
+		return
+	}
+	if len(req.domain) > 0 {
+		s.cacheController.updateIP(&req.dnsRequest, ipRec)
+	}
+}
+
+func (s *TCPNameServer) newReqID() uint16 {
+	return uint16(atomic.AddUint32(&s.reqID, 1))
+}
+
+// writeQuery writes the length-prefixed query to the connection in use. If
+// the write fails, the connection is closed, and readResponses sends the query
+// again on a new connection.
+func (s *TCPNameServer) writeQuery(req *tcpRequest) {
+	b, err := dns.PackMessage(req.msg)
+	if err != nil {
+		newError("failed to pack dns query for ", req.domain).Base(err).AtError().WriteToLog()
+		return
+	}
+	defer b.Release()
+	query := make([]byte, 2+b.Len())
+	binary.BigEndian.PutUint16(query, uint16(b.Len()))
+	copy(query[2:], b.Bytes())
+
+	s.connAccess.Lock()
+	defer s.connAccess.Unlock()
+
+	conn, err := s.getConn(req.ctx)
+	if err != nil {
+		newError(s.name, " failed to connect").Base(err).AtError().WriteToLog(session.ExportIDToError(req.ctx))
+		return
+	}
+	s.Lock()
+	req.conn = conn
+	s.Unlock()
+	if _, err := conn.Write(query); err != nil {
+		newError(s.name, " failed to write query").Base(err).AtDebug().WriteToLog(session.ExportIDToError(req.ctx))
+		conn.Close()
+		s.conn = nil
+	}
+}
+
+// queryContext returns the context for writing queries. Connections outlive
+// queries, so only the inbound is kept for routing.
+func (s *TCPNameServer) queryContext(ctx context.Context) context.Context {
+	queryCtx := context.Background()
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		queryCtx = session.ContextWithInbound(queryCtx, inbound)
+	}
+	if id := session.IDFromContext(ctx); id != 0 {
+		queryCtx = session.ContextWithID(queryCtx, id)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		queryCtx, cancel = context.WithDeadline(queryCtx, deadline)
+		go func() {
+			<-ctx.Done()
+			cancel()
+		}()
+	}
+	return queryCtx
+}
+
+func (s *TCPNameServer) sendQuery(ctx context.Context, domain string, option dns_feature.IPOption) {
+	newError(s.name, " querying DNS for: ", domain).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+
+	if s.serverName+"." == domain {
+		newError(s.name, " tries to resolve itself! Use IP or set \"hosts\" instead.").AtError().WriteToLog(session.ExportIDToError(ctx))
+		return
+	}
+
+	reqs := buildReqMsgs(domain, option, s.newReqID, genEDNS0Options(s.clientIP))
+	queryCtx := s.queryContext(ctx)
+
+	for _, r := range reqs {
+		req := &tcpRequest{
+			dnsRequest: *r,
+			ctx:        queryCtx,
+		}
+		req.expire = time.Now().Add(time.Second * 8)
+		if !s.addRequest(req) {
+			s.failRequest(req)
+			continue
+		}
+		go s.writeQuery(req)
+	}
+}
+
+// QueryIP implements Server.
+func (s *TCPNameServer) QueryIP(ctx context.Context, domain string, option dns_feature.IPOption) ([]net.IP, error) {
+	return s.cacheController.queryIP(ctx, domain, option, s.sendQuery)
+}
+
+func (s *TCPNameServer) exchange(ctx context.Context, query *dnsmessage.Message) (*dnsmessage.Message, error) {
+	q := query.Questions[0]
+	newError(s.name, " querying DNS for: ", q.Name, " ", q.Type).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+
+	id := s.newReqID()
+	msg := *query
+	msg.Header.ID = id
+	msg.Additionals = nil
+	if opt := genEDNS0Options(s.clientIP); opt != nil {
+		msg.Additionals = []dnsmessage.Resource{*opt}
+	}
+	req := &tcpRequest{
+		dnsRequest: dnsRequest{
+			reqType: q.Type,
+			domain:  q.Name.String(),
+			start:   time.Now(),
+			expire:  time.Now().Add(time.Second * 8),
+			msg:     &msg,
+			resp:    make(chan []byte, 1),
+		},
+		ctx: s.queryContext(ctx),
+	}
+	if !s.addRequest(req) {
+		return nil, newError(s.name, " is closed")
+	}
+	go s.writeQuery(req)
+
+	select {
+	case resp, ok := <-req.resp:
+		if !ok {
+			return nil, newError(s.name, " is closed")
+		}
+		return unpackResponse(resp, id)
+	case <-ctx.Done():
+		s.Lock()
+		delete(s.requests, id)
+		s.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// QueryMessage implements messageClient.
+func (s *TCPNameServer) QueryMessage(ctx context.Context, query *dnsmessage.Message) (*dnsmessage.Message, error) {
+	return s.cacheController.queryMessage(ctx, query, s.exchange)
+}
@@ -0,0 +1,339 @@
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	dns_feature "github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/pipe"
+)
+
+// tcpDNSServer is a minimal DNS over TCP or TLS server answering A queries
+// from a static table.
+type tcpDNSServer struct {
+	listener net.Listener
+	answers  map[string][4]byte
+
+	sync.Mutex
+	conns   []net.Conn
+	queries map[uint16]int
+}
+
+func newTCPDNSServer(config *tls.Config) *tcpDNSServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	if config != nil {
+		listener = tls.NewListener(listener, config)
+	}
+
+	s := &tcpDNSServer{
+		listener: listener,
+		answers: map[string][4]byte{
+			"google.com.":        {8, 8, 8, 8},
+			"api.google.com.":    {8, 8, 7, 7},
+			"v2.api.google.com.": {8, 8, 7, 8},
+		},
+		queries: make(map[uint16]int),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.Lock()
+			s.conns = append(s.conns, conn)
+			s.Unlock()
+			go s.serveConn(conn)
+		}
+	}()
+	return s
+}
+
+func newDoTServer(ca *cert.Certificate) *tcpDNSServer {
+	serverCert := cert.MustGenerate(ca, cert.DNSNames("dns.example.com"))
+	certPEM, keyPEM := serverCert.ToPEM()
+	keyPair, err := tls.X509KeyPair(certPEM, keyPEM)
+	common.Must(err)
+	return newTCPDNSServer(&tls.Config{
+		Certificates: []tls.Certificate{keyPair},
+	})
+}
+
+func (s *tcpDNSServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	var lengthBytes [2]byte
+	for {
+		if _, err := io.ReadFull(conn, lengthBytes[:]); err != nil {
+			return
+		}
+		b := make([]byte, binary.BigEndian.Uint16(lengthBytes[:]))
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return
+		}
+
+		var msg dnsmessage.Message
+		if err := msg.Unpack(b); err != nil || len(msg.Questions) != 1 {
+			return
+		}
+		s.Lock()
+		s.queries[msg.ID]++
+		s.Unlock()
+		out, err := answerQuery(s.answers, &msg)
+		if err != nil {
+			return
+		}
+		if _, err := conn.Write(out); err != nil {
+			return
+		}
+	}
+}
+
+// closeConns closes all connections accepted so far, and returns their count.
+func (s *tcpDNSServer) closeConns() int {
+	s.Lock()
+	defer s.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	n := len(s.conns)
+	s.conns = nil
+	return n
+}
+
+// duplicateQueries returns the number of queries received more than once.
+func (s *tcpDNSServer) duplicateQueries() int {
+	s.Lock()
+	defer s.Unlock()
+	n := 0
+	for _, count := range s.queries {
+		if count > 1 {
+			n++
+		}
+	}
+	return n
+}
+
+// tcpDispatcher dispatches links to TCP connections to their destinations.
+type tcpDispatcher struct {
+	dispatched int32
+}
+
+func (*tcpDispatcher) Type() interface{} {
+	return routing.DispatcherType()
+}
+
+func (*tcpDispatcher) Start() error {
+	return nil
+}
+
+func (*tcpDispatcher) Close() error {
+	return nil
+}
+
+func (d *tcpDispatcher) Dispatch(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+	conn, err := net.Dial("tcp", dest.NetAddr())
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt32(&d.dispatched, 1)
+
+	uplinkReader, uplinkWriter := pipe.New()
+	downlinkReader, downlinkWriter := pipe.New()
+	go func() {
+		buf.Copy(uplinkReader, buf.NewWriter(conn))
+		conn.Close()
+	}()
+	go func() {
+		buf.Copy(buf.NewReader(conn), downlinkWriter)
+		downlinkWriter.Close()
+	}()
+	return &transport.Link{Reader: downlinkReader, Writer: uplinkWriter}, nil
+}
+
+func trustCA(config *tls.Config, ca *cert.Certificate) {
+	caCert, err := x509.ParseCertificate(ca.Certificate)
+	common.Must(err)
+	config.RootCAs = x509.NewCertPool()
+	config.RootCAs.AddCert(caCert)
+	config.ServerName = "dns.example.com"
+}
+
+func queryTCPNameServer(t *testing.T, s *TCPNameServer, domain string, ip string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	ips, err := s.QueryIP(ctx, domain, dns_feature.IPOption{
+		IPv4Enable: true,
+	})
+	if err != nil {
+		t.Error("failed to query ", domain, ": ", err)
+		return
+	}
+	if len(ips) != 1 || ips[0].String() != ip {
+		t.Error("expect ", ip, " for ", domain, " but got ", ips)
+	}
+}
+
+func TestTLSLocalNameServer(t *testing.T) {
+	ca := cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign))
+	server := newDoTServer(ca)
+	defer server.listener.Close()
+
+	u, err := url.Parse("tls+local://" + server.listener.Addr().String())
+	common.Must(err)
+	s, err := NewTCPLocalNameServer(u, nil, nil)
+	common.Must(err)
+	if s.Name() != "DOTL//"+server.listener.Addr().String() {
+		t.Error("unexpected name: ", s.Name())
+	}
+	trustCA(s.tlsConfig, ca)
+
+	// Queries are pipelined on the same connection.
+	var wg sync.WaitGroup
+	for domain, ip := range map[string]string{
+		"google.com":     "8.8.8.8",
+		"api.google.com": "8.8.7.7",
+	} {
+		wg.Add(1)
+		go func(domain, ip string) {
+			defer wg.Done()
+			queryTCPNameServer(t, s, domain, ip)
+		}(domain, ip)
+	}
+	wg.Wait()
+
+	if n := server.closeConns(); n != 1 {
+		t.Error("expect 1 connection, but got ", n)
+	}
+	time.Sleep(time.Millisecond * 200)
+
+	// The client reconnects after the connection is closed by the server,
+	// and sends the query only once.
+	queryTCPNameServer(t, s, "v2.api.google.com", "8.8.7.8")
+	if n := server.closeConns(); n != 1 {
+		t.Error("expect 1 connection after reconnecting, but got ", n)
+	}
+	if n := server.duplicateQueries(); n != 0 {
+		t.Error("expect no duplicate queries, but got ", n)
+	}
+}
+
+func TestTCPNameServer(t *testing.T) {
+	ca := cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign))
+	for _, tc := range []struct {
+		scheme string
+		prefix string
+		server *tcpDNSServer
+	}{
+		{scheme: "tcp", prefix: "TCP", server: newTCPDNSServer(nil)},
+		{scheme: "tls", prefix: "DOT", server: newDoTServer(ca)},
+	} {
+		addr := tc.server.listener.Addr().String()
+		u, err := url.Parse(tc.scheme + "://" + addr)
+		common.Must(err)
+		d := &tcpDispatcher{}
+		s, err := NewTCPNameServer(u, d, nil, nil)
+		common.Must(err)
+		if s.Name() != tc.prefix+"//"+addr {
+			t.Error("unexpected name: ", s.Name())
+		}
+		if s.tlsConfig != nil {
+			trustCA(s.tlsConfig, ca)
+		}
+
+		queryTCPNameServer(t, s, "google.com", "8.8.8.8")
+		queryTCPNameServer(t, s, "api.google.com", "8.8.7.7")
+		if n := atomic.LoadInt32(&d.dispatched); n != 1 {
+			t.Error("expect queries of ", tc.scheme, " dispatched on 1 link, but got ", n)
+		}
+		tc.server.listener.Close()
+	}
+}
+
+func TestTCPNameServerClose(t *testing.T) {
+	server := newTCPDNSServer(nil)
+	defer server.listener.Close()
+
+	u, err := url.Parse("tcp+local://" + server.listener.Addr().String())
+	common.Must(err)
+	s, err := NewTCPLocalNameServer(u, nil, nil)
+	common.Must(err)
+	queryTCPNameServer(t, s, "google.com", "8.8.8.8")
+
+	s.connAccess.Lock()
+	conn := s.conn
+	s.connAccess.Unlock()
+	if conn == nil {
+		t.Fatal("expect a connection in use")
+	}
+
+	common.Must(s.Close())
+	if _, err := conn.Write([]byte{0}); err == nil {
+		t.Error("expect the connection closed")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if _, err := s.QueryIP(ctx, "api.google.com", ipv4Only); err == nil || ctx.Err() != nil {
+		t.Error("expect the query to fail after closing, but got ", err)
+	}
+}
+
+func TestTCPNameServerClosePending(t *testing.T) {
+	// The server never answers.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(io.Discard, conn)
+		}
+	}()
+
+	u, err := url.Parse("tcp+local://" + listener.Addr().String())
+	common.Must(err)
+	s, err := NewTCPLocalNameServer(u, nil, nil)
+	common.Must(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() {
+		_, err := s.QueryIP(ctx, "google.com", ipv4Only)
+		errs <- err
+	}()
+	for {
+		s.RLock()
+		pending := len(s.requests)
+		s.RUnlock()
+		if pending > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	common.Must(s.Close())
+	if err := <-errs; err == nil || ctx.Err() != nil {
+		t.Error("expect the pending query to fail on closing, but got ", err)
+	}
+}
@@ -2,53 +2,66 @@ package dns
 
 import (
 	"context"
-	"github.com/xtls/xray-core/transport/internet"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"golang.org/x/net/dns/dnsmessage"
+
 	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/log"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol/dns"
 	udp_proto "github.com/xtls/xray-core/common/protocol/udp"
 	"github.com/xtls/xray-core/common/session"
-	"github.com/xtls/xray-core/common/signal/pubsub"
 	"github.com/xtls/xray-core/common/task"
 	dns_feature "github.com/xtls/xray-core/features/dns"
 	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport/internet"
 	"github.com/xtls/xray-core/transport/internet/udp"
-	"golang.org/x/net/dns/dnsmessage"
 )
 
+// sourceIdleTimeout is the duration to keep the dispatcher of a source
+// without queries.
+const sourceIdleTimeout = time.Minute * 2
+
+// sourceDispatcher is the dispatcher of queries from a source.
+type sourceDispatcher struct {
+	*udp.Dispatcher
+	lastUsed time.Time
+}
+
 type ClassicNameServer struct {
 	sync.RWMutex
-	name      string
-	address   net.Destination
-	ips       map[string]record
-	requests  map[uint16]dnsRequest
-	pub       *pubsub.Service
-	udpServer *udp.Dispatcher
-	cleanup   *task.Periodic
-	reqID     uint32
-	clientIP  net.IP
+	cacheController *cacheController
+	name            string
+	address         net.Destination
+	requests        map[uint16]dnsRequest
+	udpServer       *udp.Dispatcher
+	sourceServers   map[string]*sourceDispatcher
+	dispatcher      routing.Dispatcher
+	cleanup         *task.Periodic
+	reqID           uint32
+	clientIP        net.IP
 }
 
-func NewClassicNameServer(address net.Destination, dispatcher routing.Dispatcher, clientIP net.IP) *ClassicNameServer {
+func NewClassicNameServer(address net.Destination, dispatcher routing.Dispatcher, cache *Cache, clientIP net.IP) *ClassicNameServer {
 	// default to 53 if unspecific
 	if address.Port == 0 {
 		address.Port = net.Port(53)
 	}
 
 	s := &ClassicNameServer{
-		address:  address,
-		ips:      make(map[string]record),
-		requests: make(map[uint16]dnsRequest),
-		clientIP: clientIP,
-		pub:      pubsub.NewService(),
-		name:     strings.ToUpper(address.String()),
-	}
+		address:       address,
+		requests:      make(map[uint16]dnsRequest),
+		sourceServers: make(map[string]*sourceDispatcher),
+		dispatcher:    dispatcher,
+		clientIP:      clientIP,
+		name:          strings.ToUpper(address.String()),
+	}
+	s.cacheController = newCacheController(s.name, cache)
 	s.cleanup = &task.Periodic{
 		Interval: time.Minute,
 		Execute:  s.Cleanup,
@@ -62,32 +75,22 @@ func (s *ClassicNameServer) Name() string {
 	return s.name
 }
 
+// Cleanup removes expired pending requests, and closes dispatchers of idle
+// sources.
 func (s *ClassicNameServer) Cleanup() error {
 	now := time.Now()
 	s.Lock()
 	defer s.Unlock()
 
-	if len(s.ips) == 0 && len(s.requests) == 0 {
-		return newError(s.name, " nothing to do. stopping...")
-	}
-
-	for domain, record := range s.ips {
-		if record.A != nil && record.A.Expire.Before(now) {
-			record.A = nil
-		}
-		if record.AAAA != nil && record.AAAA.Expire.Before(now) {
-			record.AAAA = nil
-		}
-
-		if record.A == nil && record.AAAA == nil {
-			delete(s.ips, domain)
-		} else {
-			s.ips[domain] = record
+	for source, d := range s.sourceServers {
+		if now.Sub(d.lastUsed) >= sourceIdleTimeout {
+			delete(s.sourceServers, source)
+			d.Close()
 		}
 	}
 
-	if len(s.ips) == 0 {
-		s.ips = make(map[string]record)
+	if len(s.requests) == 0 && len(s.sourceServers) == 0 {
+		return newError(s.name, " nothing to do. stopping...")
 	}
 
 	for id, req := range s.requests {
@@ -123,61 +126,25 @@ func (s *ClassicNameServer) HandleResponse(ctx context.Context, packet *udp_prot
 		return
 	}
 
-	var rec record
-	switch req.reqType {
-	case dnsmessage.TypeA:
-		rec.A = ipRec
-	case dnsmessage.TypeAAAA:
-		rec.AAAA = ipRec
-	}
-
-	elapsed := time.Since(req.start)
-	newError(s.name, " got answer: ", req.domain, " ", req.reqType, " -> ", ipRec.IP, " ", elapsed).AtInfo().WriteToLog()
-	if len(req.domain) > 0 && (rec.A != nil || rec.AAAA != nil) {
-		s.updateIP(req.domain, rec)
-	}
-}
-
-func (s *ClassicNameServer) updateIP(domain string, newRec record) {
-	s.Lock()
-
-	newError(s.name, " updating IP records for domain:", domain).AtDebug().WriteToLog()
-	rec := s.ips[domain]
-
-	updated := false
-	if isNewer(rec.A, newRec.A) {
-		rec.A = newRec.A
-		updated = true
-	}
-	if isNewer(rec.AAAA, newRec.AAAA) {
-		rec.AAAA = newRec.AAAA
-		updated = true
-	}
-
-	if updated {
-		s.ips[domain] = rec
-	}
-	if newRec.A != nil {
-		s.pub.Publish(domain+"4", nil)
+	if req.resp != nil {
+		req.resp <- append([]byte(nil), packet.Payload.Bytes()...)
+		return
 	}
-	if newRec.AAAA != nil {
-		s.pub.Publish(domain+"6", nil)
+	if len(req.domain) > 0 {
+		s.cacheController.updateIP(&req, ipRec)
 	}
-	s.Unlock()
-	common.Must(s.cleanup.Start())
 }
 
 func (s *ClassicNameServer) newReqID() uint16 {
 	return uint16(atomic.AddUint32(&s.reqID, 1))
 }
 
-func (s *ClassicNameServer) addPendingRequest(req *dnsRequest) {
+func (s *ClassicNameServer) addPendingRequest(id uint16, req *dnsRequest) {
 	s.Lock()
-	defer s.Unlock()
-
-	id := req.msg.ID
 	req.expire = time.Now().Add(time.Second * 8)
 	s.requests[id] = *req
+	s.Unlock()
+	common.Must(s.cleanup.Start())
 }
 
 func (s *ClassicNameServer) sendQuery(ctx context.Context, domain string, option dns_feature.IPOption) {
@@ -186,115 +153,101 @@ func (s *ClassicNameServer) sendQuery(ctx context.Context, domain string, option
 	reqs := buildReqMsgs(domain, option, s.newReqID, genEDNS0Options(s.clientIP))
 
 	for _, req := range reqs {
-		s.addPendingRequest(req)
+		s.addPendingRequest(req.msg.ID, req)
 		b, _ := dns.PackMessage(req.msg)
-		udpCtx := context.Background()
-		if inbound := session.InboundFromContext(ctx); inbound != nil {
-			udpCtx = session.ContextWithInbound(udpCtx, inbound)
-		}
-		udpCtx = internet.ContextWithLookupDomain(udpCtx, internet.LookupDomainFromContext(ctx))
-		udpCtx = session.ContextWithContent(udpCtx, &session.Content{
-			Protocol: "dns",
-		})
-		udpCtx = log.ContextWithAccessMessage(udpCtx, &log.AccessMessage{
-			From:   "DNS",
-			To:     s.address,
-			Status: log.AccessAccepted,
-			Reason: "",
-		})
-		s.udpServer.Dispatch(udpCtx, s.address, b)
+		s.dispatch(ctx, b)
 	}
 }
 
-func (s *ClassicNameServer) findIPsForDomain(domain string, option dns_feature.IPOption) ([]net.IP, error) {
-	s.RLock()
-	record, found := s.ips[domain]
-	s.RUnlock()
-
-	if !found {
-		return nil, errRecordNotFound
-	}
-
-	var ips []net.Address
-	var lastErr error
-	if option.IPv4Enable {
-		a, err := record.A.getIPs()
-		if err != nil {
-			lastErr = err
-		}
-		ips = append(ips, a...)
+// udpDispatcher returns the dispatcher for queries of a DNS request. Requests
+// from different sources use separate links, so that they are routed by their
+// sources.
+func (s *ClassicNameServer) udpDispatcher(ctx context.Context) *udp.Dispatcher {
+	inbound := session.InboundFromContext(ctx)
+	if inbound == nil || !inbound.Source.IsValid() {
+		return s.udpServer
 	}
+	source := inbound.Source.Address.String()
 
-	if option.IPv6Enable {
-		aaaa, err := record.AAAA.getIPs()
-		if err != nil {
-			lastErr = err
+	s.Lock()
+	defer s.Unlock()
+	d, found := s.sourceServers[source]
+	if !found {
+		d = &sourceDispatcher{
+			Dispatcher: udp.NewDispatcher(s.dispatcher, s.HandleResponse),
 		}
-		ips = append(ips, aaaa...)
+		s.sourceServers[source] = d
 	}
+	d.lastUsed = time.Now()
+	return d.Dispatcher
+}
 
-	if len(ips) > 0 {
-		return toNetIP(ips), nil
-	}
+// Close implements common.Closable. It closes links of all dispatchers.
+func (s *ClassicNameServer) Close() error {
+	s.Lock()
+	sources := s.sourceServers
+	s.sourceServers = make(map[string]*sourceDispatcher)
+	s.Unlock()
 
-	if lastErr != nil {
-		return nil, lastErr
+	for _, d := range sources {
+		d.Close()
 	}
+	s.cleanup.Close()
+	return s.udpServer.Close()
+}
 
-	return nil, dns_feature.ErrEmptyResponse
+func (s *ClassicNameServer) dispatch(ctx context.Context, b *buf.Buffer) {
+	udpCtx := context.Background()
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		udpCtx = session.ContextWithInbound(udpCtx, inbound)
+	}
+	udpCtx = internet.ContextWithLookupDomain(udpCtx, internet.LookupDomainFromContext(ctx))
+	udpCtx = session.ContextWithContent(udpCtx, &session.Content{
+		Protocol: "dns",
+	})
+	udpCtx = log.ContextWithAccessMessage(udpCtx, &log.AccessMessage{
+		From:   "DNS",
+		To:     s.address,
+		Status: log.AccessAccepted,
+		Reason: "",
+	})
+	s.udpDispatcher(ctx).Dispatch(udpCtx, s.address, b)
 }
 
-// QueryIP implements Server.
-func (s *ClassicNameServer) QueryIP(ctx context.Context, domain string, option dns_feature.IPOption) ([]net.IP, error) {
-	fqdn := Fqdn(domain)
+func (s *ClassicNameServer) exchange(ctx context.Context, query *dnsmessage.Message) (*dnsmessage.Message, error) {
+	newError(s.name, " querying DNS for: ", query.Questions[0].Name, " ", query.Questions[0].Type).AtDebug().WriteToLog(session.ExportIDToError(ctx))
 
-	ips, err := s.findIPsForDomain(fqdn, option)
-	if err != errRecordNotFound {
-		newError(s.name, " cache HIT ", domain, " -> ", ips).Base(err).AtDebug().WriteToLog()
-		log.Record(&log.DNSLog{s.name, domain, ips, log.DNSCacheHit, 0, err})
-		return ips, err
+	id := s.newReqID()
+	b, err := packQuery(query, id, s.clientIP)
+	if err != nil {
+		return nil, newError("failed to pack dns query").Base(err)
+	}
+	req := &dnsRequest{
+		reqType: query.Questions[0].Type,
+		start:   time.Now(),
+		msg:     query,
+		resp:    make(chan []byte, 1),
+	}
+	s.addPendingRequest(id, req)
+	s.dispatch(ctx, b)
+
+	select {
+	case resp := <-req.resp:
+		return unpackResponse(resp, id)
+	case <-ctx.Done():
+		s.Lock()
+		delete(s.requests, id)
+		s.Unlock()
+		return nil, ctx.Err()
 	}
+}
 
-	// ipv4 and ipv6 belong to different subscription groups
-	var sub4, sub6 *pubsub.Subscriber
-	if option.IPv4Enable {
-		sub4 = s.pub.Subscribe(fqdn + "4")
-		defer sub4.Close()
-	}
-	if option.IPv6Enable {
-		sub6 = s.pub.Subscribe(fqdn + "6")
-		defer sub6.Close()
-	}
-	done := make(chan interface{})
-	go func() {
-		if sub4 != nil {
-			select {
-			case <-sub4.Wait():
-			case <-ctx.Done():
-			}
-		}
-		if sub6 != nil {
-			select {
-			case <-sub6.Wait():
-			case <-ctx.Done():
-			}
-		}
-		close(done)
-	}()
-	s.sendQuery(ctx, fqdn, option)
-	start := time.Now()
-
-	for {
-		ips, err := s.findIPsForDomain(fqdn, option)
-		if err != errRecordNotFound {
-			log.Record(&log.DNSLog{s.name, domain, ips, log.DNSQueried, time.Since(start), err})
-			return ips, err
-		}
+// QueryIP implements Server.
+func (s *ClassicNameServer) QueryIP(ctx context.Context, domain string, option dns_feature.IPOption) ([]net.IP, error) {
+	return s.cacheController.queryIP(ctx, domain, option, s.sendQuery)
+}
 
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-done:
-		}
-	}
+// QueryMessage implements messageClient.
+func (s *ClassicNameServer) QueryMessage(ctx context.Context, query *dnsmessage.Message) (*dnsmessage.Message, error) {
+	return s.cacheController.queryMessage(ctx, query, s.exchange)
 }
@@ -0,0 +1,111 @@
+package dns
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/pipe"
+)
+
+// linkDispatcher dispatches requests to pipes, keeping the outbound side of
+// each link.
+type linkDispatcher struct {
+	access    sync.Mutex
+	outbounds []*pipe.Reader
+}
+
+func (*linkDispatcher) Type() interface{} {
+	return routing.DispatcherType()
+}
+
+func (*linkDispatcher) Start() error {
+	return nil
+}
+
+func (*linkDispatcher) Close() error {
+	return nil
+}
+
+func (d *linkDispatcher) Dispatch(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+	uplinkReader, uplinkWriter := pipe.New()
+	downlinkReader, _ := pipe.New()
+	d.access.Lock()
+	d.outbounds = append(d.outbounds, uplinkReader)
+	d.access.Unlock()
+	return &transport.Link{Reader: downlinkReader, Writer: uplinkWriter}, nil
+}
+
+func (d *linkDispatcher) outbound(i int) *pipe.Reader {
+	d.access.Lock()
+	defer d.access.Unlock()
+	return d.outbounds[i]
+}
+
+// linkClosed returns whether the link is closed after the pending payloads.
+func linkClosed(r *pipe.Reader) bool {
+	for {
+		mb, err := r.ReadMultiBufferTimeout(time.Millisecond * 100)
+		buf.ReleaseMulti(mb)
+		if err == buf.ErrReadTimeout {
+			return false
+		}
+		if err != nil {
+			return true
+		}
+	}
+}
+
+func TestClassicNameServerSourceCleanup(t *testing.T) {
+	d := &linkDispatcher{}
+	s := NewClassicNameServer(net.UDPDestination(net.LocalHostIP, 53), d, nil, nil)
+
+	for _, source := range []string{"10.0.0.1", "10.0.0.2"} {
+		ctx := session.ContextWithInbound(context.Background(), &session.Inbound{
+			Source: net.UDPDestination(net.ParseAddress(source), 5353),
+		})
+		b := buf.New()
+		b.WriteString("query")
+		s.dispatch(ctx, b)
+	}
+
+	s.Lock()
+	if len(s.sourceServers) != 2 {
+		t.Fatal("expect 2 source dispatchers, but got ", len(s.sourceServers))
+	}
+	s.sourceServers["10.0.0.1"].lastUsed = time.Now().Add(-sourceIdleTimeout)
+	s.Unlock()
+
+	if err := s.Cleanup(); err != nil {
+		t.Fatal(err)
+	}
+	s.Lock()
+	_, found := s.sourceServers["10.0.0.1"]
+	remaining := len(s.sourceServers)
+	s.Unlock()
+	if found || remaining != 1 {
+		t.Error("expect the idle source dispatcher removed, but got ", remaining)
+	}
+	if !linkClosed(d.outbound(0)) {
+		t.Error("expect the link of the idle source closed")
+	}
+	if linkClosed(d.outbound(1)) {
+		t.Error("expect the link of the active source open")
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.sourceServers) != 0 {
+		t.Error("expect no source dispatchers after close, but got ", len(s.sourceServers))
+	}
+	if !linkClosed(d.outbound(1)) {
+		t.Error("expect the link of the active source closed")
+	}
+}
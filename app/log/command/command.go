@@ -4,11 +4,13 @@ package command
 
 import (
 	"context"
+	"sort"
 
 	grpc "google.golang.org/grpc"
 
 	"github.com/xtls/xray-core/app/log"
 	"github.com/xtls/xray-core/common"
+	clog "github.com/xtls/xray-core/common/log"
 	"github.com/xtls/xray-core/core"
 )
 
@@ -31,6 +33,46 @@ func (s *LoggerServer) RestartLogger(ctx context.Context, request *RestartLogger
 	return &RestartLoggerResponse{}, nil
 }
 
+// SetLogLevel implements LoggerService.
+func (s *LoggerServer) SetLogLevel(ctx context.Context, request *SetLogLevelRequest) (*SetLogLevelResponse, error) {
+	logger, ok := s.V.GetFeature((*log.Instance)(nil)).(*log.Instance)
+	if !ok {
+		return nil, newError("unable to get logger instance")
+	}
+	for _, m := range request.Modules {
+		if m.Module == "" {
+			return nil, newError("module is not specified")
+		}
+	}
+
+	if request.Level != clog.Severity_Unknown {
+		logger.SetLevel(request.Level)
+	}
+	if request.ResetModules {
+		logger.ResetModuleLevels()
+	}
+	for _, m := range request.Modules {
+		logger.SetModuleLevel(m.Module, m.Level)
+	}
+	if request.Level != clog.Severity_Unknown || request.ResetModules || len(request.Modules) > 0 {
+		newError("error log level changed to ", logger.Level(), " with ", len(logger.ModuleLevels()), " module levels").AtInfo().WriteToLog()
+	}
+
+	response := &SetLogLevelResponse{
+		Level: logger.Level(),
+	}
+	for module, level := range logger.ModuleLevels() {
+		response.Modules = append(response.Modules, &ModuleLevel{
+			Module: module,
+			Level:  level,
+		})
+	}
+	sort.Slice(response.Modules, func(i, j int) bool {
+		return response.Modules[i].Module < response.Modules[j].Module
+	})
+	return response, nil
+}
+
 func (s *LoggerServer) mustEmbedUnimplementedLoggerServiceServer() {}
 
 type service struct {
@@ -11,6 +11,7 @@ import (
 	_ "github.com/xtls/xray-core/app/proxyman/inbound"
 	_ "github.com/xtls/xray-core/app/proxyman/outbound"
 	"github.com/xtls/xray-core/common"
+	clog "github.com/xtls/xray-core/common/log"
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/core"
 )
@@ -32,3 +33,57 @@ func TestLoggerRestart(t *testing.T) {
 	}
 	common.Must2(server.RestartLogger(context.Background(), &RestartLoggerRequest{}))
 }
+
+func TestSetLogLevel(t *testing.T) {
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&log.Config{
+				ErrorLogLevel: clog.Severity_Warning,
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+		},
+	})
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	server := &LoggerServer{
+		V: v,
+	}
+	resp, err := server.SetLogLevel(context.Background(), &SetLogLevelRequest{
+		Level: clog.Severity_Error,
+		Modules: []*ModuleLevel{
+			{Module: "transport/internet/tunnel", Level: clog.Severity_Debug},
+			{Module: "app/dispatcher", Level: clog.Severity_Info},
+		},
+	})
+	common.Must(err)
+	if resp.Level != clog.Severity_Error || len(resp.Modules) != 2 || resp.Modules[0].Module != "app/dispatcher" {
+		t.Error("unexpected response ", resp)
+	}
+
+	// Unknown removes the level of the module.
+	resp, err = server.SetLogLevel(context.Background(), &SetLogLevelRequest{
+		Modules: []*ModuleLevel{
+			{Module: "app/dispatcher"},
+		},
+	})
+	common.Must(err)
+	if resp.Level != clog.Severity_Error || len(resp.Modules) != 1 || resp.Modules[0].Module != "transport/internet/tunnel" {
+		t.Error("unexpected response ", resp)
+	}
+
+	resp, err = server.SetLogLevel(context.Background(), &SetLogLevelRequest{ResetModules: true})
+	common.Must(err)
+	if len(resp.Modules) != 0 {
+		t.Error("expect no module levels after reset, but got ", resp.Modules)
+	}
+
+	if _, err := server.SetLogLevel(context.Background(), &SetLogLevelRequest{
+		Modules: []*ModuleLevel{{Level: clog.Severity_Debug}},
+	}); err == nil {
+		t.Error("expect error for module not specified")
+	}
+}
@@ -4,6 +4,7 @@ package log
 
 import (
 	"context"
+	"strings"
 	"sync"
 
 	"github.com/xtls/xray-core/common"
@@ -18,6 +19,10 @@ type Instance struct {
 	errorLogger  log.Handler
 	active       bool
 	dns          bool
+	// level is the severity of the error log, and modules the severities of
+	// modules overriding it. Both can be changed at runtime.
+	level   log.Severity
+	modules map[string]log.Severity
 }
 
 // New creates a new log.Instance based on the given config.
@@ -26,6 +31,7 @@ func New(ctx context.Context, config *Config) (*Instance, error) {
 		config: config,
 		active: false,
 		dns:    config.EnableDnsLog,
+		level:  config.ErrorLogLevel,
 	}
 	log.RegisterHandler(g)
 
@@ -41,7 +47,10 @@ func New(ctx context.Context, config *Config) (*Instance, error) {
 
 func (g *Instance) initAccessLogger() error {
 	handler, err := createHandler(g.config.AccessLogType, HandlerCreatorOptions{
-		Path: g.config.AccessLogPath,
+		Path:   g.config.AccessLogPath,
+		Format: g.config.Format,
+		Rotate: g.config.Rotate,
+		Syslog: g.config.Syslog,
 	})
 	if err != nil {
 		return err
@@ -52,7 +61,10 @@ func (g *Instance) initAccessLogger() error {
 
 func (g *Instance) initErrorLogger() error {
 	handler, err := createHandler(g.config.ErrorLogType, HandlerCreatorOptions{
-		Path: g.config.ErrorLogPath,
+		Path:   g.config.ErrorLogPath,
+		Format: g.config.Format,
+		Rotate: g.config.Rotate,
+		Syslog: g.config.Syslog,
 	})
 	if err != nil {
 		return err
@@ -110,7 +122,7 @@ func (g *Instance) Handle(msg log.Message) {
 			g.accessLogger.Handle(msg)
 		}
 	case *log.GeneralMessage:
-		if g.errorLogger != nil && msg.Severity <= g.config.ErrorLogLevel {
+		if g.errorLogger != nil && msg.Severity <= g.levelOf(msg) {
 			g.errorLogger.Handle(msg)
 		}
 	default:
@@ -118,6 +130,84 @@ func (g *Instance) Handle(msg log.Message) {
 	}
 }
 
+type hasPkgPath interface {
+	PkgPath() string
+}
+
+// levelOf returns the severity of the error log for the message. The
+// severity of the most specific module of the message takes precedence.
+func (g *Instance) levelOf(msg *log.GeneralMessage) log.Severity {
+	if len(g.modules) == 0 {
+		return g.level
+	}
+	p, ok := msg.Content.(hasPkgPath)
+	if !ok {
+		return g.level
+	}
+	module := p.PkgPath()
+	for module != "" {
+		if level, found := g.modules[module]; found {
+			return level
+		}
+		idx := strings.LastIndexByte(module, '/')
+		if idx < 0 {
+			break
+		}
+		module = module[:idx]
+	}
+	return g.level
+}
+
+// Level returns the severity of the error log.
+func (g *Instance) Level() log.Severity {
+	g.RLock()
+	defer g.RUnlock()
+	return g.level
+}
+
+// SetLevel changes the severity of the error log.
+func (g *Instance) SetLevel(level log.Severity) {
+	g.Lock()
+	defer g.Unlock()
+	g.level = level
+}
+
+// ModuleLevels returns the severities of modules overriding the severity of
+// the error log.
+func (g *Instance) ModuleLevels() map[string]log.Severity {
+	g.RLock()
+	defer g.RUnlock()
+	levels := make(map[string]log.Severity, len(g.modules))
+	for module, level := range g.modules {
+		levels[module] = level
+	}
+	return levels
+}
+
+// SetModuleLevel changes the severity of the error log for the module, the
+// path of a package like "transport/internet/tunnel", and its sub packages.
+// Severity_Unknown removes the severity of the module.
+func (g *Instance) SetModuleLevel(module string, level log.Severity) {
+	module = strings.Trim(module, "/")
+	g.Lock()
+	defer g.Unlock()
+	if level == log.Severity_Unknown {
+		delete(g.modules, module)
+		return
+	}
+	if g.modules == nil {
+		g.modules = make(map[string]log.Severity)
+	}
+	g.modules[module] = level
+}
+
+// ResetModuleLevels removes severities of all modules.
+func (g *Instance) ResetModuleLevels() {
+	g.Lock()
+	defer g.Unlock()
+	g.modules = nil
+}
+
 // Close implements common.Closable.Close().
 func (g *Instance) Close() error {
 	newError("Logger closing").AtDebug().WriteToLog()
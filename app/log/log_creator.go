@@ -1,12 +1,43 @@
 package log
 
 import (
+	"time"
+
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/log"
 )
 
 type HandlerCreatorOptions struct {
-	Path string
+	Path   string
+	Format LogFormat
+	Rotate *RotateConfig
+	Syslog *SyslogConfig
+}
+
+// newHandler returns a handler writing by the creator in the format.
+func newHandler(format LogFormat, creator log.WriterCreator) log.Handler {
+	if format == LogFormat_JSON {
+		return log.NewJSONLogger(creator)
+	}
+	return log.NewLogger(creator)
+}
+
+// writerOptions returns options of LogWriters in the format.
+func writerOptions(format LogFormat) []log.WriterOption {
+	if format == LogFormat_JSON {
+		return []log.WriterOption{log.WithoutTimestamp()}
+	}
+	return nil
+}
+
+func (c *RotateConfig) toOptions() log.RotateOptions {
+	return log.RotateOptions{
+		MaxSize:    int64(c.MaxSize),
+		Interval:   time.Duration(c.Interval) * time.Second,
+		MaxBackups: int(c.MaxBackups),
+		MaxAge:     time.Duration(c.MaxAge) * time.Second,
+		Compress:   c.Compress,
+	}
 }
 
 type HandlerCreator func(LogType, HandlerCreatorOptions) (log.Handler, error)
@@ -34,15 +65,38 @@ func createHandler(logType LogType, options HandlerCreatorOptions) (log.Handler,
 
 func init() {
 	common.Must(RegisterHandlerCreator(LogType_Console, func(lt LogType, options HandlerCreatorOptions) (log.Handler, error) {
-		return log.NewLogger(log.CreateStdoutLogWriter()), nil
+		return newHandler(options.Format, log.CreateStdoutLogWriter(writerOptions(options.Format)...)), nil
 	}))
 
 	common.Must(RegisterHandlerCreator(LogType_File, func(lt LogType, options HandlerCreatorOptions) (log.Handler, error) {
-		creator, err := log.CreateFileLogWriter(options.Path)
+		var creator log.WriterCreator
+		var err error
+		if options.Rotate != nil {
+			creator, err = log.CreateRotatingFileLogWriter(options.Path, options.Rotate.toOptions(), writerOptions(options.Format)...)
+		} else {
+			creator, err = log.CreateFileLogWriter(options.Path, writerOptions(options.Format)...)
+		}
 		if err != nil {
 			return nil, err
 		}
-		return log.NewLogger(creator), nil
+		return newHandler(options.Format, creator), nil
+	}))
+
+	common.Must(RegisterHandlerCreator(LogType_Syslog, func(lt LogType, options HandlerCreatorOptions) (log.Handler, error) {
+		syslogOptions := log.SyslogOptions{
+			Network: options.Syslog.GetNetwork(),
+			Address: options.Syslog.GetAddress(),
+			Tag:     options.Syslog.GetTag(),
+		}
+		if f := options.Syslog.GetFacility(); f != SyslogConfig_Unset {
+			facility := int(f) - 1
+			syslogOptions.Facility = &facility
+		}
+		return newHandler(options.Format, log.CreateSyslogLogWriter(syslogOptions)), nil
+	}))
+
+	common.Must(RegisterHandlerCreator(LogType_Journald, func(lt LogType, options HandlerCreatorOptions) (log.Handler, error) {
+		return newHandler(options.Format, log.CreateJournaldLogWriter(options.Syslog.GetAddress(), options.Syslog.GetTag())), nil
 	}))
 
 	common.Must(RegisterHandlerCreator(LogType_None, func(lt LogType, options HandlerCreatorOptions) (log.Handler, error) {
@@ -50,3 +50,95 @@ func TestCustomLogHandler(t *testing.T) {
 
 	common.Must(logger.Close())
 }
+
+type moduleContent struct {
+	module  string
+	content string
+}
+
+func (c moduleContent) PkgPath() string {
+	return c.module
+}
+
+func (c moduleContent) String() string {
+	return c.content
+}
+
+func TestModuleLevels(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	var loggedValue []string
+
+	mockHandler := mocks.NewLogHandler(mockCtl)
+	mockHandler.EXPECT().Handle(gomock.Any()).AnyTimes().DoAndReturn(func(msg clog.Message) {
+		loggedValue = append(loggedValue, msg.String())
+	})
+
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return mockHandler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		ErrorLogLevel: clog.Severity_Warning,
+		ErrorLogType:  log.LogType_Console,
+		AccessLogType: log.LogType_None,
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+	defer logger.Close()
+
+	logger.SetModuleLevel("transport/internet", clog.Severity_Info)
+	logger.SetModuleLevel("transport/internet/tunnel", clog.Severity_Debug)
+	logger.SetModuleLevel("proxy", clog.Severity_Error)
+
+	testCases := []struct {
+		module   string
+		severity clog.Severity
+		logged   bool
+	}{
+		{module: "transport/internet/tunnel", severity: clog.Severity_Debug, logged: true},
+		{module: "transport/internet/tunnel/stack", severity: clog.Severity_Debug, logged: true},
+		{module: "transport/internet/tcp", severity: clog.Severity_Debug, logged: false},
+		{module: "transport/internet/tcp", severity: clog.Severity_Info, logged: true},
+		{module: "transport/internet", severity: clog.Severity_Info, logged: true},
+		{module: "transport/internetx", severity: clog.Severity_Info, logged: false},
+		{module: "proxy/freedom", severity: clog.Severity_Warning, logged: false},
+		{module: "app/dispatcher", severity: clog.Severity_Warning, logged: true},
+		{module: "app/dispatcher", severity: clog.Severity_Info, logged: false},
+	}
+	for _, tc := range testCases {
+		loggedValue = nil
+		clog.Record(&clog.GeneralMessage{
+			Severity: tc.severity,
+			Content:  moduleContent{module: tc.module, content: "test"},
+		})
+		if logged := len(loggedValue) > 0; logged != tc.logged {
+			t.Error("expect logged ", tc.logged, " for ", tc.severity, " in ", tc.module, ", but got ", logged)
+		}
+	}
+
+	logger.SetModuleLevel("proxy", clog.Severity_Unknown)
+	logger.SetLevel(clog.Severity_Info)
+	if levels := logger.ModuleLevels(); len(levels) != 2 {
+		t.Error("expect 2 module levels, but got ", levels)
+	}
+	loggedValue = nil
+	clog.Record(&clog.GeneralMessage{
+		Severity: clog.Severity_Info,
+		Content:  moduleContent{module: "proxy/freedom", content: "test"},
+	})
+	if len(loggedValue) != 1 {
+		t.Error("expect message logged at the error log level, but got ", loggedValue)
+	}
+
+	logger.ResetModuleLevels()
+	loggedValue = nil
+	clog.Record(&clog.GeneralMessage{
+		Severity: clog.Severity_Debug,
+		Content:  moduleContent{module: "transport/internet/tunnel", content: "test"},
+	})
+	if len(loggedValue) != 0 {
+		t.Error("expect no message logged after reset, but got ", loggedValue)
+	}
+}
@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
+)
+
+// counterVisitor is implemented by stats managers that can list their counters.
+type counterVisitor interface {
+	VisitCounters(func(string, stats.Counter) bool)
+}
+
+// collect returns all metrics of the instance. Counters are read without being
+// reset.
+func (m *Metrics) collect() []stats.Metric {
+	var metrics []stats.Metric
+
+	if manager, ok := m.instance.GetFeature(stats.ManagerType()).(counterVisitor); ok {
+		manager.VisitCounters(func(name string, c stats.Counter) bool {
+			metrics = append(metrics, counterMetric(name, c.Value()))
+			return true
+		})
+	}
+
+	metrics = append(metrics, m.runtimeMetrics()...)
+
+	for _, feature := range []interface{}{
+		m.instance.GetFeature(routing.DispatcherType()),
+		m.instance.GetFeature(dns.ClientType()),
+	} {
+		if provider, ok := feature.(stats.MetricsProvider); ok {
+			metrics = append(metrics, provider.Metrics()...)
+		}
+	}
+	stats.VisitMetricsProviders(func(name string, provider stats.MetricsProvider) bool {
+		metrics = append(metrics, provider.Metrics()...)
+		return true
+	})
+
+	return metrics
+}
+
+// runtimeMetrics returns the metrics of GetSysStats in StatsService.
+func (m *Metrics) runtimeMetrics() []stats.Metric {
+	var rtm runtime.MemStats
+	runtime.ReadMemStats(&rtm)
+
+	return []stats.Metric{
+		{Name: "xray_uptime_seconds", Help: "Seconds since Xray started.", Value: time.Since(m.startTime).Seconds(), Gauge: true},
+		{Name: "xray_goroutines", Help: "Number of goroutines.", Value: float64(runtime.NumGoroutine()), Gauge: true},
+		{Name: "xray_memory_alloc_bytes", Help: "Bytes of allocated heap objects.", Value: float64(rtm.Alloc), Gauge: true},
+		{Name: "xray_memory_alloc_bytes_total", Help: "Cumulative bytes allocated for heap objects.", Value: float64(rtm.TotalAlloc)},
+		{Name: "xray_memory_sys_bytes", Help: "Bytes of memory obtained from the OS.", Value: float64(rtm.Sys), Gauge: true},
+		{Name: "xray_memory_mallocs_total", Help: "Cumulative count of heap objects allocated.", Value: float64(rtm.Mallocs)},
+		{Name: "xray_memory_frees_total", Help: "Cumulative count of heap objects freed.", Value: float64(rtm.Frees)},
+		{Name: "xray_memory_live_objects", Help: "Number of live heap objects.", Value: float64(rtm.Mallocs - rtm.Frees), Gauge: true},
+		{Name: "xray_gc_runs_total", Help: "Number of completed GC cycles.", Value: float64(rtm.NumGC)},
+		{Name: "xray_gc_pause_seconds_total", Help: "Cumulative seconds in GC stop-the-world pauses.", Value: float64(rtm.PauseTotalNs) / 1e9},
+	}
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+func metricName(parts ...string) string {
+	for i, p := range parts {
+		parts[i] = invalidNameChars.ReplaceAllString(strings.ToLower(p), "_")
+	}
+	return "xray_" + strings.Join(parts, "_")
+}
+
+// counterMetric converts a counter to a metric, parsing labels from the
+// ">>>"-separated name of the counter.
+func counterMetric(name string, value int64) stats.Metric {
+	metric := stats.Metric{Value: float64(value)}
+	parts := strings.Split(name, ">>>")
+	switch {
+	case len(parts) == 4 && parts[0] == "dns" && parts[1] == "upstream":
+		// dns>>>upstream>>>server>>>success|failure|latency
+		if parts[3] == "latency" {
+			metric.Name = "xray_dns_upstream_latency_milliseconds_total"
+			metric.Help = "Sum of latencies of successful queries to DNS servers."
+			metric.Labels = map[string]string{"server": parts[2]}
+		} else {
+			metric.Name = "xray_dns_upstream_queries_total"
+			metric.Help = "Number of queries to DNS servers."
+			metric.Labels = map[string]string{"server": parts[2], "result": parts[3]}
+		}
+	case len(parts) == 4:
+		// inbound|outbound|user>>>tag>>>traffic>>>uplink|downlink
+		if parts[2] == "traffic" {
+			metric.Name = "xray_traffic_bytes_total"
+			metric.Help = "Traffic in bytes."
+		} else {
+			metric.Name = metricName(parts[2], "total")
+			metric.Help = "Xray stats counter."
+		}
+		metric.Labels = map[string]string{"type": parts[0], "tag": parts[1], "direction": parts[3]}
+	case len(parts) == 3:
+		// Such as dns>>>cache>>>hit.
+		metric.Name = metricName(parts[0], parts[1], parts[2], "total")
+		metric.Help = "Xray stats counter " + name + "."
+	default:
+		metric.Name = "xray_counter_total"
+		metric.Help = "Xray stats counter."
+		metric.Labels = map[string]string{"name": name}
+	}
+	return metric
+}
+
+// writeMetrics writes metrics in the Prometheus text format. Metrics of the
+// same name are grouped under a single HELP and TYPE.
+func writeMetrics(w io.Writer, metrics []stats.Metric) error {
+	sort.SliceStable(metrics, func(i, j int) bool {
+		return metrics[i].Name < metrics[j].Name
+	})
+
+	bw := bufio.NewWriter(w)
+	for i, metric := range metrics {
+		if i == 0 || metrics[i-1].Name != metric.Name {
+			typ := "counter"
+			if metric.Gauge {
+				typ = "gauge"
+			}
+			if metric.Help != "" {
+				bw.WriteString("# HELP " + metric.Name + " " + escapeHelp(metric.Help) + "\n")
+			}
+			bw.WriteString("# TYPE " + metric.Name + " " + typ + "\n")
+		}
+		bw.WriteString(metric.Name)
+		writeLabels(bw, metric.Labels)
+		bw.WriteString(" " + strconv.FormatFloat(metric.Value, 'g', -1, 64) + "\n")
+	}
+	return bw.Flush()
+}
+
+func writeLabels(w *bufio.Writer, labels map[string]string) {
+	if len(labels) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(k + `="` + labelEscaper.Replace(labels[k]) + `"`)
+	}
+	w.WriteByte('}')
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
@@ -0,0 +1,90 @@
+package metrics
+
+//go:generate go run github.com/xtls/xray-core/common/errors/errorgen
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/core"
+)
+
+const defaultPath = "/metrics"
+
+// Metrics is a Xray feature that serves metrics over HTTP in the Prometheus
+// text format.
+type Metrics struct {
+	config    *Config
+	instance  *core.Instance
+	server    *http.Server
+	startTime time.Time
+}
+
+// NewMetrics creates a new Metrics based on the given config.
+func NewMetrics(ctx context.Context, config *Config) (*Metrics, error) {
+	if config.Listen == "" {
+		return nil, newError("listen address of metrics is not specified")
+	}
+	m := &Metrics{
+		config:    config,
+		instance:  core.MustFromContext(ctx),
+		startTime: time.Now(),
+	}
+	path := config.Path
+	if path == "" {
+		path = defaultPath
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, http.HandlerFunc(m.serveMetrics))
+	m.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: time.Second * 4,
+	}
+	return m, nil
+}
+
+// Type implements common.HasType.
+func (m *Metrics) Type() interface{} {
+	return (*Metrics)(nil)
+}
+
+// Start implements common.Runnable.
+func (m *Metrics) Start() error {
+	listener, err := net.Listen("tcp", m.config.Listen)
+	if err != nil {
+		return newError("failed to listen on ", m.config.Listen).Base(err)
+	}
+	newError("metrics listening on ", listener.Addr()).AtInfo().WriteToLog()
+
+	go func() {
+		if err := m.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			newError("failed to serve metrics").Base(err).AtError().WriteToLog()
+		}
+	}()
+	return nil
+}
+
+// Close implements common.Closable.
+func (m *Metrics) Close() error {
+	return m.server.Close()
+}
+
+func (m *Metrics) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := writeMetrics(w, m.collect()); err != nil {
+		newError("failed to write metrics").Base(err).AtDebug().WriteToLog()
+	}
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		return NewMetrics(ctx, config.(*Config))
+	}))
+}
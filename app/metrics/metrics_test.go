@@ -0,0 +1,115 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/app/dispatcher"
+	"github.com/xtls/xray-core/app/metrics"
+	"github.com/xtls/xray-core/app/proxyman"
+	_ "github.com/xtls/xray-core/app/proxyman/outbound"
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	feature_stats "github.com/xtls/xray-core/features/stats"
+	"github.com/xtls/xray-core/testing/servers/tcp"
+)
+
+type staticProvider []feature_stats.Metric
+
+func (p staticProvider) Metrics() []feature_stats.Metric {
+	return p
+}
+
+func scrape(t *testing.T, url string) string {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	common.Must(err)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("unexpected status: ", resp.Status)
+	}
+	b, err := io.ReadAll(resp.Body)
+	common.Must(err)
+	return string(b)
+}
+
+func TestMetrics(t *testing.T) {
+	port := tcp.PickPort()
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&stats.Config{}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&metrics.Config{
+				Listen: "127.0.0.1:" + port.String(),
+			}),
+		},
+	}
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+	time.Sleep(time.Millisecond * 100)
+
+	manager := v.GetFeature(feature_stats.ManagerType()).(feature_stats.Manager)
+	for name, value := range map[string]int64{
+		"inbound>>>socks-in>>>traffic>>>uplink":    100,
+		"user>>>a@b.com>>>traffic>>>downlink":      200,
+		"dns>>>cache>>>hit":                        3,
+		"dns>>>upstream>>>udp://8.8.8.8>>>success": 4,
+		"custom": 5,
+	} {
+		c, err := manager.RegisterCounter(name)
+		common.Must(err)
+		c.Set(value)
+	}
+
+	feature_stats.RegisterMetricsProvider("test", staticProvider{
+		{Name: "xray_test_value", Labels: map[string]string{"note": "a \"quoted\"\nvalue"}, Value: 1.5, Gauge: true},
+	})
+	defer feature_stats.UnregisterMetricsProvider("test")
+
+	url := "http://127.0.0.1:" + port.String() + "/metrics"
+	for i := 0; i < 2; i++ {
+		body := scrape(t, url)
+		for _, line := range []string{
+			"# TYPE xray_traffic_bytes_total counter",
+			`xray_traffic_bytes_total{direction="uplink",tag="socks-in",type="inbound"} 100`,
+			`xray_traffic_bytes_total{direction="downlink",tag="a@b.com",type="user"} 200`,
+			"xray_dns_cache_hit_total 3",
+			`xray_dns_upstream_queries_total{result="success",server="udp://8.8.8.8"} 4`,
+			`xray_counter_total{name="custom"} 5`,
+			"# TYPE xray_dispatcher_active_links gauge",
+			"xray_dispatcher_links_total 0",
+			"# TYPE xray_goroutines gauge",
+			"xray_gc_runs_total ",
+			"xray_uptime_seconds ",
+			`xray_test_value{note="a \"quoted\"\nvalue"} 1.5`,
+		} {
+			if !strings.Contains(body, line) {
+				t.Error("scrape ", i, ": missing ", line, " in:\n", body)
+			}
+		}
+		if strings.Count(body, "# TYPE xray_traffic_bytes_total ") != 1 {
+			t.Error("metrics of the same name are not grouped")
+		}
+	}
+
+	// Scraping never resets counters.
+	if value := manager.GetCounter("inbound>>>socks-in>>>traffic>>>uplink").Value(); value != 100 {
+		t.Error("counter is reset to ", value)
+	}
+
+	resp, err := http.Get("http://127.0.0.1:" + port.String() + "/other")
+	common.Must(err)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Error("expect 404 for other paths, but got ", resp.Status)
+	}
+}
@@ -58,6 +58,20 @@ func (p *Policy) overrideWith(another *Policy) {
 			Connection: another.Buffer.Connection,
 		}
 	}
+	if another.Limit != nil {
+		p.Limit = &Policy_Limit{
+			Connections:    another.Limit.Connections,
+			SourceIps:      another.Limit.SourceIps,
+			SourceIpWindow: another.Limit.SourceIpWindow,
+		}
+	}
+	if another.Rate != nil {
+		p.Rate = &Rate{
+			Uplink:   another.Rate.Uplink,
+			Downlink: another.Rate.Downlink,
+			Burst:    another.Rate.Burst,
+		}
+	}
 }
 
 // ToCorePolicy converts this Policy to policy.Session.
@@ -77,17 +91,51 @@ func (p *Policy) ToCorePolicy() policy.Session {
 	if p.Buffer != nil {
 		cp.Buffer.PerConnection = p.Buffer.Connection
 	}
+	if p.Rate != nil {
+		cp.Rate = p.Rate.ToCorePolicy()
+	}
+	if p.Limit != nil {
+		cp.Limit = policy.Limit{
+			Connections:    p.Limit.Connections,
+			SourceIPs:      p.Limit.SourceIps,
+			SourceIPWindow: time.Second * time.Duration(p.Limit.SourceIpWindow),
+		}
+	}
 	return cp
 }
 
+// ToCorePolicy converts this Rate to policy.Rate.
+func (r *Rate) ToCorePolicy() policy.Rate {
+	return policy.Rate{
+		Uplink:   r.Uplink,
+		Downlink: r.Downlink,
+		Burst:    r.Burst,
+	}
+}
+
+func toCoreRates(rates map[string]*Rate) map[string]policy.Rate {
+	if len(rates) == 0 {
+		return nil
+	}
+	m := make(map[string]policy.Rate, len(rates))
+	for tag, r := range rates {
+		if r != nil {
+			m[tag] = r.ToCorePolicy()
+		}
+	}
+	return m
+}
+
 // ToCorePolicy converts this SystemPolicy to policy.System.
 func (p *SystemPolicy) ToCorePolicy() policy.System {
 	return policy.System{
 		Stats: policy.SystemStats{
-			InboundUplink:    p.Stats.InboundUplink,
-			InboundDownlink:  p.Stats.InboundDownlink,
-			OutboundUplink:   p.Stats.OutboundUplink,
-			OutboundDownlink: p.Stats.OutboundDownlink,
+			InboundUplink:    p.GetStats().GetInboundUplink(),
+			InboundDownlink:  p.GetStats().GetInboundDownlink(),
+			OutboundUplink:   p.GetStats().GetOutboundUplink(),
+			OutboundDownlink: p.GetStats().GetOutboundDownlink(),
 		},
+		InboundRate:  toCoreRates(p.InboundRate),
+		OutboundRate: toCoreRates(p.OutboundRate),
 	}
 }
@@ -2,6 +2,7 @@ package policy
 
 import (
 	"context"
+	"sync"
 
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/features/policy"
@@ -10,14 +11,22 @@ import (
 // Instance is an instance of Policy manager.
 type Instance struct {
 	levels map[uint32]*Policy
-	system *SystemPolicy
+	users  map[string]*Policy
+	system policy.System
+
+	onlineAccess sync.Mutex
+	online       map[string]*userConnections
 }
 
 // New creates new Policy manager instance.
 func New(ctx context.Context, config *Config) (*Instance, error) {
 	m := &Instance{
 		levels: make(map[uint32]*Policy),
-		system: config.System,
+		users:  config.User,
+		online: make(map[string]*userConnections),
+	}
+	if config.System != nil {
+		m.system = config.System.ToCorePolicy()
 	}
 	if len(config.Level) > 0 {
 		for lv, p := range config.Level {
@@ -43,12 +52,23 @@ func (m *Instance) ForLevel(level uint32) policy.Session {
 	return policy.SessionDefault()
 }
 
+// ForUser implements policy.UserManager.
+func (m *Instance) ForUser(level uint32, email string) policy.Session {
+	up, ok := m.users[email]
+	if !ok || up == nil || email == "" {
+		return m.ForLevel(level)
+	}
+	p := defaultPolicy()
+	if lp, ok := m.levels[level]; ok {
+		p.overrideWith(lp)
+	}
+	p.overrideWith(up)
+	return p.ToCorePolicy()
+}
+
 // ForSystem implements policy.Manager.
 func (m *Instance) ForSystem() policy.System {
-	if m.system == nil {
-		return policy.System{}
-	}
-	return m.system.ToCorePolicy()
+	return m.system
 }
 
 // Start implements common.Runnable.Start().
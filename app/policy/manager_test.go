@@ -2,11 +2,14 @@ package policy_test
 
 import (
 	"context"
+	"strconv"
 	"testing"
 	"time"
 
 	. "github.com/xtls/xray-core/app/policy"
 	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/features/policy"
 )
 
@@ -43,3 +46,95 @@ func TestPolicy(t *testing.T) {
 		}
 	}
 }
+
+func TestUserPolicy(t *testing.T) {
+	manager, err := New(context.Background(), &Config{
+		Level: map[uint32]*Policy{
+			1: {
+				Timeout: &Policy_Timeout{
+					Handshake: &Second{Value: 2},
+				},
+				Rate: &Rate{Uplink: 1024, Downlink: 2048},
+			},
+		},
+		User: map[string]*Policy{
+			"test@example.com": {
+				Rate: &Rate{Downlink: 4096, Burst: 8192},
+			},
+		},
+		System: &SystemPolicy{
+			InboundRate: map[string]*Rate{
+				"in": {Uplink: 100},
+			},
+		},
+	})
+	common.Must(err)
+
+	p := policy.ForUser(manager, 1, "test@example.com")
+	if p.Timeouts.Handshake != 2*time.Second {
+		t.Error("expect 2 sec timeout of the level, but got ", p.Timeouts.Handshake)
+	}
+	if p.Rate != (policy.Rate{Downlink: 4096, Burst: 8192}) {
+		t.Error("unexpected rate of the user: ", p.Rate)
+	}
+
+	p = policy.ForUser(manager, 1, "other@example.com")
+	if p.Rate != (policy.Rate{Uplink: 1024, Downlink: 2048}) {
+		t.Error("unexpected rate of the level: ", p.Rate)
+	}
+
+	if r := manager.ForSystem().InboundRate["in"]; r.Uplink != 100 {
+		t.Error("unexpected inbound rate: ", r)
+	}
+}
+
+func TestConnectionLimit(t *testing.T) {
+	manager, err := New(context.Background(), &Config{
+		User: map[string]*Policy{
+			"test@example.com": {
+				Limit: &Policy_Limit{Connections: 2, SourceIps: 1, SourceIpWindow: 60},
+			},
+		},
+	})
+	common.Must(err)
+
+	user := &protocol.MemoryUser{Email: "test@example.com"}
+	ip1 := net.ParseAddress("10.0.0.1")
+	ip2 := net.ParseAddress("10.0.0.2")
+
+	release1, err := policy.AcquireConnection(manager, user, ip1)
+	common.Must(err)
+	release2, err := policy.AcquireConnection(manager, user, ip1)
+	common.Must(err)
+	if _, err := policy.AcquireConnection(manager, user, ip1); err == nil {
+		t.Error("expect error over the connection limit")
+	}
+
+	users := manager.OnlineUsers()
+	if len(users) != 1 || users[0].Email != user.Email || users[0].Connections != 2 || len(users[0].SourceIPs) != 1 || !users[0].SourceIPs[0].Equal(ip1.IP()) {
+		t.Error("unexpected online users: ", users)
+	}
+
+	release1()
+	release1()
+	if _, err := policy.AcquireConnection(manager, user, ip2); err == nil {
+		t.Error("expect error over the source IP limit")
+	}
+	release2()
+
+	// The source IP is still counted within the window.
+	if _, err := policy.AcquireConnection(manager, user, ip2); err == nil {
+		t.Error("expect error over the source IP limit within the window")
+	}
+	if users := manager.OnlineUsers(); len(users) != 0 {
+		t.Error("expect no online users, but got ", users)
+	}
+
+	// Other users are not limited.
+	other := &protocol.MemoryUser{Email: "other@example.com"}
+	for i := 0; i < 4; i++ {
+		if _, err := policy.AcquireConnection(manager, other, net.ParseAddress("10.0.1."+strconv.Itoa(i))); err != nil {
+			t.Error(err)
+		}
+	}
+}
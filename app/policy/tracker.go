@@ -0,0 +1,120 @@
+package policy
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/features/policy"
+)
+
+// userConnections is the connections of a user.
+type userConnections struct {
+	connections uint32
+	ips         map[string]*sourceIP
+}
+
+type sourceIP struct {
+	ip          net.IP
+	connections uint32
+	// lastSeen is when the last connection from the IP ended.
+	lastSeen time.Time
+}
+
+// prune removes source IPs without connections out of the window.
+func (u *userConnections) prune(now time.Time, window time.Duration) {
+	for key, s := range u.ips {
+		if s.connections == 0 && now.Sub(s.lastSeen) >= window {
+			delete(u.ips, key)
+		}
+	}
+}
+
+// AcquireConnection implements policy.UserTracker.
+func (m *Instance) AcquireConnection(user *protocol.MemoryUser, source net.Address) (func(), error) {
+	if user.Email == "" {
+		return func() {}, nil
+	}
+	limit := m.ForUser(user.Level, user.Email).Limit
+	now := time.Now()
+
+	m.onlineAccess.Lock()
+	defer m.onlineAccess.Unlock()
+
+	u, found := m.online[user.Email]
+	if !found {
+		u = &userConnections{
+			ips: make(map[string]*sourceIP),
+		}
+		m.online[user.Email] = u
+	}
+	u.prune(now, limit.SourceIPWindow)
+
+	if limit.Connections > 0 && u.connections >= limit.Connections {
+		return nil, newError("user ", user.Email, " exceeds the limit of ", limit.Connections, " connections")
+	}
+	var s *sourceIP
+	if source != nil && source.Family().IsIP() {
+		key := source.IP().String()
+		s = u.ips[key]
+		if s == nil {
+			if limit.SourceIPs > 0 && uint32(len(u.ips)) >= limit.SourceIPs {
+				return nil, newError("user ", user.Email, " exceeds the limit of ", limit.SourceIPs, " source IPs")
+			}
+			s = &sourceIP{ip: source.IP()}
+			u.ips[key] = s
+		}
+		s.connections++
+	}
+	u.connections++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.onlineAccess.Lock()
+			defer m.onlineAccess.Unlock()
+
+			u.connections--
+			if s != nil {
+				s.connections--
+				s.lastSeen = time.Now()
+			}
+			if u.connections == 0 {
+				u.prune(time.Now(), limit.SourceIPWindow)
+				if len(u.ips) == 0 && m.online[user.Email] == u {
+					delete(m.online, user.Email)
+				}
+			}
+		})
+	}, nil
+}
+
+// OnlineUsers implements policy.UserTracker.
+func (m *Instance) OnlineUsers() []policy.OnlineUser {
+	m.onlineAccess.Lock()
+	defer m.onlineAccess.Unlock()
+
+	users := make([]policy.OnlineUser, 0, len(m.online))
+	for email, u := range m.online {
+		if u.connections == 0 {
+			continue
+		}
+		user := policy.OnlineUser{
+			Email:       email,
+			Connections: u.connections,
+		}
+		for _, s := range u.ips {
+			user.SourceIPs = append(user.SourceIPs, s.ip)
+		}
+		sort.Slice(user.SourceIPs, func(i, j int) bool {
+			return user.SourceIPs[i].String() < user.SourceIPs[j].String()
+		})
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Email < users[j].Email
+	})
+	return users
+}
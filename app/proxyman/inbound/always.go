@@ -65,6 +65,7 @@ func NewAlwaysOnInboundHandler(ctx context.Context, tag string, receiverConfig *
 	}
 
 	uplinkCounter, downlinkCounter := getStatCounter(core.MustFromContext(ctx), tag)
+	dispatcher := newQuotaDispatcher(ctx, h.mux)
 
 	nl := p.Network()
 	pr := receiverConfig.PortRange
@@ -96,7 +97,7 @@ func NewAlwaysOnInboundHandler(ctx context.Context, tag string, receiverConfig *
 				proxy:           p,
 				stream:          mss,
 				tag:             tag,
-				dispatcher:      h.mux,
+				dispatcher:      dispatcher,
 				sniffingConfig:  receiverConfig.GetEffectiveSniffingSettings(),
 				uplinkCounter:   uplinkCounter,
 				downlinkCounter: downlinkCounter,
@@ -117,7 +118,7 @@ func NewAlwaysOnInboundHandler(ctx context.Context, tag string, receiverConfig *
 					stream:          mss,
 					recvOrigDest:    receiverConfig.ReceiveOriginalDestination,
 					tag:             tag,
-					dispatcher:      h.mux,
+					dispatcher:      dispatcher,
 					sniffingConfig:  receiverConfig.GetEffectiveSniffingSettings(),
 					uplinkCounter:   uplinkCounter,
 					downlinkCounter: downlinkCounter,
@@ -132,7 +133,7 @@ func NewAlwaysOnInboundHandler(ctx context.Context, tag string, receiverConfig *
 					proxy:           p,
 					address:         address,
 					port:            net.Port(port),
-					dispatcher:      h.mux,
+					dispatcher:      dispatcher,
 					sniffingConfig:  receiverConfig.GetEffectiveSniffingSettings(),
 					uplinkCounter:   uplinkCounter,
 					downlinkCounter: downlinkCounter,
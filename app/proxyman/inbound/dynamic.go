@@ -11,6 +11,7 @@ import (
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/task"
 	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/routing"
 	"github.com/xtls/xray-core/proxy"
 	"github.com/xtls/xray-core/transport/internet"
 )
@@ -27,6 +28,7 @@ type DynamicInboundHandler struct {
 	worker         []worker
 	lastRefresh    time.Time
 	mux            *mux.Server
+	dispatcher     routing.Dispatcher
 	task           *task.Periodic
 
 	ctx context.Context
@@ -43,6 +45,7 @@ func NewDynamicInboundHandler(ctx context.Context, tag string, receiverConfig *p
 		v:              v,
 		ctx:            ctx,
 	}
+	h.dispatcher = newQuotaDispatcher(ctx, h.mux)
 
 	mss, err := internet.ToMemoryStreamConfig(receiverConfig.StreamSettings)
 	if err != nil {
@@ -133,7 +136,7 @@ func (h *DynamicInboundHandler) refresh() error {
 				proxy:           p,
 				stream:          h.streamSettings,
 				recvOrigDest:    h.receiverConfig.ReceiveOriginalDestination,
-				dispatcher:      h.mux,
+				dispatcher:      h.dispatcher,
 				sniffingConfig:  h.receiverConfig.GetEffectiveSniffingSettings(),
 				uplinkCounter:   uplinkCounter,
 				downlinkCounter: downlinkCounter,
@@ -152,7 +155,7 @@ func (h *DynamicInboundHandler) refresh() error {
 				proxy:           p,
 				address:         address,
 				port:            port,
-				dispatcher:      h.mux,
+				dispatcher:      h.dispatcher,
 				sniffingConfig:  h.receiverConfig.GetEffectiveSniffingSettings(),
 				uplinkCounter:   uplinkCounter,
 				downlinkCounter: downlinkCounter,
@@ -20,12 +20,14 @@ type Manager struct {
 	untaggedHandler []inbound.Handler
 	taggedHandlers  map[string]inbound.Handler
 	running         bool
+	quota           *quotaEnforcer
 }
 
 // New returns a new Manager for inbound handlers.
 func New(ctx context.Context, config *proxyman.InboundConfig) (*Manager, error) {
 	m := &Manager{
 		taggedHandlers: make(map[string]inbound.Handler),
+		quota:          newQuotaEnforcer(ctx, config.Quota),
 	}
 	return m, nil
 }
@@ -96,6 +98,10 @@ func (m *Manager) Start() error {
 
 	m.running = true
 
+	if err := m.quota.Start(); err != nil {
+		return err
+	}
+
 	for _, handler := range m.taggedHandlers {
 		if err := handler.Start(); err != nil {
 			return err
@@ -118,6 +124,9 @@ func (m *Manager) Close() error {
 	m.running = false
 
 	var errors []interface{}
+	if err := m.quota.Close(); err != nil {
+		errors = append(errors, err)
+	}
 	for _, handler := range m.taggedHandlers {
 		if err := handler.Close(); err != nil {
 			errors = append(errors, err)
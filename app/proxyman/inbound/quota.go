@@ -0,0 +1,466 @@
+package inbound
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xtls/xray-core/app/proxyman"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/platform/filesystem"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/inbound"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/features/stats"
+	"github.com/xtls/xray-core/transport"
+)
+
+const (
+	defaultQuotaSaveInterval = time.Minute
+	quotaCheckInterval       = time.Second * 10
+	// quotaIdleTimeout is the duration to keep users without connections.
+	quotaIdleTimeout = time.Minute
+)
+
+// userQuota is the traffic usage and the limits of a user.
+type userQuota struct {
+	used  uint64
+	quota uint64
+
+	email      string
+	expiry     time.Time
+	notified   bool
+	links      map[*quotaLink]struct{}
+	lastActive time.Time
+}
+
+// violation returns the reason why the user is not allowed at the time, or an
+// empty string if allowed.
+func (u *userQuota) violation(now time.Time) string {
+	if quota := atomic.LoadUint64(&u.quota); quota > 0 && atomic.LoadUint64(&u.used) >= quota {
+		return proxyman.QuotaExceeded
+	}
+	if !u.expiry.IsZero() && !now.Before(u.expiry) {
+		return proxyman.QuotaExpired
+	}
+	return ""
+}
+
+// quotaEnforcer tracks traffic usage of users with quota or expiry, shared by
+// all inbound handlers. New connections of users exceeding their quota or
+// expired are rejected, and existing ones are closed if configured.
+type quotaEnforcer struct {
+	sync.Mutex
+	config  *proxyman.QuotaConfig
+	users   map[string]*userQuota
+	channel stats.Channel
+	persist *task.Periodic
+	check   *task.Periodic
+
+	// saved is the persisted usage of users removed from users for being
+	// idle. It is restored when they connect again.
+	saved map[string]uint64
+}
+
+func newQuotaEnforcer(ctx context.Context, config *proxyman.QuotaConfig) *quotaEnforcer {
+	if config == nil {
+		config = &proxyman.QuotaConfig{}
+	}
+	e := &quotaEnforcer{
+		config: config,
+		users:  make(map[string]*userQuota),
+		saved:  make(map[string]uint64),
+	}
+	core.RequireFeatures(ctx, func(sm stats.Manager) {
+		e.channel, _ = stats.GetOrRegisterChannel(sm, proxyman.QuotaEventChannel)
+	})
+	return e
+}
+
+// Start loads the persisted usage and starts the periodic tasks.
+func (e *quotaEnforcer) Start() error {
+	e.check = &task.Periodic{
+		Interval: quotaCheckInterval,
+		Execute: func() error {
+			now := time.Now()
+			e.checkUsers(now)
+			if e.config.UsageFile == "" {
+				e.removeIdleUsers(now, nil)
+			}
+			return nil
+		},
+	}
+	if err := e.check.Start(); err != nil {
+		return err
+	}
+
+	if e.config.UsageFile == "" {
+		return nil
+	}
+	if err := e.load(); err != nil {
+		newError("failed to load usage of users from ", e.config.UsageFile).Base(err).AtWarning().WriteToLog()
+	}
+	interval := defaultQuotaSaveInterval
+	if e.config.SaveInterval > 0 {
+		interval = time.Duration(e.config.SaveInterval) * time.Second
+	}
+	e.persist = &task.Periodic{
+		Interval: interval,
+		Execute: func() error {
+			if err := e.save(); err != nil {
+				newError("failed to save usage of users to ", e.config.UsageFile).Base(err).AtWarning().WriteToLog()
+			}
+			return nil
+		},
+	}
+	return e.persist.Start()
+}
+
+// Close stops the periodic tasks and saves the usage.
+func (e *quotaEnforcer) Close() error {
+	if e.check != nil {
+		e.check.Close()
+	}
+	if e.persist != nil {
+		e.persist.Close()
+		if err := e.save(); err != nil {
+			newError("failed to save usage of users to ", e.config.UsageFile).Base(err).AtWarning().WriteToLog()
+		}
+	}
+	return nil
+}
+
+// Usage returns the traffic used by the user of the email in bytes.
+func (e *quotaEnforcer) Usage(email string) uint64 {
+	e.Lock()
+	defer e.Unlock()
+	if u, found := e.users[email]; found {
+		return atomic.LoadUint64(&u.used)
+	}
+	return e.saved[email]
+}
+
+// getUser returns the usage of the user, with limits updated from the user.
+func (e *quotaEnforcer) getUser(user *protocol.MemoryUser) *userQuota {
+	e.Lock()
+	defer e.Unlock()
+
+	u, found := e.users[user.Email]
+	if !found {
+		u = &userQuota{
+			used:  e.saved[user.Email],
+			email: user.Email,
+			links: make(map[*quotaLink]struct{}),
+		}
+		delete(e.saved, user.Email)
+		e.users[user.Email] = u
+	}
+	atomic.StoreUint64(&u.quota, user.Quota)
+	u.expiry = user.Expiry
+	u.lastActive = time.Now()
+	return u
+}
+
+// removeIdleUsers removes users without connections for quotaIdleTimeout. If
+// persisted is nil, only users without usage are removed. Otherwise users are
+// removed if their usage is persisted as in persisted.
+func (e *quotaEnforcer) removeIdleUsers(now time.Time, persisted map[string]uint64) {
+	e.Lock()
+	defer e.Unlock()
+
+	for email, u := range e.users {
+		if len(u.links) > 0 || now.Sub(u.lastActive) < quotaIdleTimeout {
+			continue
+		}
+		used := atomic.LoadUint64(&u.used)
+		if used > 0 && (persisted == nil || persisted[email] != used) {
+			continue
+		}
+		delete(e.users, email)
+		if used > 0 {
+			e.saved[email] = used
+		}
+	}
+}
+
+// allow returns an error if the user is not allowed to connect.
+func (e *quotaEnforcer) allow(u *userQuota) error {
+	e.Lock()
+	reason := u.violation(time.Now())
+	if reason == "" {
+		// Limits of the user may have been raised.
+		u.notified = false
+	}
+	e.Unlock()
+
+	if reason != "" {
+		e.exceed(u, reason)
+		return newError("user ", u.email, " is rejected for ", reason)
+	}
+	return nil
+}
+
+// add adds traffic of n bytes to the user.
+func (e *quotaEnforcer) add(u *userQuota, n int64) {
+	used := atomic.AddUint64(&u.used, uint64(n))
+	if quota := atomic.LoadUint64(&u.quota); quota > 0 && used >= quota {
+		e.exceed(u, proxyman.QuotaExceeded)
+	}
+}
+
+// exceed publishes the event of the user exceeding its limits once, and closes
+// its connections if configured.
+func (e *quotaEnforcer) exceed(u *userQuota, reason string) {
+	e.Lock()
+	if u.notified {
+		e.Unlock()
+		return
+	}
+	u.notified = true
+	var links []*quotaLink
+	if e.config.CloseConnections {
+		for l := range u.links {
+			links = append(links, l)
+		}
+	}
+	event := &proxyman.QuotaEvent{
+		Time:   time.Now(),
+		Email:  u.email,
+		Reason: reason,
+		Used:   atomic.LoadUint64(&u.used),
+		Quota:  atomic.LoadUint64(&u.quota),
+		Expiry: u.expiry,
+	}
+	e.Unlock()
+
+	newError("user ", u.email, " reached limit of ", reason, ", closing ", len(links), " connections").AtInfo().WriteToLog()
+	if e.channel != nil {
+		e.channel.Publish(context.Background(), event)
+	}
+	for _, l := range links {
+		l.interrupt()
+	}
+}
+
+// checkUsers enforces expiry of users with connections.
+func (e *quotaEnforcer) checkUsers(now time.Time) {
+	var expired []*userQuota
+	e.Lock()
+	for _, u := range e.users {
+		if len(u.links) > 0 && !u.notified && u.violation(now) == proxyman.QuotaExpired {
+			expired = append(expired, u)
+		}
+	}
+	e.Unlock()
+
+	for _, u := range expired {
+		e.exceed(u, proxyman.QuotaExpired)
+	}
+}
+
+// track returns a link counting traffic of the user.
+func (e *quotaEnforcer) track(u *userQuota, link *transport.Link) *transport.Link {
+	l := &quotaLink{
+		enforcer: e,
+		user:     u,
+		link:     link,
+	}
+	e.Lock()
+	u.links[l] = struct{}{}
+	e.Unlock()
+
+	return &transport.Link{
+		Reader: &quotaReader{link: l, Reader: link.Reader},
+		Writer: &quotaWriter{link: l, Writer: link.Writer},
+	}
+}
+
+func (e *quotaEnforcer) untrack(l *quotaLink) {
+	e.Lock()
+	delete(l.user.links, l)
+	l.user.lastActive = time.Now()
+	e.Unlock()
+}
+
+// load restores usage of users from the usage file.
+func (e *quotaEnforcer) load() error {
+	b, err := ioutil.ReadFile(e.config.UsageFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	usage := make(map[string]uint64)
+	if err := json.Unmarshal(b, &usage); err != nil {
+		return newError("invalid usage file").Base(err)
+	}
+
+	e.Lock()
+	defer e.Unlock()
+	for email, used := range usage {
+		if u, found := e.users[email]; found {
+			atomic.StoreUint64(&u.used, used)
+			continue
+		}
+		e.saved[email] = used
+	}
+	newError("restored usage of ", len(usage), " users").AtInfo().WriteToLog()
+	return nil
+}
+
+// save writes usage of users to the usage file, and removes idle users whose
+// usage is written.
+func (e *quotaEnforcer) save() error {
+	usage := make(map[string]uint64)
+	e.Lock()
+	for email, used := range e.saved {
+		usage[email] = used
+	}
+	for email, u := range e.users {
+		if used := atomic.LoadUint64(&u.used); used > 0 {
+			usage[email] = used
+		}
+	}
+	e.Unlock()
+
+	b, err := json.Marshal(usage)
+	if err != nil {
+		return err
+	}
+
+	if err := filesystem.WriteFileAtomic(e.config.UsageFile, b); err != nil {
+		return err
+	}
+	e.removeIdleUsers(time.Now(), usage)
+	return nil
+}
+
+// quotaLink is a link of a user whose traffic is counted.
+type quotaLink struct {
+	enforcer *quotaEnforcer
+	user     *userQuota
+	link     *transport.Link
+	once     sync.Once
+}
+
+func (l *quotaLink) add(n int32) {
+	if n > 0 {
+		l.enforcer.add(l.user, int64(n))
+	}
+}
+
+// done stops tracking the link.
+func (l *quotaLink) done() {
+	l.once.Do(func() {
+		l.enforcer.untrack(l)
+	})
+}
+
+func (l *quotaLink) interrupt() {
+	common.Interrupt(l.link.Reader)
+	common.Interrupt(l.link.Writer)
+	l.done()
+}
+
+type quotaReader struct {
+	buf.Reader
+	link *quotaLink
+}
+
+func (r *quotaReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	mb, err := r.Reader.ReadMultiBuffer()
+	r.link.add(mb.Len())
+	if err != nil {
+		r.link.done()
+	}
+	return mb, err
+}
+
+// ReadMultiBufferTimeout implements buf.TimeoutReader.
+func (r *quotaReader) ReadMultiBufferTimeout(timeout time.Duration) (buf.MultiBuffer, error) {
+	tr, ok := r.Reader.(buf.TimeoutReader)
+	if !ok {
+		return r.ReadMultiBuffer()
+	}
+	mb, err := tr.ReadMultiBufferTimeout(timeout)
+	r.link.add(mb.Len())
+	if err != nil && err != buf.ErrReadTimeout {
+		r.link.done()
+	}
+	return mb, err
+}
+
+func (r *quotaReader) Interrupt() {
+	common.Interrupt(r.Reader)
+	r.link.done()
+}
+
+type quotaWriter struct {
+	buf.Writer
+	link *quotaLink
+}
+
+func (w *quotaWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	w.link.add(mb.Len())
+	return w.Writer.WriteMultiBuffer(mb)
+}
+
+func (w *quotaWriter) Close() error {
+	return common.Close(w.Writer)
+}
+
+func (w *quotaWriter) Interrupt() {
+	common.Interrupt(w.Writer)
+	w.link.done()
+}
+
+// quotaDispatcher is a routing.Dispatcher enforcing quota and expiry of users.
+type quotaDispatcher struct {
+	routing.Dispatcher
+	enforcer *quotaEnforcer
+}
+
+// newQuotaDispatcher returns a dispatcher enforcing quota and expiry of users
+// with the inbound manager of the instance, or d if not available.
+func newQuotaDispatcher(ctx context.Context, d routing.Dispatcher) routing.Dispatcher {
+	m, ok := core.MustFromContext(ctx).GetFeature(inbound.ManagerType()).(*Manager)
+	if !ok || m.quota == nil {
+		return d
+	}
+	return &quotaDispatcher{
+		Dispatcher: d,
+		enforcer:   m.quota,
+	}
+}
+
+// Dispatch implements routing.Dispatcher.
+func (d *quotaDispatcher) Dispatch(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+	inbound := session.InboundFromContext(ctx)
+	if inbound == nil || inbound.User == nil || inbound.User.Email == "" {
+		return d.Dispatcher.Dispatch(ctx, dest)
+	}
+	user := inbound.User
+	if user.Quota == 0 && user.Expiry.IsZero() {
+		return d.Dispatcher.Dispatch(ctx, dest)
+	}
+
+	u := d.enforcer.getUser(user)
+	if err := d.enforcer.allow(u); err != nil {
+		return nil, err
+	}
+	link, err := d.Dispatcher.Dispatch(ctx, dest)
+	if err != nil {
+		return nil, err
+	}
+	return d.enforcer.track(u, link), nil
+}
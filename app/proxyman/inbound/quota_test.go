@@ -0,0 +1,229 @@
+package inbound
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/app/proxyman"
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/pipe"
+)
+
+// pipeDispatcher returns links of pipes, with the other ends kept for tests.
+type pipeDispatcher struct {
+	routing.Dispatcher
+	outbound *transport.Link
+}
+
+func (d *pipeDispatcher) Dispatch(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+	uplinkReader, uplinkWriter := pipe.New()
+	downlinkReader, downlinkWriter := pipe.New()
+	d.outbound = &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}
+	return &transport.Link{Reader: downlinkReader, Writer: uplinkWriter}, nil
+}
+
+func newTestEnforcer(t *testing.T, config *proxyman.QuotaConfig) (*quotaEnforcer, chan interface{}) {
+	channel := stats.NewChannel(&stats.ChannelConfig{SubscriberLimit: 1, BufferSize: 16})
+	common.Must(channel.Start())
+	t.Cleanup(func() { channel.Close() })
+	sub, err := channel.Subscribe()
+	common.Must(err)
+
+	return &quotaEnforcer{
+		config:  config,
+		users:   make(map[string]*userQuota),
+		saved:   make(map[string]uint64),
+		channel: channel,
+	}, sub
+}
+
+func userContext(user *protocol.MemoryUser) context.Context {
+	return session.ContextWithInbound(context.Background(), &session.Inbound{User: user})
+}
+
+func writeBytes(t *testing.T, w buf.Writer, n int32) {
+	t.Helper()
+	b := buf.New()
+	b.Extend(n)
+	common.Must(w.WriteMultiBuffer(buf.MultiBuffer{b}))
+}
+
+func TestQuotaExceeded(t *testing.T) {
+	e, events := newTestEnforcer(t, &proxyman.QuotaConfig{CloseConnections: true})
+	next := &pipeDispatcher{}
+	d := &quotaDispatcher{Dispatcher: next, enforcer: e}
+	user := &protocol.MemoryUser{Email: "test@example.com", Quota: 100}
+	dest := net.TCPDestination(net.DomainAddress("example.com"), 80)
+
+	link, err := d.Dispatch(userContext(user), dest)
+	common.Must(err)
+
+	writeBytes(t, link.Writer, 60)
+	mb, err := next.outbound.Reader.ReadMultiBuffer()
+	common.Must(err)
+	buf.ReleaseMulti(mb)
+
+	writeBytes(t, next.outbound.Writer, 50)
+	mb, err = link.Reader.ReadMultiBuffer()
+	common.Must(err)
+	buf.ReleaseMulti(mb)
+
+	select {
+	case msg := <-events:
+		event := msg.(*proxyman.QuotaEvent)
+		if event.Email != user.Email || event.Reason != proxyman.QuotaExceeded || event.Used != 110 || event.Quota != 100 {
+			t.Error("unexpected event: ", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no quota event")
+	}
+
+	// The existing connection is closed.
+	if _, err := link.Reader.ReadMultiBuffer(); err == nil {
+		t.Error("expect link to be closed")
+	}
+
+	// New connections are rejected.
+	if _, err := d.Dispatch(userContext(user), dest); err == nil {
+		t.Error("expect user over quota to be rejected")
+	}
+
+	// Raising the quota allows the user again.
+	user.Quota = 200
+	if _, err := d.Dispatch(userContext(user), dest); err != nil {
+		t.Error("expect user to be allowed, but got ", err)
+	}
+
+	// Users without quota are not tracked.
+	if _, err := d.Dispatch(userContext(&protocol.MemoryUser{Email: "free@example.com"}), dest); err != nil {
+		t.Error(err)
+	}
+	if e.Usage("free@example.com") != 0 || len(e.users) != 1 {
+		t.Error("unexpected tracked users: ", len(e.users))
+	}
+}
+
+func TestQuotaExpired(t *testing.T) {
+	e, events := newTestEnforcer(t, &proxyman.QuotaConfig{CloseConnections: true})
+	next := &pipeDispatcher{}
+	d := &quotaDispatcher{Dispatcher: next, enforcer: e}
+	dest := net.TCPDestination(net.DomainAddress("example.com"), 80)
+
+	if _, err := d.Dispatch(userContext(&protocol.MemoryUser{Email: "old@example.com", Expiry: time.Now().Add(-time.Hour)}), dest); err == nil {
+		t.Error("expect expired user to be rejected")
+	}
+	if event := (<-events).(*proxyman.QuotaEvent); event.Reason != proxyman.QuotaExpired {
+		t.Error("unexpected event: ", event)
+	}
+
+	expiry := time.Now().Add(time.Hour)
+	link, err := d.Dispatch(userContext(&protocol.MemoryUser{Email: "test@example.com", Expiry: expiry}), dest)
+	common.Must(err)
+
+	e.checkUsers(time.Now())
+	select {
+	case <-events:
+		t.Fatal("unexpected event before expiry")
+	default:
+	}
+
+	e.checkUsers(expiry)
+	if event := (<-events).(*proxyman.QuotaEvent); event.Email != "test@example.com" || event.Reason != proxyman.QuotaExpired {
+		t.Error("unexpected event: ", event)
+	}
+	if _, err := link.Reader.ReadMultiBuffer(); err == nil {
+		t.Error("expect link of expired user to be closed")
+	}
+}
+
+func TestQuotaPersist(t *testing.T) {
+	config := &proxyman.QuotaConfig{
+		UsageFile: filepath.Join(t.TempDir(), "usage.json"),
+	}
+	e, _ := newTestEnforcer(t, config)
+	common.Must(e.Start())
+
+	d := &quotaDispatcher{Dispatcher: &pipeDispatcher{}, enforcer: e}
+	user := &protocol.MemoryUser{Email: "test@example.com", Quota: 1000}
+	link, err := d.Dispatch(userContext(user), net.TCPDestination(net.DomainAddress("example.com"), 80))
+	common.Must(err)
+	writeBytes(t, link.Writer, 300)
+	common.Must(e.Close())
+
+	e, _ = newTestEnforcer(t, config)
+	common.Must(e.Start())
+	defer e.Close()
+	if used := e.Usage("test@example.com"); used != 300 {
+		t.Error("expect restored usage 300, but got ", used)
+	}
+}
+
+func TestQuotaRemoveIdleUsers(t *testing.T) {
+	config := &proxyman.QuotaConfig{
+		UsageFile: filepath.Join(t.TempDir(), "usage.json"),
+	}
+	e, _ := newTestEnforcer(t, config)
+	d := &quotaDispatcher{Dispatcher: &pipeDispatcher{}, enforcer: e}
+	dest := net.TCPDestination(net.DomainAddress("example.com"), 80)
+	user := &protocol.MemoryUser{Email: "test@example.com", Quota: 1000}
+	idle := func(email string) {
+		e.Lock()
+		e.users[email].lastActive = time.Now().Add(-quotaIdleTimeout)
+		e.Unlock()
+	}
+
+	link, err := d.Dispatch(userContext(user), dest)
+	common.Must(err)
+	writeBytes(t, link.Writer, 300)
+
+	// Users with connections are kept.
+	idle(user.Email)
+	common.Must(e.save())
+	if len(e.users) != 1 {
+		t.Fatal("expect user with connections to be kept")
+	}
+
+	// Idle users are removed once their usage is saved, and restored when
+	// they connect again.
+	common.Interrupt(link.Writer)
+	idle(user.Email)
+	common.Must(e.save())
+	if len(e.users) != 0 {
+		t.Error("expect idle user to be removed, but got ", len(e.users))
+	}
+	if used := e.Usage(user.Email); used != 300 {
+		t.Error("expect usage 300 of removed user, but got ", used)
+	}
+	link, err = d.Dispatch(userContext(user), dest)
+	common.Must(err)
+	writeBytes(t, link.Writer, 100)
+	if used := e.Usage(user.Email); used != 400 || len(e.saved) != 0 {
+		t.Error("expect usage 400 of restored user, but got ", used)
+	}
+
+	// Without usage file, only idle users without usage are removed.
+	e, _ = newTestEnforcer(t, &proxyman.QuotaConfig{})
+	d = &quotaDispatcher{Dispatcher: &pipeDispatcher{}, enforcer: e}
+	for _, email := range []string{"used@example.com", "unused@example.com"} {
+		link, err := d.Dispatch(userContext(&protocol.MemoryUser{Email: email, Quota: 1000}), dest)
+		common.Must(err)
+		if email == "used@example.com" {
+			writeBytes(t, link.Writer, 100)
+		}
+		common.Interrupt(link.Writer)
+		idle(email)
+	}
+	e.removeIdleUsers(time.Now(), nil)
+	if _, found := e.users["unused@example.com"]; found || len(e.users) != 1 {
+		t.Error("expect only idle user without usage to be removed, but got ", len(e.users))
+	}
+}
@@ -0,0 +1,27 @@
+package proxyman
+
+import "time"
+
+// QuotaEventChannel is the name of the stats channel where QuotaEvents are
+// published.
+const QuotaEventChannel = "quota>>>events"
+
+// Reasons of QuotaEvents.
+const (
+	QuotaExceeded = "quota"
+	QuotaExpired  = "expired"
+)
+
+// QuotaEvent is published when a user exceeds its traffic quota or expires.
+//
+// xray:api:beta
+type QuotaEvent struct {
+	Time  time.Time
+	Email string
+	// Reason is either QuotaExceeded or QuotaExpired.
+	Reason string
+	// Used is the traffic used by the user in bytes.
+	Used   uint64
+	Quota  uint64
+	Expiry time.Time
+}
@@ -28,6 +28,13 @@ func (c routingContext) GetTargetPort() net.Port {
 	return net.Port(c.RoutingContext.GetTargetPort())
 }
 
+// GetUserLevel implements routing.Context. A connection has a user if either
+// the user or a non-zero level is set.
+func (c routingContext) GetUserLevel() (uint32, bool) {
+	level := c.RoutingContext.GetUserLevel()
+	return level, level > 0 || len(c.RoutingContext.GetUser()) > 0
+}
+
 // AsRoutingContext converts a protobuf RoutingContext into an implementation of routing.Context.
 func AsRoutingContext(r *RoutingContext) routing.Context {
 	return routingContext{r}
@@ -48,6 +55,8 @@ var fieldMap = map[string]func(*RoutingContext, routing.Route){
 	"domain":         func(s *RoutingContext, r routing.Route) { s.TargetDomain = r.GetTargetDomain() },
 	"protocol":       func(s *RoutingContext, r routing.Route) { s.Protocol = r.GetProtocol() },
 	"user":           func(s *RoutingContext, r routing.Route) { s.User = r.GetUser() },
+	"user_level":     func(s *RoutingContext, r routing.Route) { s.UserLevel, _ = r.GetUserLevel() },
+	"user_tags":      func(s *RoutingContext, r routing.Route) { s.UserTags = r.GetUserTags() },
 	"attributes":     func(s *RoutingContext, r routing.Route) { s.Attributes = r.GetAttributes() },
 	"outbound_group": func(s *RoutingContext, r routing.Route) { s.OutboundGroupTags = r.GetOutboundGroupTags() },
 	"outbound":       func(s *RoutingContext, r routing.Route) { s.OutboundTag = r.GetOutboundTag() },
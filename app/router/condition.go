@@ -2,11 +2,14 @@ package router
 
 import (
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"go.starlark.net/starlark"
 	"go.starlark.net/syntax"
 
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol/tls"
 	"github.com/xtls/xray-core/common/strmatcher"
 	"github.com/xtls/xray-core/features/routing"
 )
@@ -222,6 +225,56 @@ func (v *UserMatcher) Apply(ctx routing.Context) bool {
 	return false
 }
 
+type UserLevelMatcher struct {
+	ranges []*LevelRange
+}
+
+func NewUserLevelMatcher(ranges []*LevelRange) *UserLevelMatcher {
+	return &UserLevelMatcher{
+		ranges: ranges,
+	}
+}
+
+// Apply implements Condition.
+func (v *UserLevelMatcher) Apply(ctx routing.Context) bool {
+	level, found := ctx.GetUserLevel()
+	if !found {
+		return false
+	}
+	for _, r := range v.ranges {
+		if r.From <= level && level <= r.To {
+			return true
+		}
+	}
+	return false
+}
+
+type UserTagMatcher struct {
+	tags map[string]bool
+}
+
+func NewUserTagMatcher(tags []string) *UserTagMatcher {
+	tagsMap := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if len(tag) > 0 {
+			tagsMap[tag] = true
+		}
+	}
+	return &UserTagMatcher{
+		tags: tagsMap,
+	}
+}
+
+// Apply implements Condition.
+func (v *UserTagMatcher) Apply(ctx routing.Context) bool {
+	for _, tag := range ctx.GetUserTags() {
+		if v.tags[tag] {
+			return true
+		}
+	}
+	return false
+}
+
 type InboundTagMatcher struct {
 	tags []string
 }
@@ -333,3 +386,204 @@ func (m *AttributeMatcher) Apply(ctx routing.Context) bool {
 	}
 	return m.Match(attributes)
 }
+
+// ALPNMatcher matches the application protocols offered in TLS ClientHello.
+type ALPNMatcher struct {
+	protocols map[string]bool
+}
+
+func NewALPNMatcher(protocols []string) *ALPNMatcher {
+	m := &ALPNMatcher{
+		protocols: make(map[string]bool, len(protocols)),
+	}
+	for _, p := range protocols {
+		m.protocols[p] = true
+	}
+	return m
+}
+
+// Apply implements Condition.
+func (m *ALPNMatcher) Apply(ctx routing.Context) bool {
+	alpn := ctx.GetAttributes()[tls.AttributeALPN]
+	if len(alpn) == 0 {
+		return false
+	}
+	for _, p := range strings.Split(alpn, ",") {
+		if m.protocols[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// TLSFingerprintMatcher matches JA3 or JA4 fingerprints of TLS ClientHello.
+type TLSFingerprintMatcher struct {
+	fingerprints map[string]bool
+}
+
+func NewTLSFingerprintMatcher(fingerprints []string) *TLSFingerprintMatcher {
+	m := &TLSFingerprintMatcher{
+		fingerprints: make(map[string]bool, len(fingerprints)),
+	}
+	for _, f := range fingerprints {
+		m.fingerprints[strings.ToLower(f)] = true
+	}
+	return m
+}
+
+// Apply implements Condition.
+func (m *TLSFingerprintMatcher) Apply(ctx routing.Context) bool {
+	attributes := ctx.GetAttributes()
+	for _, key := range []string{tls.AttributeJA3, tls.AttributeJA4} {
+		if f := attributes[key]; len(f) > 0 && m.fingerprints[f] {
+			return true
+		}
+	}
+	return false
+}
+
+type timeWindow struct {
+	weekdays  uint32
+	start     int
+	end       int
+	location  *time.Location
+	notBefore int64
+	notAfter  int64
+}
+
+// TimeMatcher matches the current time against a list of time windows.
+type TimeMatcher struct {
+	windows []timeWindow
+}
+
+func NewTimeMatcher(windows []*TimeWindow) (*TimeMatcher, error) {
+	m := &TimeMatcher{
+		windows: make([]timeWindow, 0, len(windows)),
+	}
+	for _, w := range windows {
+		if w.StartMinute >= 24*60 || w.EndMinute > 24*60 {
+			return nil, newError("invalid time window: ", w.StartMinute, "-", w.EndMinute)
+		}
+		location := time.Local
+		if len(w.Timezone) > 0 {
+			loc, err := time.LoadLocation(w.Timezone)
+			if err != nil {
+				return nil, newError("invalid time zone: ", w.Timezone).Base(err)
+			}
+			location = loc
+		}
+		m.windows = append(m.windows, timeWindow{
+			weekdays:  w.Weekdays,
+			start:     int(w.StartMinute),
+			end:       int(w.EndMinute),
+			location:  location,
+			notBefore: w.NotBefore,
+			notAfter:  w.NotAfter,
+		})
+	}
+	return m, nil
+}
+
+func (w *timeWindow) hasWeekday(day time.Weekday) bool {
+	return w.weekdays == 0 || w.weekdays&(1<<uint(day)) != 0
+}
+
+func (w *timeWindow) match(t time.Time) bool {
+	if w.notBefore != 0 && t.Unix() < w.notBefore {
+		return false
+	}
+	if w.notAfter != 0 && t.Unix() > w.notAfter {
+		return false
+	}
+
+	t = t.In(w.location)
+	minute := t.Hour()*60 + t.Minute()
+	switch {
+	case w.start == 0 && w.end == 0:
+		return w.hasWeekday(t.Weekday())
+	case w.start < w.end:
+		return minute >= w.start && minute < w.end && w.hasWeekday(t.Weekday())
+	case minute >= w.start:
+		return w.hasWeekday(t.Weekday())
+	case minute < w.end:
+		// The window started on the previous day.
+		return w.hasWeekday((t.Weekday() + 6) % 7)
+	default:
+		return false
+	}
+}
+
+// ApplyTime returns true if the given time is in any of the time windows.
+func (m *TimeMatcher) ApplyTime(t time.Time) bool {
+	for i := range m.windows {
+		if m.windows[i].match(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply implements Condition.
+func (m *TimeMatcher) Apply(ctx routing.Context) bool {
+	return m.ApplyTime(time.Now())
+}
+
+// ReloadableCondition is a Condition that can be rebuilt at runtime, for
+// example when the external sources it depends on have changed.
+type ReloadableCondition struct {
+	sources []*RuleSource
+	build   func(loader *SourceLoader) (Condition, error)
+	cond    atomic.Value
+}
+
+type conditionHolder struct {
+	Condition
+}
+
+// NewReloadableCondition creates a ReloadableCondition and builds its initial
+// condition with the loader.
+func NewReloadableCondition(sources []*RuleSource, loader *SourceLoader, build func(loader *SourceLoader) (Condition, error)) (*ReloadableCondition, error) {
+	c := &ReloadableCondition{
+		sources: sources,
+		build:   build,
+	}
+	if err := c.Reload(loader); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload rebuilds the underlying condition with sources loaded by the loader.
+// The current condition is kept if rebuilding fails.
+func (c *ReloadableCondition) Reload(loader *SourceLoader) error {
+	cond, err := c.build(loader)
+	if err != nil {
+		return err
+	}
+	c.cond.Store(conditionHolder{cond})
+	return nil
+}
+
+// DependsOn returns true if the condition loads any of the given files.
+func (c *ReloadableCondition) DependsOn(files map[string]bool) bool {
+	for _, source := range c.sources {
+		if files[source.File] {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the sources of the condition in config form.
+func (c *ReloadableCondition) String() string {
+	names := make([]string, 0, len(c.sources))
+	for _, source := range c.sources {
+		names = append(names, source.ConfigString())
+	}
+	return strings.Join(names, ", ")
+}
+
+// Apply implements Condition.
+func (c *ReloadableCondition) Apply(ctx routing.Context) bool {
+	return c.cond.Load().(conditionHolder).Apply(ctx)
+}
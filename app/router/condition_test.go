@@ -1,13 +1,16 @@
 package router_test
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 
+	"github.com/xtls/xray-core/app/proxyman/command"
 	. "github.com/xtls/xray-core/app/router"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
@@ -16,9 +19,14 @@ import (
 	"github.com/xtls/xray-core/common/platform/filesystem"
 	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/common/protocol/http"
+	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/uuid"
 	"github.com/xtls/xray-core/features/routing"
 	routing_session "github.com/xtls/xray-core/features/routing/session"
+	"github.com/xtls/xray-core/proxy"
+	"github.com/xtls/xray-core/proxy/vless"
+	"github.com/xtls/xray-core/transport/internet"
 )
 
 func init() {
@@ -224,6 +232,55 @@ func TestRoutingRule(t *testing.T) {
 				},
 			},
 		},
+		{
+			rule: &RoutingRule{
+				UserLevel: []*LevelRange{
+					{From: 0, To: 0},
+					{From: 5, To: 9},
+				},
+			},
+			test: []ruleTest{
+				{
+					input:  withInbound(&session.Inbound{User: &protocol.MemoryUser{Level: 0}}),
+					output: true,
+				},
+				{
+					input:  withInbound(&session.Inbound{User: &protocol.MemoryUser{Level: 7}}),
+					output: true,
+				},
+				{
+					input:  withInbound(&session.Inbound{User: &protocol.MemoryUser{Level: 3}}),
+					output: false,
+				},
+				{
+					input:  withInbound(&session.Inbound{Tag: "socks"}),
+					output: false,
+				},
+				{
+					input:  withBackground(),
+					output: false,
+				},
+			},
+		},
+		{
+			rule: &RoutingRule{
+				UserTag: []string{"premium"},
+			},
+			test: []ruleTest{
+				{
+					input:  withInbound(&session.Inbound{User: &protocol.MemoryUser{Tags: []string{"team-a", "premium"}}}),
+					output: true,
+				},
+				{
+					input:  withInbound(&session.Inbound{User: &protocol.MemoryUser{Tags: []string{"basic"}}}),
+					output: false,
+				},
+				{
+					input:  withBackground(),
+					output: false,
+				},
+			},
+		},
 		{
 			rule: &RoutingRule{
 				Protocol: []string{"http"},
@@ -318,6 +375,44 @@ func TestRoutingRule(t *testing.T) {
 				},
 			},
 		},
+		{
+			rule: &RoutingRule{
+				Alpn: []string{"h2"},
+			},
+			test: []ruleTest{
+				{
+					input:  withContent(&session.Content{Protocol: "tls", Attributes: map[string]string{"tls.alpn": "h2,http/1.1"}}),
+					output: true,
+				},
+				{
+					input:  withContent(&session.Content{Protocol: "tls", Attributes: map[string]string{"tls.alpn": "http/1.1"}}),
+					output: false,
+				},
+				{
+					input:  withContent(&session.Content{Protocol: "tls"}),
+					output: false,
+				},
+			},
+		},
+		{
+			rule: &RoutingRule{
+				TlsFingerprint: []string{"B8F81673C0E1D29908346F3BAB892B9B", "t13d1516h2_8daaf6152771_e5627efa2ab1"},
+			},
+			test: []ruleTest{
+				{
+					input:  withContent(&session.Content{Protocol: "tls", Attributes: map[string]string{"tls.ja3": "b8f81673c0e1d29908346f3bab892b9b"}}),
+					output: true,
+				},
+				{
+					input:  withContent(&session.Content{Protocol: "tls", Attributes: map[string]string{"tls.ja3": "0", "tls.ja4": "t13d1516h2_8daaf6152771_e5627efa2ab1"}}),
+					output: true,
+				},
+				{
+					input:  withContent(&session.Content{Protocol: "tls", Attributes: map[string]string{"tls.ja3": "0", "tls.ja4": "t12d1510h2_f0daf39aad75_e69ac49eb88f"}}),
+					output: false,
+				},
+			},
+		},
 	}
 
 	for _, test := range cases {
@@ -333,6 +428,67 @@ func TestRoutingRule(t *testing.T) {
 	}
 }
 
+// userInbound is an inbound handler whose proxy keeps the users added to it.
+type userInbound struct {
+	users []*protocol.MemoryUser
+}
+
+func (*userInbound) Start() error {
+	return nil
+}
+
+func (*userInbound) Close() error {
+	return nil
+}
+
+func (*userInbound) Tag() string {
+	return "in"
+}
+
+func (*userInbound) GetRandomInboundProxy() (interface{}, net.Port, int) {
+	return nil, 0, 0
+}
+
+func (h *userInbound) GetInbound() proxy.Inbound {
+	return h
+}
+
+func (*userInbound) Network() []net.Network {
+	return []net.Network{net.Network_TCP}
+}
+
+func (*userInbound) Process(context.Context, net.Network, internet.Connection, routing.Dispatcher) error {
+	return nil
+}
+
+func (h *userInbound) AddUser(ctx context.Context, user *protocol.MemoryUser) error {
+	h.users = append(h.users, user)
+	return nil
+}
+
+func (*userInbound) RemoveUser(context.Context, string) error {
+	return nil
+}
+
+func TestUserLevelMatcherAddedUser(t *testing.T) {
+	handler := &userInbound{}
+	id := uuid.New()
+	op := &command.AddUserOperation{User: &protocol.User{
+		Email:   "new@example.com",
+		Account: serial.ToTypedMessage(&vless.Account{Id: id.String()}),
+	}}
+	common.Must(op.ApplyInbound(context.Background(), handler))
+	if len(handler.users) != 1 {
+		t.Fatal("expect 1 added user, but got ", len(handler.users))
+	}
+
+	cond, err := (&RoutingRule{UserLevel: []*LevelRange{{From: 0, To: 1}}}).BuildCondition()
+	common.Must(err)
+	if !cond.Apply(withInbound(&session.Inbound{Tag: "in", User: handler.users[0]})) {
+		t.Error("expect level 0 of the added user to match")
+	}
+}
+
 func loadGeoSite(country string) ([]*Domain, error) {
 	geositeBytes, err := filesystem.ReadAsset("geosite.dat")
 	if err != nil {
@@ -400,6 +556,61 @@ func TestChinaSites(t *testing.T) {
 	}
 }
 
+func TestTimeMatcher(t *testing.T) {
+	matcher, err := NewTimeMatcher([]*TimeWindow{
+		{
+			// Monday to Friday, 09:00-18:00
+			Weekdays:    0x3e,
+			StartMinute: 9 * 60,
+			EndMinute:   18 * 60,
+			Timezone:    "Asia/Shanghai",
+		},
+		{
+			// Saturday night to Sunday morning, 22:00-06:00
+			Weekdays:    0x40,
+			StartMinute: 22 * 60,
+			EndMinute:   6 * 60,
+			Timezone:    "UTC",
+		},
+		{
+			// Whole day of 2021-01-01 UTC
+			NotBefore: 1609459200,
+			NotAfter:  1609545599,
+		},
+	})
+	common.Must(err)
+
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	common.Must(err)
+
+	cases := []struct {
+		input  time.Time
+		output bool
+	}{
+		{time.Date(2021, 6, 7, 9, 0, 0, 0, shanghai), true},    // Monday
+		{time.Date(2021, 6, 7, 17, 59, 0, 0, shanghai), true},  // Monday
+		{time.Date(2021, 6, 7, 18, 0, 0, 0, shanghai), false},  // Monday
+		{time.Date(2021, 6, 7, 8, 59, 0, 0, shanghai), false},  // Monday
+		{time.Date(2021, 6, 7, 2, 0, 0, 0, time.UTC), true},    // Monday 10:00 in Shanghai
+		{time.Date(2021, 6, 12, 10, 0, 0, 0, shanghai), false}, // Saturday
+		{time.Date(2021, 6, 12, 23, 0, 0, 0, time.UTC), true},  // Saturday
+		{time.Date(2021, 6, 13, 5, 0, 0, 0, time.UTC), true},   // Sunday, window started on Saturday
+		{time.Date(2021, 6, 13, 23, 0, 0, 0, time.UTC), false}, // Sunday
+		{time.Date(2021, 6, 14, 0, 30, 0, 0, time.UTC), false}, // Monday, window started on Sunday
+		{time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC), true},   // Friday, in date range
+		{time.Date(2021, 1, 2, 12, 0, 0, 0, time.UTC), false},  // Saturday, out of date range
+	}
+	for _, c := range cases {
+		if v := matcher.ApplyTime(c.input); v != c.output {
+			t.Error("for ", c.input, " expect ", c.output, " but got ", v)
+		}
+	}
+
+	if _, err := NewTimeMatcher([]*TimeWindow{{Timezone: "Invalid/Zone"}}); err == nil {
+		t.Error("expect error for invalid time zone")
+	}
+}
+
 func BenchmarkMphDomainMatcher(b *testing.B) {
 	domains, err := loadGeoSite("CN")
 	common.Must(err)
@@ -64,33 +64,52 @@ func (r *Rule) Apply(ctx routing.Context) bool {
 }
 
 func (rr *RoutingRule) BuildCondition() (Condition, error) {
+	loader := NewSourceLoader()
+	defer loader.Release()
+	return rr.buildCondition(loader)
+}
+
+// buildCondition builds the condition of the rule with external sources
+// loaded by the loader.
+func (rr *RoutingRule) buildCondition(loader *SourceLoader) (Condition, error) {
 	conds := NewConditionChan()
 
-	if len(rr.Domain) > 0 {
-		switch rr.DomainMatcher {
-		case "linear":
-			matcher, err := NewDomainMatcher(rr.Domain)
-			if err != nil {
-				return nil, newError("failed to build domain condition").Base(err)
-			}
-			conds.Add(matcher)
-		case "mph", "hybrid":
-			fallthrough
-		default:
-			matcher, err := NewMphMatcherGroup(rr.Domain)
-			if err != nil {
-				return nil, newError("failed to build domain condition with MphDomainMatcher").Base(err)
+	if len(rr.DomainSource) > 0 {
+		cond, err := NewReloadableCondition(rr.DomainSource, loader, func(loader *SourceLoader) (Condition, error) {
+			domains := append([]*Domain(nil), rr.Domain...)
+			for _, source := range rr.DomainSource {
+				loaded, err := loader.LoadDomains(source)
+				if err != nil {
+					return nil, newError("failed to load domains from ", source.ConfigString()).Base(err)
+				}
+				domains = append(domains, loaded...)
 			}
-			newError("MphDomainMatcher is enabled for ", len(rr.Domain), " domain rule(s)").AtDebug().WriteToLog()
-			conds.Add(matcher)
+			return rr.buildDomainCondition(domains)
+		})
+		if err != nil {
+			return nil, err
 		}
-
+		conds.Add(cond)
+	} else if len(rr.Domain) > 0 {
+		cond, err := rr.buildDomainCondition(rr.Domain)
+		if err != nil {
+			return nil, err
+		}
+		conds.Add(cond)
 	}
 
 	if len(rr.UserEmail) > 0 {
 		conds.Add(NewUserMatcher(rr.UserEmail))
 	}
 
+	if len(rr.UserLevel) > 0 {
+		conds.Add(NewUserLevelMatcher(rr.UserLevel))
+	}
+
+	if len(rr.UserTag) > 0 {
+		conds.Add(NewUserTagMatcher(rr.UserTag))
+	}
+
 	if len(rr.InboundTag) > 0 {
 		conds.Add(NewInboundTagMatcher(rr.InboundTag))
 	}
@@ -111,7 +130,28 @@ func (rr *RoutingRule) BuildCondition() (Condition, error) {
 		conds.Add(NewNetworkMatcher(rr.NetworkList.Network))
 	}
 
-	if len(rr.Geoip) > 0 {
+	if len(rr.GeoipSource) > 0 {
+		cond, err := NewReloadableCondition(rr.GeoipSource, loader, func(loader *SourceLoader) (Condition, error) {
+			geoips := append([]*GeoIP(nil), rr.Geoip...)
+			if len(geoips) == 0 && len(rr.Cidr) > 0 {
+				geoips = append(geoips, &GeoIP{Cidr: rr.Cidr})
+			}
+			for _, source := range rr.GeoipSource {
+				cidrs, err := loader.LoadCIDRs(source)
+				if err != nil {
+					return nil, newError("failed to load IPs from ", source.ConfigString()).Base(err)
+				}
+				// Leave country code empty so that the matcher is not shared
+				// with other rules, and a reload always takes effect.
+				geoips = append(geoips, &GeoIP{Cidr: cidrs})
+			}
+			return NewMultiGeoIPMatcher(geoips, false)
+		})
+		if err != nil {
+			return nil, err
+		}
+		conds.Add(cond)
+	} else if len(rr.Geoip) > 0 {
 		cond, err := NewMultiGeoIPMatcher(rr.Geoip, false)
 		if err != nil {
 			return nil, err
@@ -151,6 +191,22 @@ func (rr *RoutingRule) BuildCondition() (Condition, error) {
 		conds.Add(cond)
 	}
 
+	if len(rr.Alpn) > 0 {
+		conds.Add(NewALPNMatcher(rr.Alpn))
+	}
+
+	if len(rr.TlsFingerprint) > 0 {
+		conds.Add(NewTLSFingerprintMatcher(rr.TlsFingerprint))
+	}
+
+	if len(rr.TimeWindow) > 0 {
+		cond, err := NewTimeMatcher(rr.TimeWindow)
+		if err != nil {
+			return nil, err
+		}
+		conds.Add(cond)
+	}
+
 	if conds.Len() == 0 {
 		return nil, newError("this rule has no effective fields").AtWarning()
 	}
@@ -158,6 +214,26 @@ func (rr *RoutingRule) BuildCondition() (Condition, error) {
 	return conds, nil
 }
 
+func (rr *RoutingRule) buildDomainCondition(domains []*Domain) (Condition, error) {
+	switch rr.DomainMatcher {
+	case "linear":
+		matcher, err := NewDomainMatcher(domains)
+		if err != nil {
+			return nil, newError("failed to build domain condition").Base(err)
+		}
+		return matcher, nil
+	case "mph", "hybrid":
+		fallthrough
+	default:
+		matcher, err := NewMphMatcherGroup(domains)
+		if err != nil {
+			return nil, newError("failed to build domain condition with MphDomainMatcher").Base(err)
+		}
+		newError("MphDomainMatcher is enabled for ", len(domains), " domain rule(s)").AtDebug().WriteToLog()
+		return matcher, nil
+	}
+}
+
 func (br *BalancingRule) Build(ohm outbound.Manager) (*Balancer, error) {
 	return &Balancer{
 		selectors: br.OutboundSelector,
@@ -4,6 +4,7 @@ package router
 
 import (
 	"context"
+	"time"
 
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/core"
@@ -19,6 +20,8 @@ type Router struct {
 	rules          []*Rule
 	balancers      map[string]*Balancer
 	dns            dns.Client
+	reloadables    []*ReloadableCondition
+	watcher        *SourceWatcher
 }
 
 // Route is an implementation of routing.Route.
@@ -43,11 +46,22 @@ func (r *Router) Init(config *Config, d dns.Client, ohm outbound.Manager) error
 	}
 
 	r.rules = make([]*Rule, 0, len(config.Rule))
+	var sources [][]*RuleSource
+	loader := NewSourceLoader()
+	defer loader.Release()
 	for _, rule := range config.Rule {
-		cond, err := rule.BuildCondition()
+		cond, err := rule.buildCondition(loader)
 		if err != nil {
 			return err
 		}
+		if chain, ok := cond.(*ConditionChan); ok {
+			for _, c := range *chain {
+				if rc, ok := c.(*ReloadableCondition); ok {
+					r.reloadables = append(r.reloadables, rc)
+				}
+			}
+		}
+		sources = append(sources, rule.DomainSource, rule.GeoipSource)
 		rr := &Rule{
 			Condition: cond,
 			Tag:       rule.GetTag(),
@@ -63,9 +77,32 @@ func (r *Router) Init(config *Config, d dns.Client, ohm outbound.Manager) error
 		r.rules = append(r.rules, rr)
 	}
 
+	if config.ReloadInterval > 0 && len(r.reloadables) > 0 {
+		r.watcher = NewSourceWatcher(time.Duration(config.ReloadInterval)*time.Second, SourceFiles(sources...), r.reload)
+	}
+
 	return nil
 }
 
+func (r *Router) reload(changed map[string]bool) {
+	loader := NewSourceLoader()
+	defer loader.Release()
+	failed := false
+	for _, cond := range r.reloadables {
+		if !cond.DependsOn(changed) {
+			continue
+		}
+		if err := cond.Reload(loader); err != nil {
+			newError("failed to reload routing rule from ", cond, ", keeping the previous one").Base(err).AtWarning().WriteToLog()
+			failed = true
+		}
+	}
+	if failed {
+		return
+	}
+	newError("reloaded routing rules from ", len(changed), " changed file(s)").AtInfo().WriteToLog()
+}
+
 // PickRoute implements routing.Router.
 func (r *Router) PickRoute(ctx routing.Context) (routing.Route, error) {
 	rule, ctx, err := r.pickRouteInternal(ctx)
@@ -107,12 +144,18 @@ func (r *Router) pickRouteInternal(ctx routing.Context) (*Rule, routing.Context,
 }
 
 // Start implements common.Runnable.
-func (*Router) Start() error {
+func (r *Router) Start() error {
+	if r.watcher != nil {
+		return r.watcher.Start()
+	}
 	return nil
 }
 
 // Close implements common.Closable.
-func (*Router) Close() error {
+func (r *Router) Close() error {
+	if r.watcher != nil {
+		return r.watcher.Close()
+	}
 	return nil
 }
 
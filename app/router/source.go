@@ -0,0 +1,342 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/platform"
+	"github.com/xtls/xray-core/common/platform/filesystem"
+	"github.com/xtls/xray-core/common/task"
+)
+
+// ConfigString returns the source in the form used by config files.
+func (s *RuleSource) ConfigString() string {
+	if len(s.Code) == 0 {
+		return "ext:" + s.File
+	}
+	code := s.Code
+	for _, attr := range s.Attribute {
+		code += "@" + attr
+	}
+	return "ext:" + s.File + ":" + code
+}
+
+func (s *RuleSource) readLines() ([]string, error) {
+	bs, err := filesystem.ReadFile(SourceLocation(s.File))
+	if err != nil {
+		return nil, newError("failed to read rule source: ", s.File).Base(err)
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(bs))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, newError("failed to read rule source: ", s.File).Base(err)
+	}
+	return lines, nil
+}
+
+func (s *RuleSource) matchAttributes(domain *Domain) bool {
+	for _, attr := range s.Attribute {
+		found := false
+		for _, a := range domain.Attribute {
+			if strings.EqualFold(a.Key, attr) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadDomains loads the domains provided by this source.
+func (s *RuleSource) LoadDomains() ([]*Domain, error) {
+	loader := NewSourceLoader()
+	defer loader.Release()
+	return loader.LoadDomains(s)
+}
+
+// LoadCIDRs loads the IP ranges provided by this source.
+func (s *RuleSource) LoadCIDRs() ([]*CIDR, error) {
+	loader := NewSourceLoader()
+	defer loader.Release()
+	return loader.LoadCIDRs(s)
+}
+
+// SourceLoader loads rule sources, parsing each geosite or geoip file only
+// once. A SourceLoader is used for one build or reload of rules, so that
+// changed files are parsed again by the next one.
+type SourceLoader struct {
+	geosites map[string]*GeoSiteList
+	geoips   map[string]*GeoIPList
+}
+
+// NewSourceLoader creates a SourceLoader.
+func NewSourceLoader() *SourceLoader {
+	return &SourceLoader{
+		geosites: make(map[string]*GeoSiteList),
+		geoips:   make(map[string]*GeoIPList),
+	}
+}
+
+// Release drops the parsed files, and returns their memory to the OS.
+func (l *SourceLoader) Release() {
+	if len(l.geosites) == 0 && len(l.geoips) == 0 {
+		return
+	}
+	l.geosites = make(map[string]*GeoSiteList)
+	l.geoips = make(map[string]*GeoIPList)
+	runtime.GC()
+}
+
+func (l *SourceLoader) geoSiteList(file string) (*GeoSiteList, error) {
+	if list, found := l.geosites[file]; found {
+		return list, nil
+	}
+	bs, err := filesystem.ReadFile(SourceLocation(file))
+	if err != nil {
+		return nil, newError("failed to read rule source: ", file).Base(err)
+	}
+	list := new(GeoSiteList)
+	if err := proto.Unmarshal(bs, list); err != nil {
+		return nil, newError("failed to parse geosite file: ", file).Base(err)
+	}
+	l.geosites[file] = list
+	return list, nil
+}
+
+func (l *SourceLoader) geoIPList(file string) (*GeoIPList, error) {
+	if list, found := l.geoips[file]; found {
+		return list, nil
+	}
+	bs, err := filesystem.ReadFile(SourceLocation(file))
+	if err != nil {
+		return nil, newError("failed to read rule source: ", file).Base(err)
+	}
+	list := new(GeoIPList)
+	if err := proto.Unmarshal(bs, list); err != nil {
+		return nil, newError("failed to parse geoip file: ", file).Base(err)
+	}
+	l.geoips[file] = list
+	return list, nil
+}
+
+// LoadDomains loads the domains provided by the source.
+func (l *SourceLoader) LoadDomains(s *RuleSource) ([]*Domain, error) {
+	if len(s.Code) == 0 {
+		lines, err := s.readLines()
+		if err != nil {
+			return nil, err
+		}
+		domains := make([]*Domain, 0, len(lines))
+		for _, line := range lines {
+			domain, err := parsePlainDomain(line)
+			if err != nil {
+				return nil, newError("invalid domain in ", s.File, ": ", line).Base(err)
+			}
+			domains = append(domains, domain)
+		}
+		return domains, nil
+	}
+
+	list, err := l.geoSiteList(s.File)
+	if err != nil {
+		return nil, err
+	}
+	for _, site := range list.Entry {
+		if !strings.EqualFold(site.CountryCode, s.Code) {
+			continue
+		}
+		if len(s.Attribute) == 0 {
+			return site.Domain, nil
+		}
+		domains := make([]*Domain, 0, len(site.Domain))
+		for _, domain := range site.Domain {
+			if s.matchAttributes(domain) {
+				domains = append(domains, domain)
+			}
+		}
+		return domains, nil
+	}
+	return nil, newError("list not found in ", s.File, ": ", s.Code)
+}
+
+// LoadCIDRs loads the IP ranges provided by the source.
+func (l *SourceLoader) LoadCIDRs(s *RuleSource) ([]*CIDR, error) {
+	if len(s.Code) == 0 {
+		lines, err := s.readLines()
+		if err != nil {
+			return nil, err
+		}
+		cidrs := make([]*CIDR, 0, len(lines))
+		for _, line := range lines {
+			cidr, err := parsePlainCIDR(line)
+			if err != nil {
+				return nil, newError("invalid IP in ", s.File, ": ", line).Base(err)
+			}
+			cidrs = append(cidrs, cidr)
+		}
+		return cidrs, nil
+	}
+
+	list, err := l.geoIPList(s.File)
+	if err != nil {
+		return nil, err
+	}
+	for _, geoip := range list.Entry {
+		if strings.EqualFold(geoip.CountryCode, s.Code) {
+			return geoip.Cidr, nil
+		}
+	}
+	return nil, newError("code not found in ", s.File, ": ", s.Code)
+}
+
+// parsePlainDomain parses a line of a plain domain list. The syntax is the
+// same as domain entries in routing rules.
+func parsePlainDomain(line string) (*Domain, error) {
+	switch {
+	case strings.HasPrefix(line, "regexp:"):
+		return &Domain{Type: Domain_Regex, Value: line[7:]}, nil
+	case strings.HasPrefix(line, "domain:"):
+		return &Domain{Type: Domain_Domain, Value: line[7:]}, nil
+	case strings.HasPrefix(line, "full:"):
+		return &Domain{Type: Domain_Full, Value: line[5:]}, nil
+	case strings.HasPrefix(line, "keyword:"):
+		return &Domain{Type: Domain_Plain, Value: line[8:]}, nil
+	case strings.HasPrefix(line, "dotless:"):
+		switch substr := line[8:]; {
+		case substr == "":
+			return &Domain{Type: Domain_Regex, Value: "^[^.]*$"}, nil
+		case !strings.Contains(substr, "."):
+			return &Domain{Type: Domain_Regex, Value: "^[^.]*" + substr + "[^.]*$"}, nil
+		default:
+			return nil, newError("substr in dotless rule should not contain a dot: ", substr)
+		}
+	default:
+		return &Domain{Type: Domain_Plain, Value: line}, nil
+	}
+}
+
+// parsePlainCIDR parses a line of a plain IP list, either a single IP or a CIDR.
+func parsePlainCIDR(line string) (*CIDR, error) {
+	if !strings.Contains(line, "/") {
+		ip := net.ParseIP(line)
+		if ip == nil {
+			return nil, newError("invalid IP address")
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &CIDR{Ip: ip4, Prefix: 32}, nil
+		}
+		return &CIDR{Ip: ip, Prefix: 128}, nil
+	}
+	ip, ipNet, err := net.ParseCIDR(line)
+	if err != nil {
+		return nil, err
+	}
+	ones, _ := ipNet.Mask.Size()
+	if ip4 := ip.To4(); ip4 != nil {
+		return &CIDR{Ip: ip4, Prefix: uint32(ones)}, nil
+	}
+	return &CIDR{Ip: ip, Prefix: uint32(ones)}, nil
+}
+
+// SourceWatcher periodically checks the modification time of rule source
+// files, and invokes a callback with the sources whose files changed.
+type SourceWatcher struct {
+	sync.Mutex
+	modTime  map[string]time.Time
+	onChange func(changed map[string]bool)
+	task     *task.Periodic
+}
+
+// NewSourceWatcher creates a SourceWatcher for the given files.
+func NewSourceWatcher(interval time.Duration, files []string, onChange func(changed map[string]bool)) *SourceWatcher {
+	w := &SourceWatcher{
+		modTime:  make(map[string]time.Time, len(files)),
+		onChange: onChange,
+	}
+	for _, file := range files {
+		w.modTime[file] = statModTime(file)
+	}
+	w.task = &task.Periodic{
+		Interval: interval,
+		Execute:  w.check,
+	}
+	return w
+}
+
+// SourceLocation returns the path of an external source file. Relative paths
+// are looked up in the asset directories.
+func SourceLocation(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return platform.GetAssetLocation(file)
+}
+
+func statModTime(file string) time.Time {
+	info, err := os.Stat(SourceLocation(file))
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func (w *SourceWatcher) check() error {
+	w.Lock()
+	changed := make(map[string]bool)
+	for file, last := range w.modTime {
+		if t := statModTime(file); !t.IsZero() && !t.Equal(last) {
+			w.modTime[file] = t
+			changed[file] = true
+		}
+	}
+	w.Unlock()
+
+	if len(changed) > 0 {
+		w.onChange(changed)
+	}
+	return nil
+}
+
+// Start implements common.Runnable.
+func (w *SourceWatcher) Start() error {
+	return w.task.Start()
+}
+
+// Close implements common.Closable.
+func (w *SourceWatcher) Close() error {
+	return w.task.Close()
+}
+
+// SourceFiles returns the distinct files of the given sources.
+func SourceFiles(sources ...[]*RuleSource) []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, list := range sources {
+		for _, source := range list {
+			if !seen[source.File] {
+				seen[source.File] = true
+				files = append(files, source.File)
+			}
+		}
+	}
+	return files
+}
@@ -0,0 +1,166 @@
+package router_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	. "github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/platform"
+	"github.com/xtls/xray-core/common/session"
+)
+
+func TestRuleSourceReload(t *testing.T) {
+	domainFile := platform.GetAssetLocation("reload_test_domains.txt")
+	ipFile := platform.GetAssetLocation("reload_test_ips.txt")
+	defer os.Remove(domainFile)
+	defer os.Remove(ipFile)
+
+	common.Must(ioutil.WriteFile(domainFile, []byte("# comment\ndomain:example.com\nfull:test.org\n"), 0644))
+	common.Must(ioutil.WriteFile(ipFile, []byte("10.0.0.0/8\n192.168.1.1\n"), 0644))
+
+	rule := &RoutingRule{
+		Domain: []*Domain{
+			{Type: Domain_Full, Value: "static.com"},
+		},
+		DomainSource: []*RuleSource{
+			{File: "reload_test_domains.txt"},
+		},
+		GeoipSource: []*RuleSource{
+			{File: "reload_test_ips.txt"},
+		},
+	}
+	cond, err := rule.BuildCondition()
+	common.Must(err)
+
+	reloadables := []*ReloadableCondition{}
+	for _, c := range *cond.(*ConditionChan) {
+		if rc, ok := c.(*ReloadableCondition); ok {
+			reloadables = append(reloadables, rc)
+		}
+	}
+	if len(reloadables) != 2 {
+		t.Fatal("expect 2 reloadable conditions, but got ", len(reloadables))
+	}
+
+	domainCond, ipCond := reloadables[0], reloadables[1]
+	domainCtx := func(domain string) *session.Outbound {
+		return &session.Outbound{Target: net.TCPDestination(net.DomainAddress(domain), 80)}
+	}
+	ipCtx := func(ip string) *session.Outbound {
+		return &session.Outbound{Target: net.TCPDestination(net.ParseAddress(ip), 80)}
+	}
+
+	cases := []struct {
+		cond   Condition
+		input  *session.Outbound
+		output bool
+	}{
+		{domainCond, domainCtx("www.example.com"), true},
+		{domainCond, domainCtx("test.org"), true},
+		{domainCond, domainCtx("static.com"), true},
+		{domainCond, domainCtx("new.net"), false},
+		{ipCond, ipCtx("10.1.2.3"), true},
+		{ipCond, ipCtx("192.168.1.1"), true},
+		{ipCond, ipCtx("172.16.0.1"), false},
+	}
+	for _, c := range cases {
+		if v := c.cond.Apply(withOutbound(c.input)); v != c.output {
+			t.Error("for ", c.input.Target, " expect ", c.output, " but got ", v)
+		}
+	}
+
+	common.Must(ioutil.WriteFile(domainFile, []byte("domain:new.net\n"), 0644))
+	common.Must(ioutil.WriteFile(ipFile, []byte("172.16.0.0/12\n"), 0644))
+	if !domainCond.DependsOn(map[string]bool{"reload_test_domains.txt": true}) {
+		t.Error("expect domain condition to depend on its source")
+	}
+	if s := domainCond.String(); s != "ext:reload_test_domains.txt" {
+		t.Error("unexpected sources of domain condition: ", s)
+	}
+	loader := NewSourceLoader()
+	common.Must(domainCond.Reload(loader))
+	common.Must(ipCond.Reload(loader))
+
+	cases = []struct {
+		cond   Condition
+		input  *session.Outbound
+		output bool
+	}{
+		{domainCond, domainCtx("www.example.com"), false},
+		{domainCond, domainCtx("static.com"), true},
+		{domainCond, domainCtx("new.net"), true},
+		{ipCond, ipCtx("10.1.2.3"), false},
+		{ipCond, ipCtx("172.16.0.1"), true},
+	}
+	for _, c := range cases {
+		if v := c.cond.Apply(withOutbound(c.input)); v != c.output {
+			t.Error("after reload, for ", c.input.Target, " expect ", c.output, " but got ", v)
+		}
+	}
+
+	common.Must(ioutil.WriteFile(domainFile, []byte("regexp:(\n"), 0644))
+	if err := domainCond.Reload(NewSourceLoader()); err == nil {
+		t.Error("expect error on invalid domain list")
+	}
+	if !domainCond.Apply(withOutbound(domainCtx("new.net"))) {
+		t.Error("expect previous condition to be kept after a failed reload")
+	}
+}
+
+func TestPlainDomainSource(t *testing.T) {
+	file := platform.GetAssetLocation("dotless_test_domains.txt")
+	defer os.Remove(file)
+
+	common.Must(ioutil.WriteFile(file, []byte("dotless:\ndotless:local\n"), 0644))
+	domains, err := (&RuleSource{File: "dotless_test_domains.txt"}).LoadDomains()
+	common.Must(err)
+	if len(domains) != 2 || domains[0].Type != Domain_Regex || domains[0].Value != "^[^.]*$" ||
+		domains[1].Type != Domain_Regex || domains[1].Value != "^[^.]*local[^.]*$" {
+		t.Error("unexpected domains: ", domains)
+	}
+
+	common.Must(ioutil.WriteFile(file, []byte("dotless:example.com\n"), 0644))
+	if _, err := (&RuleSource{File: "dotless_test_domains.txt"}).LoadDomains(); err == nil {
+		t.Error("expect error on dotless rule with a dot")
+	}
+}
+
+func TestSourceLoader(t *testing.T) {
+	file := platform.GetAssetLocation("loader_test_geosite.dat")
+	defer os.Remove(file)
+
+	writeSites := func(domain string) {
+		bs, err := proto.Marshal(&GeoSiteList{Entry: []*GeoSite{
+			{CountryCode: "A", Domain: []*Domain{{Type: Domain_Full, Value: "a." + domain}}},
+			{CountryCode: "B", Domain: []*Domain{{Type: Domain_Full, Value: "b." + domain}}},
+		}})
+		common.Must(err)
+		common.Must(ioutil.WriteFile(file, bs, 0644))
+	}
+	load := func(loader *SourceLoader, code string) string {
+		domains, err := loader.LoadDomains(&RuleSource{File: "loader_test_geosite.dat", Code: code})
+		common.Must(err)
+		return domains[0].Value
+	}
+
+	writeSites("example.com")
+	loader := NewSourceLoader()
+	defer loader.Release()
+	if v := load(loader, "a"); v != "a.example.com" {
+		t.Error("unexpected domain: ", v)
+	}
+
+	// The file is parsed only once by a loader.
+	writeSites("example.org")
+	if v := load(loader, "b"); v != "b.example.com" {
+		t.Error("expect domain from the parsed file, but got ", v)
+	}
+	if v := load(NewSourceLoader(), "b"); v != "b.example.org" {
+		t.Error("expect domain from the changed file, but got ", v)
+	}
+}
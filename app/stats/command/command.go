@@ -13,18 +13,27 @@ import (
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/strmatcher"
 	"github.com/xtls/xray-core/core"
+	feature_policy "github.com/xtls/xray-core/features/policy"
 	feature_stats "github.com/xtls/xray-core/features/stats"
 )
 
 // statsServer is an implementation of StatsService.
 type statsServer struct {
 	stats     feature_stats.Manager
+	policy    feature_policy.Manager
 	startTime time.Time
 }
 
 func NewStatsServer(manager feature_stats.Manager) StatsServiceServer {
+	return NewStatsServerWithPolicy(manager, nil)
+}
+
+// NewStatsServerWithPolicy creates a StatsServiceServer listing online users
+// tracked by the policy manager.
+func NewStatsServerWithPolicy(manager feature_stats.Manager, pm feature_policy.Manager) StatsServiceServer {
 	return &statsServer{
 		stats:     manager,
+		policy:    pm,
 		startTime: time.Now(),
 	}
 }
@@ -102,14 +111,50 @@ func (s *statsServer) GetSysStats(ctx context.Context, request *SysStatsRequest)
 	return response, nil
 }
 
+func (s *statsServer) GetOnlineUsers(ctx context.Context, request *GetOnlineUsersRequest) (*GetOnlineUsersResponse, error) {
+	tracker, ok := s.policy.(feature_policy.UserTracker)
+	if !ok {
+		return nil, newError("online users are not tracked by the policy manager")
+	}
+
+	response := &GetOnlineUsersResponse{}
+	for _, user := range tracker.OnlineUsers() {
+		u := &OnlineUser{
+			Email:       user.Email,
+			Connections: user.Connections,
+		}
+		for _, ip := range user.SourceIPs {
+			u.Ips = append(u.Ips, ip.String())
+		}
+		response.Users = append(response.Users, u)
+	}
+	return response, nil
+}
+
+func (s *statsServer) SnapshotStats(ctx context.Context, request *SnapshotStatsRequest) (*SnapshotStatsResponse, error) {
+	manager, ok := s.stats.(*stats.Manager)
+	if !ok {
+		return nil, newError("SnapshotStats only works its own stats.Manager.")
+	}
+	n, err := manager.Snapshot()
+	if err != nil {
+		return nil, newError("failed to snapshot counters").Base(err)
+	}
+	return &SnapshotStatsResponse{
+		File:     manager.PersistFile(),
+		Counters: uint32(n),
+	}, nil
+}
+
 func (s *statsServer) mustEmbedUnimplementedStatsServiceServer() {}
 
 type service struct {
-	statsManager feature_stats.Manager
+	statsManager  feature_stats.Manager
+	policyManager feature_policy.Manager
 }
 
 func (s *service) Register(server *grpc.Server) {
-	ss := NewStatsServer(s.statsManager)
+	ss := NewStatsServerWithPolicy(s.statsManager, s.policyManager)
 	RegisterStatsServiceServer(server, ss)
 
 	// For compatibility purposes
@@ -122,8 +167,9 @@ func init() {
 	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, cfg interface{}) (interface{}, error) {
 		s := new(service)
 
-		core.RequireFeatures(ctx, func(sm feature_stats.Manager) {
+		core.RequireFeatures(ctx, func(sm feature_stats.Manager, pm feature_policy.Manager) {
 			s.statsManager = sm
+			s.policyManager = pm
 		})
 
 		return s, nil
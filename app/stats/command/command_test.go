@@ -2,14 +2,18 @@ package command_test
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 
+	"github.com/xtls/xray-core/app/policy"
 	"github.com/xtls/xray-core/app/stats"
 	. "github.com/xtls/xray-core/app/stats/command"
 	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
 )
 
 func TestGetStats(t *testing.T) {
@@ -90,3 +94,49 @@ func TestQueryStats(t *testing.T) {
 		t.Error(r)
 	}
 }
+
+func TestGetOnlineUsers(t *testing.T) {
+	m, err := stats.NewManager(context.Background(), &stats.Config{})
+	common.Must(err)
+	pm, err := policy.New(context.Background(), &policy.Config{})
+	common.Must(err)
+
+	release, err := pm.AcquireConnection(&protocol.MemoryUser{Email: "test@example.com"}, net.ParseAddress("10.0.0.1"))
+	common.Must(err)
+	defer release()
+
+	s := NewStatsServerWithPolicy(m, pm)
+	resp, err := s.GetOnlineUsers(context.Background(), &GetOnlineUsersRequest{})
+	common.Must(err)
+	if r := cmp.Diff(resp.Users, []*OnlineUser{
+		{Email: "test@example.com", Connections: 1, Ips: []string{"10.0.0.1"}},
+	}, cmpopts.IgnoreUnexported(OnlineUser{})); r != "" {
+		t.Error(r)
+	}
+
+	if _, err := NewStatsServer(m).GetOnlineUsers(context.Background(), &GetOnlineUsersRequest{}); err == nil {
+		t.Error("expect error without a policy manager")
+	}
+}
+
+func TestSnapshotStats(t *testing.T) {
+	m, err := stats.NewManager(context.Background(), &stats.Config{})
+	common.Must(err)
+	s := NewStatsServer(m)
+	if _, err := s.SnapshotStats(context.Background(), &SnapshotStatsRequest{}); err == nil {
+		t.Error("expect error without persist file")
+	}
+
+	file := filepath.Join(t.TempDir(), "stats.json")
+	m, err = stats.NewManager(context.Background(), &stats.Config{PersistFile: file})
+	common.Must(err)
+	_, err = m.RegisterCounter("test_counter")
+	common.Must(err)
+
+	s = NewStatsServer(m)
+	resp, err := s.SnapshotStats(context.Background(), &SnapshotStatsRequest{})
+	common.Must(err)
+	if resp.File != file || resp.Counters != 1 {
+		t.Error("unexpected response: ", resp)
+	}
+}
@@ -0,0 +1,174 @@
+package stats
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/xtls/xray-core/common/platform/filesystem"
+	"github.com/xtls/xray-core/common/task"
+)
+
+const defaultPersistInterval = time.Minute * 5
+
+// snapshot is the content of the persist file of counters.
+type snapshot struct {
+	Time     int64            `json:"time"`
+	Counters map[string]int64 `json:"counters"`
+	// Checksum is the hex encoded SHA-256 of Counters in JSON.
+	Checksum string `json:"checksum"`
+}
+
+func checksum(counters map[string]int64) (string, error) {
+	// Maps are marshaled with sorted keys, so the result is stable.
+	b, err := json.Marshal(counters)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// shouldPersist returns whether the counter of the name is persisted.
+func (m *Manager) shouldPersist(name string) bool {
+	if len(m.config.GetPersistPatterns()) == 0 {
+		return true
+	}
+	for _, pattern := range m.config.GetPersistPatterns() {
+		if strings.Contains(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// startPersist restores counters from the persist file, and starts taking
+// snapshots periodically. If the file can't be restored, it is moved aside
+// and counters start from zero.
+func (m *Manager) startPersist() error {
+	if err := m.load(); err != nil {
+		file := m.config.GetPersistFile()
+		newError("failed to restore counters from ", file, ", starting with empty counters").Base(err).AtWarning().WriteToLog()
+		if err := os.Rename(file, file+".corrupt"); err != nil {
+			newError("failed to move aside ", file).Base(err).AtWarning().WriteToLog()
+		}
+	}
+	interval := defaultPersistInterval
+	if m.config.GetPersistInterval() > 0 {
+		interval = time.Duration(m.config.GetPersistInterval()) * time.Second
+	}
+	m.persist = &task.Periodic{
+		Interval: interval,
+		Execute: func() error {
+			if _, err := m.Snapshot(); err != nil {
+				newError("failed to save counters to ", m.config.GetPersistFile()).Base(err).AtWarning().WriteToLog()
+			}
+			return nil
+		},
+	}
+	return m.persist.Start()
+}
+
+// closePersist stops periodic snapshots and takes a final one.
+func (m *Manager) closePersist() {
+	if m.persist == nil {
+		return
+	}
+	m.persist.Close()
+	m.persist = nil
+	if _, err := m.Snapshot(); err != nil {
+		newError("failed to save counters to ", m.config.GetPersistFile()).Base(err).AtWarning().WriteToLog()
+	}
+}
+
+// load reads the persist file. Values of registered counters are restored
+// immediately, and the others when they are registered.
+func (m *Manager) load() error {
+	b, err := ioutil.ReadFile(m.config.GetPersistFile())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var s snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return newError("invalid persist file").Base(err)
+	}
+	sum, err := checksum(s.Counters)
+	if err != nil {
+		return err
+	}
+	if sum != s.Checksum {
+		return newError("checksum mismatch of persist file")
+	}
+
+	m.access.Lock()
+	defer m.access.Unlock()
+	n := 0
+	for name, value := range s.Counters {
+		if !m.shouldPersist(name) {
+			continue
+		}
+		n++
+		if c, found := m.counters[name]; found {
+			c.Add(value)
+			continue
+		}
+		m.restored[name] = value
+	}
+	newError("restored ", n, " counters saved at ", time.Unix(s.Time, 0)).AtInfo().WriteToLog()
+	return nil
+}
+
+// Snapshot writes values of persisted counters to the persist file, and
+// returns the number of them.
+func (m *Manager) Snapshot() (int, error) {
+	if m.config.GetPersistFile() == "" {
+		return 0, newError("persist file of counters is not specified")
+	}
+
+	m.saveAccess.Lock()
+	defer m.saveAccess.Unlock()
+
+	s := snapshot{
+		Time:     time.Now().Unix(),
+		Counters: make(map[string]int64),
+	}
+	m.access.RLock()
+	for name, c := range m.counters {
+		if m.shouldPersist(name) {
+			s.Counters[name] = c.Value()
+		}
+	}
+	// Restored counters not yet registered are kept.
+	for name, value := range m.restored {
+		s.Counters[name] = value
+	}
+	m.access.RUnlock()
+
+	sum, err := checksum(s.Counters)
+	if err != nil {
+		return 0, err
+	}
+	s.Checksum = sum
+	b, err := json.Marshal(s)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := filesystem.WriteFileAtomic(m.config.GetPersistFile(), b); err != nil {
+		return 0, err
+	}
+	return len(s.Counters), nil
+}
+
+// PersistFile returns the file counters are persisted in, or empty if
+// counters are not persisted.
+func (m *Manager) PersistFile() string {
+	return m.config.GetPersistFile()
+}
@@ -0,0 +1,85 @@
+package stats_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+)
+
+func TestPersistCounters(t *testing.T) {
+	config := &Config{
+		PersistFile:     filepath.Join(t.TempDir(), "stats.json"),
+		PersistPatterns: []string{"user>>>"},
+	}
+
+	m, err := NewManager(context.Background(), config)
+	common.Must(err)
+	common.Must(m.Start())
+	c, err := m.RegisterCounter("user>>>test@example.com>>>traffic>>>uplink")
+	common.Must(err)
+	c.Set(100)
+	c, err = m.RegisterCounter("inbound>>>in>>>traffic>>>uplink")
+	common.Must(err)
+	c.Set(200)
+
+	n, err := m.Snapshot()
+	common.Must(err)
+	if n != 1 {
+		t.Error("expect 1 counter in snapshot, but got ", n)
+	}
+	common.Must(m.Close())
+
+	m, err = NewManager(context.Background(), config)
+	common.Must(err)
+	common.Must(m.Start())
+	defer m.Close()
+
+	c, err = m.RegisterCounter("user>>>test@example.com>>>traffic>>>uplink")
+	common.Must(err)
+	if v := c.Value(); v != 100 {
+		t.Error("expect restored value 100, but got ", v)
+	}
+	c, err = m.RegisterCounter("inbound>>>in>>>traffic>>>uplink")
+	common.Must(err)
+	if v := c.Value(); v != 0 {
+		t.Error("expect counter not persisted, but got ", v)
+	}
+}
+
+func TestPersistChecksum(t *testing.T) {
+	config := &Config{
+		PersistFile: filepath.Join(t.TempDir(), "stats.json"),
+	}
+
+	m, err := NewManager(context.Background(), config)
+	common.Must(err)
+	c, err := m.RegisterCounter("test_counter")
+	common.Must(err)
+	c.Set(100)
+	_, err = m.Snapshot()
+	common.Must(err)
+
+	b, err := ioutil.ReadFile(config.PersistFile)
+	common.Must(err)
+	common.Must(ioutil.WriteFile(config.PersistFile, []byte(strings.Replace(string(b), "100", "900", 1)), 0o600))
+
+	// A corrupted persist file is moved aside, and counters start from zero.
+	m, err = NewManager(context.Background(), config)
+	common.Must(err)
+	common.Must(m.Start())
+	defer m.Close()
+	c, err = m.RegisterCounter("test_counter")
+	common.Must(err)
+	if v := c.Value(); v != 0 {
+		t.Error("expect counter not restored from corrupted file, but got ", v)
+	}
+	if _, err := os.Stat(config.PersistFile + ".corrupt"); err != nil {
+		t.Error("expect corrupted file moved aside: ", err)
+	}
+}
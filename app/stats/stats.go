@@ -8,22 +8,31 @@ import (
 
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/task"
 	"github.com/xtls/xray-core/features/stats"
 )
 
 // Manager is an implementation of stats.Manager.
 type Manager struct {
 	access   sync.RWMutex
+	config   *Config
 	counters map[string]*Counter
 	channels map[string]*Channel
 	running  bool
+
+	// restored holds persisted values of counters not yet registered.
+	restored   map[string]int64
+	saveAccess sync.Mutex
+	persist    *task.Periodic
 }
 
 // NewManager creates an instance of Statistics Manager.
 func NewManager(ctx context.Context, config *Config) (*Manager, error) {
 	m := &Manager{
+		config:   config,
 		counters: make(map[string]*Counter),
 		channels: make(map[string]*Channel),
+		restored: make(map[string]int64),
 	}
 
 	return m, nil
@@ -44,6 +53,10 @@ func (m *Manager) RegisterCounter(name string) (stats.Counter, error) {
 	}
 	newError("create new counter ", name).AtDebug().WriteToLog()
 	c := new(Counter)
+	if value, found := m.restored[name]; found {
+		c.Set(value)
+		delete(m.restored, name)
+	}
 	m.counters[name] = c
 	return c, nil
 }
@@ -126,6 +139,12 @@ func (m *Manager) GetChannel(name string) stats.Channel {
 
 // Start implements common.Runnable.
 func (m *Manager) Start() error {
+	if m.config.GetPersistFile() != "" {
+		if err := m.startPersist(); err != nil {
+			return err
+		}
+	}
+
 	m.access.Lock()
 	defer m.access.Unlock()
 	m.running = true
@@ -143,6 +162,8 @@ func (m *Manager) Start() error {
 
 // Close implement common.Closable.
 func (m *Manager) Close() error {
+	m.closePersist()
+
 	m.access.Lock()
 	defer m.access.Unlock()
 	m.running = false
@@ -11,6 +11,9 @@ type Lru interface {
 	GetKeyFromValue(value interface{}) (key interface{}, ok bool)
 	PeekKeyFromValue(value interface{}) (key interface{}, ok bool) // Peek means check but NOT bring to top
 	Put(key, value interface{})
+	// Range calls f for each key and value from the least recently used one,
+	// until f returns false.
+	Range(f func(key, value interface{}) bool)
 }
 
 type lru struct {
@@ -83,3 +86,14 @@ func (l lru) Put(key, value interface{}) {
 		l.mu.Unlock()
 	}
 }
+
+func (l lru) Range(f func(key, value interface{}) bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for element := l.doubleLinkedlist.Back(); element != nil; element = element.Prev() {
+		e := element.Value.(lruElement)
+		if !f(e.key, e.value) {
+			return
+		}
+	}
+}
@@ -84,3 +84,29 @@ func TestPeekKeyFromValue(t *testing.T) {
 		t.Error("should get 2", v)
 	}
 }
+
+func TestLruRange(t *testing.T) {
+	lru := NewLru(3)
+	lru.Put(1, 1)
+	lru.Put(2, 2)
+	lru.Put(3, 3)
+	lru.Get(1)
+
+	var keys []interface{}
+	lru.Range(func(key, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if len(keys) != 3 || keys[0] != 2 || keys[1] != 3 || keys[2] != 1 {
+		t.Error("should range from the least recently used, but got ", keys)
+	}
+
+	keys = nil
+	lru.Range(func(key, value interface{}) bool {
+		keys = append(keys, key)
+		return false
+	})
+	if len(keys) != 1 {
+		t.Error("should stop ranging, but got ", keys)
+	}
+}
@@ -24,7 +24,6 @@ type hasSeverity interface {
 // Error is an error object with underlying error.
 type Error struct {
 	pathObj  interface{}
-	prefix   []interface{}
 	message  []interface{}
 	inner    error
 	severity log.Severity
@@ -35,7 +34,9 @@ func (err *Error) WithPathObj(obj interface{}) *Error {
 	return err
 }
 
-func (err *Error) pkgPath() string {
+// PkgPath returns the path of the package the error is created in, relative to
+// the module. It is empty if no path object is set.
+func (err *Error) PkgPath() string {
 	if err.pathObj == nil {
 		return ""
 	}
@@ -49,13 +50,7 @@ func (err *Error) pkgPath() string {
 // Error implements error.Error().
 func (err *Error) Error() string {
 	builder := strings.Builder{}
-	for _, prefix := range err.prefix {
-		builder.WriteByte('[')
-		builder.WriteString(serial.ToString(prefix))
-		builder.WriteString("] ")
-	}
-
-	path := err.pkgPath()
+	path := err.PkgPath()
 	if len(path) > 0 {
 		builder.WriteString(path)
 		builder.WriteString(": ")
@@ -138,13 +133,10 @@ func (err *Error) WriteToLog(opts ...ExportOption) {
 		opt(&holder)
 	}
 
-	if holder.SessionID > 0 {
-		err.prefix = append(err.prefix, holder.SessionID)
-	}
-
 	log.Record(&log.GeneralMessage{
-		Severity: GetSeverity(err),
-		Content:  err,
+		Severity:  GetSeverity(err),
+		SessionID: holder.SessionID,
+		Content:   err,
 	})
 }
 
@@ -2,7 +2,9 @@ package log
 
 import (
 	"context"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/xtls/xray-core/common/serial"
 )
@@ -18,6 +20,7 @@ type AccessStatus string
 const (
 	AccessAccepted = AccessStatus("accepted")
 	AccessRejected = AccessStatus("rejected")
+	AccessClosed   = AccessStatus("closed")
 )
 
 type AccessMessage struct {
@@ -27,6 +30,19 @@ type AccessMessage struct {
 	Reason interface{}
 	Email  string
 	Detour string
+
+	// Fields below are written in the JSON format. Uplink, Downlink and
+	// Duration are also written in text for closed connections.
+	SessionID   uint32
+	InboundTag  string
+	OutboundTag string
+	// Domain is the domain sniffed from the content of the connection.
+	Domain string
+	// Uplink, Downlink and Duration are the numbers of bytes transferred and
+	// the lifetime of the connection, set in records of closed connections.
+	Uplink   int64
+	Downlink int64
+	Duration time.Duration
 }
 
 func (m *AccessMessage) String() string {
@@ -48,6 +64,15 @@ func (m *AccessMessage) String() string {
 		builder.WriteString(reason)
 	}
 
+	if m.Status == AccessClosed {
+		builder.WriteString(" uplink: ")
+		builder.WriteString(strconv.FormatInt(m.Uplink, 10))
+		builder.WriteString(" downlink: ")
+		builder.WriteString(strconv.FormatInt(m.Downlink, 10))
+		builder.WriteString(" duration: ")
+		builder.WriteString(m.Duration.Round(time.Millisecond).String())
+	}
+
 	if len(m.Email) > 0 {
 		builder.WriteString(" email: ")
 		builder.WriteString(m.Email)
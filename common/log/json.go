@@ -0,0 +1,118 @@
+package log
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/xtls/xray-core/common/serial"
+)
+
+type hasPkgPath interface {
+	PkgPath() string
+}
+
+type jsonGeneralMessage struct {
+	Time      string `json:"time"`
+	Level     string `json:"level"`
+	SessionID uint32 `json:"session,omitempty"`
+	Module    string `json:"module,omitempty"`
+	Message   string `json:"message"`
+}
+
+type jsonAccessMessage struct {
+	Time        string `json:"time"`
+	Level       string `json:"level"`
+	Type        string `json:"type"`
+	SessionID   uint32 `json:"session,omitempty"`
+	Status      string `json:"status"`
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	Domain      string `json:"domain,omitempty"`
+	Email       string `json:"email,omitempty"`
+	InboundTag  string `json:"inbound,omitempty"`
+	OutboundTag string `json:"outbound,omitempty"`
+	Route       string `json:"route,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	Uplink      int64  `json:"uplink,omitempty"`
+	Downlink    int64  `json:"downlink,omitempty"`
+	DurationMs  int64  `json:"duration_ms,omitempty"`
+}
+
+type jsonDNSLog struct {
+	Time      string   `json:"time"`
+	Level     string   `json:"level"`
+	Type      string   `json:"type"`
+	Server    string   `json:"server"`
+	Status    string   `json:"status"`
+	Domain    string   `json:"domain"`
+	IPs       []string `json:"ips,omitempty"`
+	ElapsedMs int64    `json:"elapsed_ms,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// FormatJSON returns the message as a JSON object.
+func FormatJSON(msg Message) string {
+	now := time.Now().Format(time.RFC3339Nano)
+	var v interface{}
+	switch msg := msg.(type) {
+	case *GeneralMessage:
+		m := &jsonGeneralMessage{
+			Time:      now,
+			Level:     strings.ToLower(msg.Severity.String()),
+			SessionID: msg.SessionID,
+			Message:   serial.ToString(msg.Content),
+		}
+		if p, ok := msg.Content.(hasPkgPath); ok {
+			m.Module = p.PkgPath()
+		}
+		v = m
+	case *AccessMessage:
+		v = &jsonAccessMessage{
+			Time:        now,
+			Level:       "info",
+			Type:        "access",
+			SessionID:   msg.SessionID,
+			Status:      string(msg.Status),
+			Source:      serial.ToString(msg.From),
+			Destination: serial.ToString(msg.To),
+			Domain:      msg.Domain,
+			Email:       msg.Email,
+			InboundTag:  msg.InboundTag,
+			OutboundTag: msg.OutboundTag,
+			Route:       msg.Detour,
+			Reason:      serial.ToString(msg.Reason),
+			Uplink:      msg.Uplink,
+			Downlink:    msg.Downlink,
+			DurationMs:  msg.Duration.Milliseconds(),
+		}
+	case *DNSLog:
+		m := &jsonDNSLog{
+			Time:      now,
+			Level:     "info",
+			Type:      "dns",
+			Server:    msg.Server,
+			Status:    strings.TrimSuffix(string(msg.Status), ":"),
+			Domain:    msg.Domain,
+			ElapsedMs: msg.Elapsed.Milliseconds(),
+		}
+		for _, ip := range msg.Result {
+			m.IPs = append(m.IPs, ip.String())
+		}
+		if msg.Error != nil {
+			m.Error = msg.Error.Error()
+		}
+		v = m
+	default:
+		v = &jsonGeneralMessage{
+			Time:    now,
+			Level:   "info",
+			Message: msg.String(),
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return msg.String()
+	}
+	return string(b)
+}
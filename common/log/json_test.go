@@ -0,0 +1,76 @@
+package log_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	. "github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+)
+
+func formatJSON(t *testing.T, msg Message) map[string]interface{} {
+	t.Helper()
+	var v map[string]interface{}
+	common.Must(json.Unmarshal([]byte(FormatJSON(msg)), &v))
+	if _, ok := v["time"].(string); !ok {
+		t.Error("expect time in ", v)
+	}
+	delete(v, "time")
+	return v
+}
+
+func TestFormatAccessMessageJSON(t *testing.T) {
+	v := formatJSON(t, &AccessMessage{
+		From:        net.TCPDestination(net.ParseAddress("10.0.0.1"), 1234),
+		To:          net.TCPDestination(net.ParseAddress("1.2.3.4"), 443),
+		Status:      AccessAccepted,
+		Email:       "test@example.com",
+		Detour:      "in -> out",
+		SessionID:   42,
+		InboundTag:  "in",
+		OutboundTag: "out",
+		Domain:      "example.com",
+		Uplink:      100,
+		Downlink:    200,
+	})
+	if r := cmp.Diff(v, map[string]interface{}{
+		"level":       "info",
+		"type":        "access",
+		"session":     float64(42),
+		"status":      "accepted",
+		"source":      "tcp:10.0.0.1:1234",
+		"destination": "tcp:1.2.3.4:443",
+		"domain":      "example.com",
+		"email":       "test@example.com",
+		"inbound":     "in",
+		"outbound":    "out",
+		"route":       "in -> out",
+		"uplink":      float64(100),
+		"downlink":    float64(200),
+	}); r != "" {
+		t.Error(r)
+	}
+}
+
+type errPathObjHolder struct{}
+
+func TestFormatGeneralMessageJSON(t *testing.T) {
+	err := errors.New("test error").WithPathObj(errPathObjHolder{}).AtWarning()
+	v := formatJSON(t, &GeneralMessage{
+		Severity:  err.Severity(),
+		SessionID: 42,
+		Content:   err,
+	})
+	if r := cmp.Diff(v, map[string]interface{}{
+		"level":   "warning",
+		"session": float64(42),
+		"module":  "common/log_test",
+		"message": "common/log_test: test error",
+	}); r != "" {
+		t.Error(r)
+	}
+}
@@ -19,11 +19,16 @@ type Handler interface {
 // GeneralMessage is a general log message that can contain all kind of content.
 type GeneralMessage struct {
 	Severity Severity
-	Content  interface{}
+	// SessionID is the ID of the session the message is about, or 0 if none.
+	SessionID uint32
+	Content   interface{}
 }
 
 // String implements Message.
 func (m *GeneralMessage) String() string {
+	if m.SessionID > 0 {
+		return serial.Concat("[", m.Severity, "] [", m.SessionID, "] ", m.Content)
+	}
 	return serial.Concat("[", m.Severity, "] ", m.Content)
 }
 
@@ -44,6 +49,13 @@ func RegisterHandler(handler Handler) {
 	logHandler.Set(handler)
 }
 
+// ReplaceHandler registers the handler as current log handler and returns the
+// previous one, which is nil if no handler was registered. A nil handler
+// discards all logs.
+func ReplaceHandler(handler Handler) Handler {
+	return logHandler.Replace(handler)
+}
+
 type syncHandler struct {
 	sync.RWMutex
 	Handler
@@ -64,3 +76,12 @@ func (h *syncHandler) Set(handler Handler) {
 
 	h.Handler = handler
 }
+
+func (h *syncHandler) Replace(handler Handler) Handler {
+	h.Lock()
+	defer h.Unlock()
+
+	previous := h.Handler
+	h.Handler = handler
+	return previous
+}
@@ -17,11 +17,46 @@ type Writer interface {
 	io.Closer
 }
 
+// severityWriter is a Writer that records the severity of each message, such as
+// syslog. Messages are written without line separators.
+type severityWriter interface {
+	WriteSeverity(Severity, string) error
+}
+
+// severityOf returns the severity of the message. Messages other than general
+// ones are at Info.
+func severityOf(msg Message) Severity {
+	if m, ok := msg.(*GeneralMessage); ok {
+		return m.Severity
+	}
+	return Severity_Info
+}
+
 // WriterCreator is a function to create LogWriters.
 type WriterCreator func() Writer
 
+// WriterOption is an option of LogWriters.
+type WriterOption func(*log.Logger)
+
+// WithoutTimestamp disables the timestamp prefix of LogWriters, for messages
+// that carry their own.
+func WithoutTimestamp() WriterOption {
+	return func(l *log.Logger) {
+		l.SetFlags(0)
+	}
+}
+
+func newLogger(w io.Writer, opts []WriterOption) *log.Logger {
+	l := log.New(w, "", log.Ldate|log.Ltime)
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
 type generalLogger struct {
 	creator WriterCreator
+	format  func(Message) string
 	buffer  chan Message
 	access  *semaphore.Instance
 	done    *done.Instance
@@ -31,6 +66,19 @@ type generalLogger struct {
 func NewLogger(logWriterCreator WriterCreator) Handler {
 	return &generalLogger{
 		creator: logWriterCreator,
+		format:  Message.String,
+		buffer:  make(chan Message, 16),
+		access:  semaphore.New(1),
+		done:    done.New(),
+	}
+}
+
+// NewJSONLogger returns a log handler that writes messages as JSON objects,
+// one per line. The LogWriters should be created WithoutTimestamp.
+func NewJSONLogger(logWriterCreator WriterCreator) Handler {
+	return &generalLogger{
+		creator: logWriterCreator,
+		format:  FormatJSON,
 		buffer:  make(chan Message, 16),
 		access:  semaphore.New(1),
 		done:    done.New(),
@@ -55,7 +103,11 @@ func (l *generalLogger) run() {
 		case <-l.done.Wait():
 			return
 		case msg := <-l.buffer:
-			logger.Write(msg.String() + platform.LineSeparator())
+			if w, ok := logger.(severityWriter); ok {
+				w.WriteSeverity(severityOf(msg), l.format(msg))
+			} else {
+				logger.Write(l.format(msg) + platform.LineSeparator())
+			}
 			dataWritten = true
 		case <-ticker.C:
 			if !dataWritten {
@@ -97,7 +149,7 @@ func (w *consoleLogWriter) Close() error {
 }
 
 type fileLogWriter struct {
-	file   *os.File
+	file   io.Closer
 	logger *log.Logger
 }
 
@@ -111,25 +163,25 @@ func (w *fileLogWriter) Close() error {
 }
 
 // CreateStdoutLogWriter returns a LogWriterCreator that creates LogWriter for stdout.
-func CreateStdoutLogWriter() WriterCreator {
+func CreateStdoutLogWriter(opts ...WriterOption) WriterCreator {
 	return func() Writer {
 		return &consoleLogWriter{
-			logger: log.New(os.Stdout, "", log.Ldate|log.Ltime),
+			logger: newLogger(os.Stdout, opts),
 		}
 	}
 }
 
 // CreateStderrLogWriter returns a LogWriterCreator that creates LogWriter for stderr.
-func CreateStderrLogWriter() WriterCreator {
+func CreateStderrLogWriter(opts ...WriterOption) WriterCreator {
 	return func() Writer {
 		return &consoleLogWriter{
-			logger: log.New(os.Stderr, "", log.Ldate|log.Ltime),
+			logger: newLogger(os.Stderr, opts),
 		}
 	}
 }
 
 // CreateFileLogWriter returns a LogWriterCreator that creates LogWriter for the given file.
-func CreateFileLogWriter(path string) (WriterCreator, error) {
+func CreateFileLogWriter(path string, opts ...WriterOption) (WriterCreator, error) {
 	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
 	if err != nil {
 		return nil, err
@@ -142,7 +194,27 @@ func CreateFileLogWriter(path string) (WriterCreator, error) {
 		}
 		return &fileLogWriter{
 			file:   file,
-			logger: log.New(file, "", log.Ldate|log.Ltime),
+			logger: newLogger(file, opts),
+		}
+	}, nil
+}
+
+// CreateRotatingFileLogWriter returns a LogWriterCreator that creates LogWriter
+// for the given file, rotated by the options.
+func CreateRotatingFileLogWriter(path string, rotate RotateOptions, opts ...WriterOption) (WriterCreator, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+	return func() Writer {
+		file, err := openRotatingFile(path, rotate)
+		if err != nil {
+			return nil
+		}
+		return &fileLogWriter{
+			file:   file,
+			logger: newLogger(file, opts),
 		}
 	}, nil
 }
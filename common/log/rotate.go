@@ -0,0 +1,222 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "20060102-150405.000"
+
+// RotateOptions are the options to rotate log files.
+type RotateOptions struct {
+	// MaxSize is the size in bytes to rotate the file at. 0 for no limit.
+	MaxSize int64
+	// Interval rotates the file at each multiple of it since the Unix epoch,
+	// so a day rotates at UTC midnight. 0 to disable.
+	Interval time.Duration
+	// MaxBackups is the number of rotated files to keep. 0 to keep all.
+	MaxBackups int
+	// MaxAge is the duration to keep rotated files for. 0 to keep all.
+	MaxAge time.Duration
+	// Compress compresses rotated files with gzip.
+	Compress bool
+}
+
+// rotatingFile is a log file rotated by size and time. Rotated files are
+// renamed with the time of rotation as suffix.
+type rotatingFile struct {
+	path    string
+	options RotateOptions
+	file    *os.File
+	size    int64
+	period  time.Time
+
+	// Rotated files are cleaned up one at a time in background.
+	cleanup       sync.WaitGroup
+	cleanupAccess sync.Mutex
+}
+
+func openRotatingFile(path string, options RotateOptions) (*rotatingFile, error) {
+	f := &rotatingFile{
+		path:    path,
+		options: options,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	if f.size > 0 {
+		f.period = f.periodOf(info.ModTime())
+	} else {
+		f.period = f.periodOf(time.Now())
+	}
+	return nil
+}
+
+func (f *rotatingFile) periodOf(t time.Time) time.Time {
+	if f.options.Interval <= 0 {
+		return time.Time{}
+	}
+	return t.Truncate(f.options.Interval)
+}
+
+func (f *rotatingFile) shouldRotate(now time.Time, n int) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.options.MaxSize > 0 && f.size+int64(n) > f.options.MaxSize {
+		return true
+	}
+	return f.options.Interval > 0 && !f.periodOf(now).Equal(f.period)
+}
+
+// Write implements io.Writer.
+func (f *rotatingFile) Write(b []byte) (int, error) {
+	if now := time.Now(); f.shouldRotate(now, len(b)) {
+		if err := f.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(b)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) rotate(now time.Time) error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	backup := f.backupName(now)
+	if err := os.Rename(f.path, backup); err != nil {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.cleanup.Add(1)
+	go func() {
+		defer f.cleanup.Done()
+		f.cleanupAccess.Lock()
+		defer f.cleanupAccess.Unlock()
+		if f.options.Compress {
+			// The file may be removed as an old backup already.
+			if err := compressFile(backup); err != nil && !os.IsNotExist(err) {
+				os.Stderr.WriteString("failed to compress log file " + backup + ": " + err.Error() + "\n")
+			}
+		}
+		f.removeBackups(now)
+	}()
+	return nil
+}
+
+// backupName returns an unused name for the file rotated at the time.
+func (f *rotatingFile) backupName(now time.Time) string {
+	base := f.path + "." + now.Format(backupTimeFormat)
+	name := base
+	for i := 1; fileExists(name) || fileExists(name+".gz"); i++ {
+		name = base + "." + strconv.Itoa(i)
+	}
+	return name
+}
+
+func fileExists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// removeBackups removes rotated files beyond MaxBackups or older than MaxAge.
+func (f *rotatingFile) removeBackups(now time.Time) {
+	if f.options.MaxBackups <= 0 && f.options.MaxAge <= 0 {
+		return
+	}
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return
+	}
+	var backups []string
+	for _, name := range matches {
+		suffix := strings.TrimPrefix(name, f.path+".")
+		if len(suffix) < len(backupTimeFormat) {
+			continue
+		}
+		if _, err := time.Parse(backupTimeFormat, suffix[:len(backupTimeFormat)]); err == nil {
+			backups = append(backups, name)
+		}
+	}
+	// Newest first, as the names without ".gz" sort by time.
+	sort.Slice(backups, func(i, j int) bool {
+		return strings.TrimSuffix(backups[i], ".gz") > strings.TrimSuffix(backups[j], ".gz")
+	})
+
+	for i, name := range backups {
+		expired := false
+		if f.options.MaxAge > 0 {
+			if info, err := os.Stat(name); err == nil && now.Sub(info.ModTime()) > f.options.MaxAge {
+				expired = true
+			}
+		}
+		if expired || (f.options.MaxBackups > 0 && i >= f.options.MaxBackups) {
+			os.Remove(name)
+		}
+	}
+}
+
+// Close implements io.Closer. It waits for compression of rotated files.
+func (f *rotatingFile) Close() error {
+	err := f.file.Close()
+	f.cleanup.Wait()
+	return err
+}
+
+// compressFile replaces the file with its gzip compressed copy.
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	w := gzip.NewWriter(dst)
+	if _, err := io.Copy(w, src); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return err
+	}
+	if err := w.Close(); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return err
+	}
+	src.Close()
+	return os.Remove(name)
+}
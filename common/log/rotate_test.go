@@ -0,0 +1,36 @@
+package log_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	. "github.com/xtls/xray-core/common/log"
+)
+
+func TestRotatingFileLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	creator, err := CreateRotatingFileLogWriter(path, RotateOptions{
+		MaxSize:    100,
+		MaxBackups: 2,
+		Compress:   true,
+	}, WithoutTimestamp())
+	common.Must(err)
+
+	w := creator()
+	line := strings.Repeat("x", 39) + "\n"
+	for i := 0; i < 10; i++ {
+		common.Must(w.Write(line))
+	}
+	common.Must(w.Close())
+
+	backups, err := filepath.Glob(path + ".*.gz")
+	common.Must(err)
+	if len(backups) != 2 {
+		t.Error("expect 2 compressed backups, but got ", backups)
+	}
+	if others, _ := filepath.Glob(path + ".*[0-9]"); len(others) != 0 {
+		t.Error("expect no uncompressed backups, but got ", others)
+	}
+}
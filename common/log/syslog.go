@@ -0,0 +1,199 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultSyslogAddress is the unix socket of the local syslog daemon.
+	DefaultSyslogAddress = "/dev/log"
+	// DefaultJournaldAddress is the native protocol socket of systemd-journald.
+	DefaultJournaldAddress = "/run/systemd/journal/socket"
+	// DefaultSyslogTag is the app name of messages in syslog and journald.
+	DefaultSyslogTag = "xray"
+	// SyslogFacilityDaemon is the facility of system daemons.
+	SyslogFacilityDaemon = 3
+)
+
+// SyslogOptions are the options to write logs to syslog.
+type SyslogOptions struct {
+	// Network is one of "unixgram", "unix", "udp" and "tcp". Default unixgram.
+	Network string
+	// Address is the socket path or host:port of the syslog server. Default
+	// DefaultSyslogAddress for unix sockets, or localhost:514.
+	Address string
+	// Tag is the app name of messages. Default DefaultSyslogTag.
+	Tag string
+	// Facility is the syslog facility code. SyslogFacilityDaemon if nil.
+	Facility *int
+}
+
+// syslogSeverity returns the RFC 5424 severity of the severity.
+func syslogSeverity(s Severity) int {
+	switch s {
+	case Severity_Error:
+		return 3
+	case Severity_Warning:
+		return 4
+	case Severity_Info:
+		return 6
+	case Severity_Debug:
+		return 7
+	default:
+		return 5
+	}
+}
+
+type syslogWriter struct {
+	options  SyslogOptions
+	facility int
+	conn     net.Conn
+	hostname string
+	pid      string
+}
+
+func (w *syslogWriter) stream() bool {
+	return w.options.Network == "unix" || w.options.Network == "tcp"
+}
+
+func (w *syslogWriter) format(severity Severity, s string) string {
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	builder := strings.Builder{}
+	builder.WriteByte('<')
+	builder.WriteString(strconv.Itoa(w.facility*8 + syslogSeverity(severity)))
+	builder.WriteString(">1 ")
+	builder.WriteString(time.Now().Format("2006-01-02T15:04:05.000000Z07:00"))
+	builder.WriteByte(' ')
+	builder.WriteString(w.hostname)
+	builder.WriteByte(' ')
+	builder.WriteString(w.options.Tag)
+	builder.WriteByte(' ')
+	builder.WriteString(w.pid)
+	builder.WriteString(" - - ")
+	builder.WriteString(strings.TrimRight(s, "\r\n"))
+	msg := builder.String()
+	if w.stream() {
+		// Octet counting of RFC 6587.
+		return strconv.Itoa(len(msg)) + " " + msg
+	}
+	return msg
+}
+
+// WriteSeverity implements severityWriter.
+func (w *syslogWriter) WriteSeverity(severity Severity, s string) error {
+	_, err := w.conn.Write([]byte(w.format(severity, s)))
+	return err
+}
+
+// Write implements Writer.
+func (w *syslogWriter) Write(s string) error {
+	return w.WriteSeverity(Severity_Info, s)
+}
+
+func (w *syslogWriter) Close() error {
+	return w.conn.Close()
+}
+
+// CreateSyslogLogWriter returns a LogWriterCreator that creates LogWriter for
+// syslog in the RFC 5424 format.
+func CreateSyslogLogWriter(options SyslogOptions) WriterCreator {
+	if options.Network == "" {
+		options.Network = "unixgram"
+	}
+	if options.Address == "" {
+		if strings.HasPrefix(options.Network, "unix") {
+			options.Address = DefaultSyslogAddress
+		} else {
+			options.Address = "localhost:514"
+		}
+	}
+	if options.Tag == "" {
+		options.Tag = DefaultSyslogTag
+	}
+	facility := SyslogFacilityDaemon
+	if options.Facility != nil {
+		facility = *options.Facility
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return func() Writer {
+		conn, err := net.Dial(options.Network, options.Address)
+		if err != nil {
+			return nil
+		}
+		return &syslogWriter{
+			options:  options,
+			facility: facility,
+			conn:     conn,
+			hostname: hostname,
+			pid:      strconv.Itoa(os.Getpid()),
+		}
+	}
+}
+
+type journaldWriter struct {
+	conn *net.UnixConn
+	tag  string
+}
+
+// appendJournalField appends a field in the native journal protocol.
+func appendJournalField(b *bytes.Buffer, key string, value string) {
+	b.WriteString(key)
+	if strings.Contains(value, "\n") {
+		// Values with newlines are written with their lengths.
+		b.WriteByte('\n')
+		binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	} else {
+		b.WriteByte('=')
+	}
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// WriteSeverity implements severityWriter.
+func (w *journaldWriter) WriteSeverity(severity Severity, s string) error {
+	var b bytes.Buffer
+	appendJournalField(&b, "MESSAGE", strings.TrimRight(s, "\r\n"))
+	appendJournalField(&b, "PRIORITY", strconv.Itoa(syslogSeverity(severity)))
+	appendJournalField(&b, "SYSLOG_IDENTIFIER", w.tag)
+	_, err := w.conn.Write(b.Bytes())
+	return err
+}
+
+// Write implements Writer.
+func (w *journaldWriter) Write(s string) error {
+	return w.WriteSeverity(Severity_Info, s)
+}
+
+func (w *journaldWriter) Close() error {
+	return w.conn.Close()
+}
+
+// CreateJournaldLogWriter returns a LogWriterCreator that creates LogWriter for
+// systemd-journald at the socket address, or DefaultJournaldAddress if empty.
+func CreateJournaldLogWriter(address string, tag string) WriterCreator {
+	if address == "" {
+		address = DefaultJournaldAddress
+	}
+	if tag == "" {
+		tag = DefaultSyslogTag
+	}
+	return func() Writer {
+		conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: address, Net: "unixgram"})
+		if err != nil {
+			return nil
+		}
+		return &journaldWriter{
+			conn: conn,
+			tag:  tag,
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package log_test
+
+import (
+	"net"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	. "github.com/xtls/xray-core/common/log"
+)
+
+func readPacket(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+	common.Must(conn.SetReadDeadline(time.Now().Add(time.Second * 5)))
+	b := make([]byte, 2048)
+	n, _, err := conn.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b[:n])
+}
+
+func TestSyslogLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenPacket("unixgram", path)
+	common.Must(err)
+	defer conn.Close()
+
+	handler := NewLogger(CreateSyslogLogWriter(SyslogOptions{
+		Address: path,
+		Tag:     "test",
+	}))
+	defer common.Close(handler)
+	handler.Handle(&GeneralMessage{Severity: Severity_Warning, Content: "test message"})
+
+	// Facility daemon (3) and severity warning (4).
+	pattern := regexp.MustCompile(`^<28>1 \S+ \S+ test \d+ - - \[Warning\] test message$`)
+	if msg := readPacket(t, conn); !pattern.MatchString(msg) {
+		t.Error("unexpected syslog message: ", msg)
+	}
+}
+
+func TestSyslogLoggerUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	common.Must(err)
+	defer conn.Close()
+
+	facility := 16
+	handler := NewLogger(CreateSyslogLogWriter(SyslogOptions{
+		Network:  "udp",
+		Address:  conn.LocalAddr().String(),
+		Facility: &facility,
+	}))
+	defer common.Close(handler)
+	handler.Handle(&AccessMessage{From: "from", To: "to", Status: AccessAccepted})
+
+	if msg := readPacket(t, conn); !strings.HasPrefix(msg, "<134>1 ") || !strings.HasSuffix(msg, " xray "+strings.Fields(msg)[4]+" - - from accepted to") {
+		t.Error("unexpected syslog message: ", msg)
+	}
+}
+
+func TestJournaldLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenPacket("unixgram", path)
+	common.Must(err)
+	defer conn.Close()
+
+	handler := NewLogger(CreateJournaldLogWriter(path, ""))
+	defer common.Close(handler)
+	handler.Handle(&GeneralMessage{Severity: Severity_Error, Content: "line1\nline2"})
+
+	expected := "MESSAGE\n\x13\x00\x00\x00\x00\x00\x00\x00[Error] line1\nline2\nPRIORITY=3\nSYSLOG_IDENTIFIER=xray\n"
+	if msg := readPacket(t, conn); msg != expected {
+		t.Errorf("unexpected journal message: %q", msg)
+	}
+}
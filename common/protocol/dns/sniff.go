@@ -0,0 +1,95 @@
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/xtls/xray-core/common"
+)
+
+type SniffHeader struct {
+	domain string
+}
+
+func (h *SniffHeader) Protocol() string {
+	return "dns"
+}
+
+func (h *SniffHeader) Domain() string {
+	return h.domain
+}
+
+var errNotDNS = errors.New("not DNS")
+
+// isQueryHeader checks the 12-byte message header. A query has exactly one
+// question, no answer or authority records, and at most one OPT record in the
+// additional section.
+func isQueryHeader(b []byte) bool {
+	return b[2]&0x80 == 0 && b[2]&0x78 == 0 &&
+		binary.BigEndian.Uint16(b[4:]) == 1 && binary.BigEndian.Uint16(b[6:]) == 0 &&
+		binary.BigEndian.Uint16(b[8:]) == 0 && binary.BigEndian.Uint16(b[10:]) <= 1
+}
+
+// parseQuery checks that b starts with a well formed DNS query, and returns
+// the name being queried.
+func parseQuery(b []byte) (string, error) {
+	if !isQueryHeader(b) {
+		return "", errNotDNS
+	}
+	var parser dnsmessage.Parser
+	_, err := parser.Start(b)
+	if err != nil {
+		return "", errNotDNS
+	}
+	q, err := parser.Question()
+	if err != nil {
+		return "", errNotDNS
+	}
+	if err := parser.SkipAllQuestions(); err != nil {
+		return "", errNotDNS
+	}
+	if err := parser.SkipAllAnswers(); err != nil {
+		return "", errNotDNS
+	}
+	if err := parser.SkipAllAuthorities(); err != nil {
+		return "", errNotDNS
+	}
+	if err := parser.SkipAllAdditionals(); err != nil {
+		return "", errNotDNS
+	}
+	return strings.TrimSuffix(q.Name.String(), "."), nil
+}
+
+// SniffDNS sniffs a DNS query carried in a UDP datagram.
+func SniffDNS(b []byte) (*SniffHeader, error) {
+	if len(b) < 12 {
+		return nil, common.ErrNoClue
+	}
+	domain, err := parseQuery(b)
+	if err != nil {
+		return nil, err
+	}
+	return &SniffHeader{domain: domain}, nil
+}
+
+// SniffTCPDNS sniffs a DNS query prefixed by its length, as sent over TCP.
+func SniffTCPDNS(b []byte) (*SniffHeader, error) {
+	if len(b) < 2+12 {
+		return nil, common.ErrNoClue
+	}
+	length := int(binary.BigEndian.Uint16(b))
+	if length < 12 || !isQueryHeader(b[2:]) {
+		return nil, errNotDNS
+	}
+	if len(b) < 2+length {
+		return nil, common.ErrNoClue
+	}
+	domain, err := parseQuery(b[2 : 2+length])
+	if err != nil {
+		return nil, err
+	}
+	return &SniffHeader{domain: domain}, nil
+}
@@ -0,0 +1,47 @@
+package dns_test
+
+import (
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/xtls/xray-core/common"
+	. "github.com/xtls/xray-core/common/protocol/dns"
+)
+
+func TestSniffDNS(t *testing.T) {
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: 1, RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: dnsmessage.MustNewName("www.example.com."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET},
+		},
+	}
+	packed, err := query.Pack()
+	common.Must(err)
+
+	header, err := SniffDNS(packed)
+	common.Must(err)
+	if header.Protocol() != "dns" || header.Domain() != "www.example.com" {
+		t.Error("unexpected sniff result: ", header.Protocol(), " ", header.Domain())
+	}
+
+	tcpPacked := append([]byte{byte(len(packed) >> 8), byte(len(packed))}, packed...)
+	if _, err := SniffTCPDNS(tcpPacked[:len(tcpPacked)-1]); err != common.ErrNoClue {
+		t.Error("expect ErrNoClue on partial query, but got ", err)
+	}
+	header, err = SniffTCPDNS(tcpPacked)
+	common.Must(err)
+	if header.Domain() != "www.example.com" {
+		t.Error("unexpected domain: ", header.Domain())
+	}
+
+	query.Header.Response = true
+	response, err := query.Pack()
+	common.Must(err)
+	if _, err := SniffDNS(response); err == nil {
+		t.Error("expect error on DNS response")
+	}
+	if _, err := SniffDNS([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")); err == nil {
+		t.Error("expect error on non-DNS data")
+	}
+}
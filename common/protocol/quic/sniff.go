@@ -0,0 +1,345 @@
+package quic
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/protocol/tls"
+)
+
+type SniffHeader struct {
+	domain string
+	hello  *tls.SniffHeader
+}
+
+func (h *SniffHeader) Protocol() string {
+	return "quic"
+}
+
+func (h *SniffHeader) Domain() string {
+	return h.domain
+}
+
+// Attributes returns metadata of the ClientHello as content attributes.
+func (h *SniffHeader) Attributes() map[string]string {
+	attrs := h.hello.Attributes()
+	attrs[tls.AttributeJA4] = h.hello.JA4(true)
+	return attrs
+}
+
+const (
+	versionDraft29 uint32 = 0xff00001d
+	version1       uint32 = 0x1
+	version2       uint32 = 0x6b3343cf
+)
+
+var (
+	saltDraft29 = []byte{0xaf, 0xbf, 0xec, 0x28, 0x99, 0x93, 0xd2, 0x4c, 0x9e, 0x97, 0x86, 0xf1, 0x9c, 0x61, 0x11, 0xe0, 0x43, 0x90, 0xa8, 0x99}
+	saltV1      = []byte{0x38, 0x76, 0x2c, 0xf7, 0xf5, 0x59, 0x34, 0xb3, 0x4d, 0x17, 0x9a, 0xe6, 0xa4, 0xc8, 0x0c, 0xad, 0xcc, 0xbb, 0x7f, 0x0a}
+	saltV2      = []byte{0x0d, 0xed, 0xe3, 0xde, 0xf7, 0x00, 0xa6, 0xdb, 0x81, 0x93, 0x81, 0xbe, 0x6e, 0x26, 0x9d, 0xcb, 0xf9, 0xbd, 0x2e, 0xd9}
+)
+
+var (
+	errNotQuic        = errors.New("not QUIC")
+	errNotQuicInitial = errors.New("not QUIC initial packet")
+)
+
+// versionParams holds the version specific parameters to protect Initial packets.
+type versionParams struct {
+	salt        []byte
+	labelPrefix string
+	initialType byte
+}
+
+func getVersionParams(version uint32) (*versionParams, bool) {
+	switch version {
+	case version1:
+		return &versionParams{salt: saltV1, labelPrefix: "quic ", initialType: 0x0}, true
+	case versionDraft29:
+		return &versionParams{salt: saltDraft29, labelPrefix: "quic ", initialType: 0x0}, true
+	case version2:
+		return &versionParams{salt: saltV2, labelPrefix: "quicv2 ", initialType: 0x1}, true
+	default:
+		return nil, false
+	}
+}
+
+// hkdfExpandLabel implements HKDF-Expand-Label from RFC 8446, Section 7.1.
+func hkdfExpandLabel(secret []byte, label string, length int) []byte {
+	fullLabel := "tls13 " + label
+	info := make([]byte, 0, 4+len(fullLabel))
+	info = append(info, byte(length>>8), byte(length), byte(len(fullLabel)))
+	info = append(info, fullLabel...)
+	info = append(info, 0)
+	out := make([]byte, length)
+	common.Must2(hkdf.Expand(sha256.New, secret, info).Read(out))
+	return out
+}
+
+// initialKeys returns the key, IV and header protection key of client Initial packets.
+func initialKeys(params *versionParams, dcid []byte) (key, iv, hp []byte) {
+	initialSecret := hkdf.Extract(sha256.New, dcid, params.salt)
+	clientSecret := hkdfExpandLabel(initialSecret, "client in", sha256.Size)
+	key = hkdfExpandLabel(clientSecret, params.labelPrefix+"key", 16)
+	iv = hkdfExpandLabel(clientSecret, params.labelPrefix+"iv", 12)
+	hp = hkdfExpandLabel(clientSecret, params.labelPrefix+"hp", 16)
+	return
+}
+
+// readVarInt reads a variable-length integer, defined in RFC 9000, Section 16.
+func readVarInt(b []byte) (uint64, int, bool) {
+	if len(b) == 0 {
+		return 0, 0, false
+	}
+	length := 1 << (b[0] >> 6)
+	if len(b) < length {
+		return 0, 0, false
+	}
+	v := uint64(b[0] & 0x3f)
+	for i := 1; i < length; i++ {
+		v = v<<8 | uint64(b[i])
+	}
+	return v, length, true
+}
+
+// decryptInitial removes protection of the client Initial packet at the
+// beginning of b. It returns the decrypted payload and the length of the
+// packet.
+func decryptInitial(b []byte) ([]byte, int, error) {
+	if len(b) < 7 {
+		return nil, 0, common.ErrNoClue
+	}
+	if b[0]&0x80 == 0 {
+		return nil, 0, errNotQuic
+	}
+	version := binary.BigEndian.Uint32(b[1:5])
+	params, ok := getVersionParams(version)
+	if !ok {
+		return nil, 0, errNotQuic
+	}
+	if (b[0]>>4)&0x3 != params.initialType {
+		return nil, 0, errNotQuicInitial
+	}
+
+	offset := 5
+	dcidLen := int(b[offset])
+	if dcidLen > 20 {
+		return nil, 0, errNotQuic
+	}
+	offset++
+	if len(b) < offset+dcidLen+1 {
+		return nil, 0, common.ErrNoClue
+	}
+	dcid := b[offset : offset+dcidLen]
+	offset += dcidLen
+
+	scidLen := int(b[offset])
+	if scidLen > 20 {
+		return nil, 0, errNotQuic
+	}
+	offset += 1 + scidLen
+
+	if len(b) < offset {
+		return nil, 0, common.ErrNoClue
+	}
+	tokenLen, n, ok := readVarInt(b[offset:])
+	if !ok || uint64(len(b)-offset-n) < tokenLen {
+		return nil, 0, common.ErrNoClue
+	}
+	offset += n + int(tokenLen)
+
+	packetLen, n, ok := readVarInt(b[offset:])
+	if !ok {
+		return nil, 0, common.ErrNoClue
+	}
+	if packetLen < 4+16 || packetLen > 0xffff {
+		return nil, 0, errNotQuic
+	}
+	offset += n
+	pnOffset := offset
+	if len(b) < pnOffset+int(packetLen) {
+		return nil, 0, common.ErrNoClue
+	}
+	packetEnd := pnOffset + int(packetLen)
+
+	key, iv, hpKey := initialKeys(params, dcid)
+
+	hp, err := aes.NewCipher(hpKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	mask := make([]byte, aes.BlockSize)
+	hp.Encrypt(mask, b[pnOffset+4:pnOffset+4+16])
+
+	header := make([]byte, pnOffset+4)
+	copy(header, b)
+	header[0] ^= mask[0] & 0x0f
+	pnLen := int(header[0]&0x3) + 1
+	var pn uint64
+	for i := 0; i < pnLen; i++ {
+		header[pnOffset+i] ^= mask[1+i]
+		pn = pn<<8 | uint64(header[pnOffset+i])
+	}
+	header = header[:pnOffset+pnLen]
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, 0, err
+	}
+	nonce := make([]byte, len(iv))
+	copy(nonce, iv)
+	for i := 0; i < 8; i++ {
+		nonce[len(nonce)-1-i] ^= byte(pn >> (8 * i))
+	}
+
+	payload, err := aead.Open(nil, nonce, b[pnOffset+pnLen:packetEnd], header)
+	if err != nil {
+		return nil, 0, errNotQuic
+	}
+	return payload, packetEnd, nil
+}
+
+// cryptoStream reassembles data of CRYPTO frames by their offsets.
+type cryptoStream struct {
+	data   []byte
+	filled []bool
+}
+
+func (s *cryptoStream) write(offset int, data []byte) {
+	if end := offset + len(data); end > len(s.data) {
+		s.data = append(s.data, make([]byte, end-len(s.data))...)
+		s.filled = append(s.filled, make([]bool, end-len(s.filled))...)
+	}
+	copy(s.data[offset:], data)
+	for i := range data {
+		s.filled[offset+i] = true
+	}
+}
+
+// prefix returns the contiguous data from offset 0.
+func (s *cryptoStream) prefix() []byte {
+	for i, ok := range s.filled {
+		if !ok {
+			return s.data[:i]
+		}
+	}
+	return s.data
+}
+
+func (s *cryptoStream) readFrames(payload []byte) error {
+	for len(payload) > 0 {
+		frameType, n, ok := readVarInt(payload)
+		if !ok {
+			return errNotQuic
+		}
+		payload = payload[n:]
+		switch frameType {
+		case 0x00, 0x01: // PADDING, PING
+		case 0x02, 0x03: // ACK
+			// Largest Acknowledged, ACK Delay, ACK Range Count, First ACK Range
+			var fields [4]uint64
+			for i := range fields {
+				v, n, ok := readVarInt(payload)
+				if !ok {
+					return errNotQuic
+				}
+				fields[i] = v
+				payload = payload[n:]
+			}
+			// Each ACK range has Gap and ACK Range Length.
+			for i := uint64(0); i < fields[2]*2; i++ {
+				_, n, ok := readVarInt(payload)
+				if !ok {
+					return errNotQuic
+				}
+				payload = payload[n:]
+			}
+			if frameType == 0x03 {
+				// ECN counts
+				for i := 0; i < 3; i++ {
+					_, n, ok := readVarInt(payload)
+					if !ok {
+						return errNotQuic
+					}
+					payload = payload[n:]
+				}
+			}
+		case 0x06: // CRYPTO
+			offset, n, ok := readVarInt(payload)
+			if !ok {
+				return errNotQuic
+			}
+			payload = payload[n:]
+			length, n, ok := readVarInt(payload)
+			if !ok || uint64(len(payload)-n) < length || offset+length > 1<<16 {
+				return errNotQuic
+			}
+			payload = payload[n:]
+			s.write(int(offset), payload[:length])
+			payload = payload[length:]
+		case 0x1c: // CONNECTION_CLOSE
+			return errNotQuicInitial
+		default:
+			return errNotQuic
+		}
+	}
+	return nil
+}
+
+// SniffQUIC extracts server name from the TLS ClientHello carried in QUIC
+// Initial packets. b may contain several packets, from one or more UDP
+// datagrams, in case the ClientHello spans multiple packets.
+func SniffQUIC(b []byte) (*SniffHeader, error) {
+	stream := &cryptoStream{}
+	for len(b) > 0 {
+		// Skip padding between datagrams.
+		if b[0] == 0 {
+			b = b[1:]
+			continue
+		}
+		payload, packetLen, err := decryptInitial(b)
+		if err != nil {
+			if err == common.ErrNoClue || len(stream.data) == 0 {
+				return nil, err
+			}
+			// Packets of other types may follow Initial packets in the same
+			// datagram.
+			break
+		}
+		b = b[packetLen:]
+		if err := stream.readFrames(payload); err != nil {
+			return nil, err
+		}
+	}
+
+	data := stream.prefix()
+	if len(data) < 4 {
+		return nil, common.ErrNoClue
+	}
+	if data[0] != 0x01 /* client hello */ {
+		return nil, errNotQuicInitial
+	}
+	helloLen := int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+	if len(data) < 4+helloLen {
+		return nil, common.ErrNoClue
+	}
+
+	h := &tls.SniffHeader{}
+	if err := tls.ReadClientHello(data[:4+helloLen], h); err != nil {
+		if err == common.ErrNoClue {
+			return nil, errNotQuicInitial
+		}
+		return nil, err
+	}
+	return &SniffHeader{domain: h.Domain(), hello: h}, nil
+}
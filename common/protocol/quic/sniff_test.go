@@ -0,0 +1,141 @@
+package quic
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+)
+
+func TestInitialKeys(t *testing.T) {
+	// https://www.rfc-editor.org/rfc/rfc9001#appendix-A.1
+	dcid, _ := hex.DecodeString("8394c8f03e515708")
+	params, _ := getVersionParams(version1)
+	key, iv, hp := initialKeys(params, dcid)
+
+	cases := []struct {
+		name   string
+		actual []byte
+		expect string
+	}{
+		{"key", key, "1f369613dd76d5467730efcbe3b1a22d"},
+		{"iv", iv, "fa044b2f42a3fd3b46fb255c"},
+		{"hp", hp, "9f50449e04a0e810283a1e9933adedd2"},
+	}
+	for _, c := range cases {
+		if hex.EncodeToString(c.actual) != c.expect {
+			t.Error(c.name, ": expect ", c.expect, " but got ", hex.EncodeToString(c.actual))
+		}
+	}
+}
+
+// buildClientHello returns a TLS ClientHello handshake message with the given
+// server name, padded to at least size bytes.
+func buildClientHello(serverName string, size int) []byte {
+	var ext []byte
+	sni := []byte{0x00}
+	sni = append(sni, byte(len(serverName)>>8), byte(len(serverName)))
+	sni = append(sni, serverName...)
+	ext = append(ext, 0x00, 0x00, byte((len(sni)+2)>>8), byte(len(sni)+2), byte(len(sni)>>8), byte(len(sni)))
+	ext = append(ext, sni...)
+	if padding := size - 4 - 2 - 32 - 1 - 4 - 2 - 2 - len(ext) - 4; padding > 0 {
+		ext = append(ext, 0x00, 0x15, byte(padding>>8), byte(padding))
+		ext = append(ext, make([]byte, padding)...)
+	}
+
+	body := []byte{0x03, 0x03}
+	body = append(body, make([]byte, 32)...)    // random
+	body = append(body, 0x00)                   // session id
+	body = append(body, 0x00, 0x02, 0x13, 0x01) // cipher suites
+	body = append(body, 0x01, 0x00)             // compression methods
+	body = append(body, byte(len(ext)>>8), byte(len(ext)))
+	body = append(body, ext...)
+
+	hello := []byte{0x01, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}
+	return append(hello, body...)
+}
+
+// sealInitial builds a protected client Initial packet, carrying a CRYPTO
+// frame of data at the given offset.
+func sealInitial(version uint32, dcid []byte, pn uint32, offset int, data []byte) []byte {
+	params, _ := getVersionParams(version)
+	key, iv, hpKey := initialKeys(params, dcid)
+
+	payload := []byte{0x06, 0x40 | byte(offset>>8), byte(offset), 0x40 | byte(len(data)>>8), byte(len(data))}
+	payload = append(payload, data...)
+	// PADDING frames
+	payload = append(payload, make([]byte, 32)...)
+
+	header := []byte{0xc0 | params.initialType<<4 | 0x03}
+	header = append(header, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(header[1:], version)
+	header = append(header, byte(len(dcid)))
+	header = append(header, dcid...)
+	header = append(header, 0x00) // scid
+	header = append(header, 0x00) // token
+	packetLen := 4 + len(payload) + 16
+	header = append(header, 0x40|byte(packetLen>>8), byte(packetLen))
+	pnOffset := len(header)
+	header = append(header, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(header[pnOffset:], pn)
+
+	block, _ := aes.NewCipher(key)
+	aead, _ := cipher.NewGCM(block)
+	nonce := make([]byte, len(iv))
+	copy(nonce, iv)
+	for i := 0; i < 4; i++ {
+		nonce[len(nonce)-1-i] ^= byte(pn >> (8 * i))
+	}
+	packet := aead.Seal(header, nonce, payload, header)
+
+	hp, _ := aes.NewCipher(hpKey)
+	mask := make([]byte, aes.BlockSize)
+	hp.Encrypt(mask, packet[pnOffset+4:pnOffset+4+16])
+	packet[0] ^= mask[0] & 0x0f
+	for i := 0; i < 4; i++ {
+		packet[pnOffset+i] ^= mask[1+i]
+	}
+	return packet
+}
+
+func TestSniffQUIC(t *testing.T) {
+	dcid, _ := hex.DecodeString("8394c8f03e515708")
+	hello := buildClientHello("www.example.com", 1500)
+	half := len(hello) / 2
+
+	for _, version := range []uint32{version1, versionDraft29, version2} {
+		first := sealInitial(version, dcid, 0, 0, hello[:half])
+		second := sealInitial(version, dcid, 1, half, hello[half:])
+
+		if _, err := SniffQUIC(first); err != common.ErrNoClue {
+			t.Error("expect ErrNoClue on partial ClientHello, but got ", err)
+		}
+		// The second packet may arrive first.
+		packets := append(append([]byte{}, second...), first...)
+		header, err := SniffQUIC(packets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Protocol() != "quic" || header.Domain() != "www.example.com" {
+			t.Error("unexpected sniff result: ", header.Protocol(), " ", header.Domain())
+		}
+
+		whole := sealInitial(version, dcid, 0, 0, hello)
+		if header, err := SniffQUIC(append(whole, make([]byte, 64)...)); err != nil || header.Domain() != "www.example.com" {
+			t.Error("failed to sniff ClientHello in a single packet: ", err)
+		}
+	}
+
+	invalid := sealInitial(version1, dcid, 0, 0, hello)
+	invalid[len(invalid)-1] ^= 0xff
+	if _, err := SniffQUIC(invalid); err == nil || err == common.ErrNoClue {
+		t.Error("expect error on corrupted packet, but got ", err)
+	}
+	if _, err := SniffQUIC(bytes.Repeat([]byte{0x16, 0x03, 0x01}, 100)); err == nil || err == common.ErrNoClue {
+		t.Error("expect error on non-QUIC data, but got ", err)
+	}
+}
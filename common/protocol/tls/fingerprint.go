@@ -0,0 +1,179 @@
+package tls
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Keys of content attributes set from a sniffed ClientHello.
+const (
+	AttributeALPN     = "tls.alpn"
+	AttributeVersions = "tls.versions"
+	AttributeJA3      = "tls.ja3"
+	AttributeJA4      = "tls.ja4"
+)
+
+func joinUint16(list []uint16, format func(uint16) string, sep string) string {
+	s := make([]string, len(list))
+	for i, v := range list {
+		s[i] = format(v)
+	}
+	return strings.Join(s, sep)
+}
+
+func decimal(v uint16) string {
+	return strconv.Itoa(int(v))
+}
+
+func hex4(v uint16) string {
+	return fmt.Sprintf("%04x", v)
+}
+
+// VersionName returns the name of a TLS version, like "tls1.3".
+func VersionName(v uint16) string {
+	switch v {
+	case 0x0300:
+		return "ssl3.0"
+	case 0x0301:
+		return "tls1.0"
+	case 0x0302:
+		return "tls1.1"
+	case 0x0303:
+		return "tls1.2"
+	case 0x0304:
+		return "tls1.3"
+	default:
+		return hex4(v)
+	}
+}
+
+// JA3 returns the JA3 fingerprint of the ClientHello, as MD5 in hex.
+// https://github.com/salesforce/ja3
+func (h *SniffHeader) JA3() string {
+	points := make([]string, len(h.pointFormats))
+	for i, p := range h.pointFormats {
+		points[i] = strconv.Itoa(int(p))
+	}
+	s := strings.Join([]string{
+		decimal(h.version),
+		joinUint16(h.cipherSuites, decimal, "-"),
+		joinUint16(h.extensions, decimal, "-"),
+		joinUint16(h.supportedGroups, decimal, "-"),
+		strings.Join(points, "-"),
+	}, ",")
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncatedHash(s string) string {
+	if s == "" {
+		return "000000000000"
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:6])
+}
+
+func isAlphanumeric(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// JA4 returns the JA4 fingerprint of the ClientHello. quic should be true if
+// the ClientHello is carried in QUIC.
+// https://github.com/FoxIO-LLC/ja4/blob/main/technical_details/JA4.md
+func (h *SniffHeader) JA4(quic bool) string {
+	var b strings.Builder
+	if quic {
+		b.WriteByte('q')
+	} else {
+		b.WriteByte('t')
+	}
+
+	var version uint16
+	for _, v := range h.SupportedVersions() {
+		if v > version {
+			version = v
+		}
+	}
+	switch version {
+	case 0x0304:
+		b.WriteString("13")
+	case 0x0303:
+		b.WriteString("12")
+	case 0x0302:
+		b.WriteString("11")
+	case 0x0301:
+		b.WriteString("10")
+	case 0x0300:
+		b.WriteString("s3")
+	default:
+		b.WriteString("00")
+	}
+
+	if h.domain != "" {
+		b.WriteByte('d')
+	} else {
+		b.WriteByte('i')
+	}
+
+	count := func(n int) string {
+		if n > 99 {
+			n = 99
+		}
+		return fmt.Sprintf("%02d", n)
+	}
+	b.WriteString(count(len(h.cipherSuites)))
+	b.WriteString(count(len(h.extensions)))
+
+	if len(h.alpn) == 0 {
+		b.WriteString("00")
+	} else {
+		alpn := h.alpn[0]
+		first, last := alpn[0], alpn[len(alpn)-1]
+		if isAlphanumeric(first) && isAlphanumeric(last) {
+			b.WriteByte(first)
+			b.WriteByte(last)
+		} else {
+			encoded := hex.EncodeToString([]byte(alpn))
+			b.WriteByte(encoded[0])
+			b.WriteByte(encoded[len(encoded)-1])
+		}
+	}
+
+	ciphers := append([]uint16(nil), h.cipherSuites...)
+	sort.Slice(ciphers, func(i, j int) bool { return ciphers[i] < ciphers[j] })
+
+	// Server name and ALPN extensions are excluded from the hash.
+	var extensions []uint16
+	for _, e := range h.extensions {
+		if e != 0x00 && e != 0x10 {
+			extensions = append(extensions, e)
+		}
+	}
+	sort.Slice(extensions, func(i, j int) bool { return extensions[i] < extensions[j] })
+	extensionString := joinUint16(extensions, hex4, ",")
+	if extensionString != "" && len(h.signatureSchemes) > 0 {
+		extensionString += "_" + joinUint16(h.signatureSchemes, hex4, ",")
+	}
+
+	b.WriteByte('_')
+	b.WriteString(truncatedHash(joinUint16(ciphers, hex4, ",")))
+	b.WriteByte('_')
+	b.WriteString(truncatedHash(extensionString))
+	return b.String()
+}
+
+// Attributes returns the ClientHello metadata as content attributes. ALPN and
+// versions are comma separated lists.
+func (h *SniffHeader) Attributes() map[string]string {
+	return map[string]string{
+		AttributeALPN:     strings.Join(h.alpn, ","),
+		AttributeVersions: joinUint16(h.SupportedVersions(), VersionName, ","),
+		AttributeJA3:      h.JA3(),
+		AttributeJA4:      h.JA4(false),
+	}
+}
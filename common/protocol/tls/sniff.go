@@ -10,6 +10,15 @@ import (
 
 type SniffHeader struct {
 	domain string
+
+	version           uint16
+	cipherSuites      []uint16
+	extensions        []uint16
+	supportedGroups   []uint16
+	pointFormats      []uint8
+	signatureSchemes  []uint16
+	supportedVersions []uint16
+	alpn              []string
 }
 
 func (h *SniffHeader) Protocol() string {
@@ -20,6 +29,21 @@ func (h *SniffHeader) Domain() string {
 	return h.domain
 }
 
+// ALPN returns the application protocols offered by the client.
+func (h *SniffHeader) ALPN() []string {
+	return h.alpn
+}
+
+// SupportedVersions returns the TLS versions offered by the client, GREASE
+// values excluded. The legacy version is returned if the client does not send
+// the supported_versions extension.
+func (h *SniffHeader) SupportedVersions() []uint16 {
+	if len(h.supportedVersions) == 0 {
+		return []uint16{h.version}
+	}
+	return h.supportedVersions
+}
+
 var errNotTLS = errors.New("not TLS header")
 var errNotClientHello = errors.New("not client hello")
 
@@ -27,12 +51,119 @@ func IsValidTLSVersion(major, minor byte) bool {
 	return major == 3
 }
 
+// isGREASE reports whether v is a reserved GREASE value, defined in RFC 8701.
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+func readUint16List(data []byte) ([]uint16, bool) {
+	if len(data)%2 != 0 {
+		return nil, false
+	}
+	list := make([]uint16, 0, len(data)/2)
+	for i := 0; i < len(data); i += 2 {
+		if v := binary.BigEndian.Uint16(data[i:]); !isGREASE(v) {
+			list = append(list, v)
+		}
+	}
+	return list, true
+}
+
+// readExtension parses the content of extensions used for fingerprinting.
+// Malformed extensions other than server name are ignored.
+func (h *SniffHeader) readExtension(extension uint16, data []byte) error {
+	switch extension {
+	case 0x00: /* extensionServerName */
+		d := data
+		if len(d) < 2 {
+			return errNotClientHello
+		}
+		namesLen := int(d[0])<<8 | int(d[1])
+		d = d[2:]
+		if len(d) != namesLen {
+			return errNotClientHello
+		}
+		for len(d) > 0 {
+			if len(d) < 3 {
+				return errNotClientHello
+			}
+			nameType := d[0]
+			nameLen := int(d[1])<<8 | int(d[2])
+			d = d[3:]
+			if len(d) < nameLen {
+				return errNotClientHello
+			}
+			if nameType == 0 {
+				serverName := string(d[:nameLen])
+				// An SNI value may not include a
+				// trailing dot. See
+				// https://tools.ietf.org/html/rfc6066#section-3.
+				if strings.HasSuffix(serverName, ".") {
+					return errNotClientHello
+				}
+				h.domain = serverName
+				return nil
+			}
+			d = d[nameLen:]
+		}
+	case 0x0a: /* extensionSupportedCurves */
+		if len(data) < 2 || len(data) != 2+int(binary.BigEndian.Uint16(data)) {
+			break
+		}
+		groups, ok := readUint16List(data[2:])
+		if !ok {
+			break
+		}
+		h.supportedGroups = groups
+	case 0x0b: /* extensionSupportedPoints */
+		if len(data) < 1 || len(data) != 1+int(data[0]) {
+			break
+		}
+		h.pointFormats = data[1:]
+	case 0x0d: /* extensionSignatureAlgorithms */
+		if len(data) < 2 || len(data) != 2+int(binary.BigEndian.Uint16(data)) {
+			break
+		}
+		schemes, ok := readUint16List(data[2:])
+		if !ok {
+			break
+		}
+		h.signatureSchemes = schemes
+	case 0x10: /* extensionALPN */
+		if len(data) < 2 || len(data) != 2+int(binary.BigEndian.Uint16(data)) {
+			break
+		}
+		var alpn []string
+		for d := data[2:]; len(d) > 0; {
+			protoLen := int(d[0])
+			if protoLen == 0 || len(d) < 1+protoLen {
+				return nil
+			}
+			alpn = append(alpn, string(d[1:1+protoLen]))
+			d = d[1+protoLen:]
+		}
+		h.alpn = alpn
+	case 0x2b: /* extensionSupportedVersions */
+		if len(data) < 1 || len(data) != 1+int(data[0]) {
+			break
+		}
+		versions, ok := readUint16List(data[1:])
+		if !ok {
+			break
+		}
+		h.supportedVersions = versions
+	}
+	return nil
+}
+
 // ReadClientHello returns server name (if any) from TLS client hello message.
+// Other fields of the message are kept in h for fingerprinting.
 // https://github.com/golang/go/blob/master/src/crypto/tls/handshake_messages.go#L300
 func ReadClientHello(data []byte, h *SniffHeader) error {
 	if len(data) < 42 {
 		return common.ErrNoClue
 	}
+	h.version = binary.BigEndian.Uint16(data[4:6])
 	sessionIDLen := int(data[38])
 	if sessionIDLen > 32 || len(data) < 39+sessionIDLen {
 		return common.ErrNoClue
@@ -47,6 +178,7 @@ func ReadClientHello(data []byte, h *SniffHeader) error {
 	if cipherSuiteLen%2 == 1 || len(data) < 2+cipherSuiteLen {
 		return errNotClientHello
 	}
+	h.cipherSuites, _ = readUint16List(data[2 : 2+cipherSuiteLen])
 	data = data[2+cipherSuiteLen:]
 	if len(data) < 1 {
 		return common.ErrNoClue
@@ -80,45 +212,19 @@ func ReadClientHello(data []byte, h *SniffHeader) error {
 		if len(data) < length {
 			return errNotClientHello
 		}
-
-		if extension == 0x00 { /* extensionServerName */
-			d := data[:length]
-			if len(d) < 2 {
-				return errNotClientHello
-			}
-			namesLen := int(d[0])<<8 | int(d[1])
-			d = d[2:]
-			if len(d) != namesLen {
-				return errNotClientHello
-			}
-			for len(d) > 0 {
-				if len(d) < 3 {
-					return errNotClientHello
-				}
-				nameType := d[0]
-				nameLen := int(d[1])<<8 | int(d[2])
-				d = d[3:]
-				if len(d) < nameLen {
-					return errNotClientHello
-				}
-				if nameType == 0 {
-					serverName := string(d[:nameLen])
-					// An SNI value may not include a
-					// trailing dot. See
-					// https://tools.ietf.org/html/rfc6066#section-3.
-					if strings.HasSuffix(serverName, ".") {
-						return errNotClientHello
-					}
-					h.domain = serverName
-					return nil
-				}
-				d = d[nameLen:]
-			}
+		if !isGREASE(extension) {
+			h.extensions = append(h.extensions, extension)
+		}
+		if err := h.readExtension(extension, data[:length]); err != nil {
+			return err
 		}
 		data = data[length:]
 	}
 
-	return errNotTLS
+	if h.domain == "" {
+		return errNotTLS
+	}
+	return nil
 }
 
 func SniffTLS(b []byte) (*SniffHeader, error) {
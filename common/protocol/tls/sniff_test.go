@@ -6,6 +6,36 @@ import (
 	. "github.com/xtls/xray-core/common/protocol/tls"
 )
 
+// clientHello is a ClientHello of Chrome with server name c.s-microsoft.com.
+var clientHello = []byte{
+	0x16, 0x03, 0x01, 0x00, 0xc8, 0x01, 0x00, 0x00,
+	0xc4, 0x03, 0x03, 0x1a, 0xac, 0xb2, 0xa8, 0xfe,
+	0xb4, 0x96, 0x04, 0x5b, 0xca, 0xf7, 0xc1, 0xf4,
+	0x2e, 0x53, 0x24, 0x6e, 0x34, 0x0c, 0x58, 0x36,
+	0x71, 0x97, 0x59, 0xe9, 0x41, 0x66, 0xe2, 0x43,
+	0xa0, 0x13, 0xb6, 0x00, 0x00, 0x20, 0x1a, 0x1a,
+	0xc0, 0x2b, 0xc0, 0x2f, 0xc0, 0x2c, 0xc0, 0x30,
+	0xcc, 0xa9, 0xcc, 0xa8, 0xcc, 0x14, 0xcc, 0x13,
+	0xc0, 0x13, 0xc0, 0x14, 0x00, 0x9c, 0x00, 0x9d,
+	0x00, 0x2f, 0x00, 0x35, 0x00, 0x0a, 0x01, 0x00,
+	0x00, 0x7b, 0xba, 0xba, 0x00, 0x00, 0xff, 0x01,
+	0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x16, 0x00,
+	0x14, 0x00, 0x00, 0x11, 0x63, 0x2e, 0x73, 0x2d,
+	0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x6f, 0x66,
+	0x74, 0x2e, 0x63, 0x6f, 0x6d, 0x00, 0x17, 0x00,
+	0x00, 0x00, 0x23, 0x00, 0x00, 0x00, 0x0d, 0x00,
+	0x14, 0x00, 0x12, 0x04, 0x03, 0x08, 0x04, 0x04,
+	0x01, 0x05, 0x03, 0x08, 0x05, 0x05, 0x01, 0x08,
+	0x06, 0x06, 0x01, 0x02, 0x01, 0x00, 0x05, 0x00,
+	0x05, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x12,
+	0x00, 0x00, 0x00, 0x10, 0x00, 0x0e, 0x00, 0x0c,
+	0x02, 0x68, 0x32, 0x08, 0x68, 0x74, 0x74, 0x70,
+	0x2f, 0x31, 0x2e, 0x31, 0x00, 0x0b, 0x00, 0x02,
+	0x01, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x08,
+	0xaa, 0xaa, 0x00, 0x1d, 0x00, 0x17, 0x00, 0x18,
+	0xaa, 0xaa, 0x00, 0x01, 0x00,
+}
+
 func TestTLSHeaders(t *testing.T) {
 	cases := []struct {
 		input  []byte
@@ -13,34 +43,7 @@ func TestTLSHeaders(t *testing.T) {
 		err    bool
 	}{
 		{
-			input: []byte{
-				0x16, 0x03, 0x01, 0x00, 0xc8, 0x01, 0x00, 0x00,
-				0xc4, 0x03, 0x03, 0x1a, 0xac, 0xb2, 0xa8, 0xfe,
-				0xb4, 0x96, 0x04, 0x5b, 0xca, 0xf7, 0xc1, 0xf4,
-				0x2e, 0x53, 0x24, 0x6e, 0x34, 0x0c, 0x58, 0x36,
-				0x71, 0x97, 0x59, 0xe9, 0x41, 0x66, 0xe2, 0x43,
-				0xa0, 0x13, 0xb6, 0x00, 0x00, 0x20, 0x1a, 0x1a,
-				0xc0, 0x2b, 0xc0, 0x2f, 0xc0, 0x2c, 0xc0, 0x30,
-				0xcc, 0xa9, 0xcc, 0xa8, 0xcc, 0x14, 0xcc, 0x13,
-				0xc0, 0x13, 0xc0, 0x14, 0x00, 0x9c, 0x00, 0x9d,
-				0x00, 0x2f, 0x00, 0x35, 0x00, 0x0a, 0x01, 0x00,
-				0x00, 0x7b, 0xba, 0xba, 0x00, 0x00, 0xff, 0x01,
-				0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x16, 0x00,
-				0x14, 0x00, 0x00, 0x11, 0x63, 0x2e, 0x73, 0x2d,
-				0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x6f, 0x66,
-				0x74, 0x2e, 0x63, 0x6f, 0x6d, 0x00, 0x17, 0x00,
-				0x00, 0x00, 0x23, 0x00, 0x00, 0x00, 0x0d, 0x00,
-				0x14, 0x00, 0x12, 0x04, 0x03, 0x08, 0x04, 0x04,
-				0x01, 0x05, 0x03, 0x08, 0x05, 0x05, 0x01, 0x08,
-				0x06, 0x06, 0x01, 0x02, 0x01, 0x00, 0x05, 0x00,
-				0x05, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x12,
-				0x00, 0x00, 0x00, 0x10, 0x00, 0x0e, 0x00, 0x0c,
-				0x02, 0x68, 0x32, 0x08, 0x68, 0x74, 0x74, 0x70,
-				0x2f, 0x31, 0x2e, 0x31, 0x00, 0x0b, 0x00, 0x02,
-				0x01, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x08,
-				0xaa, 0xaa, 0x00, 0x1d, 0x00, 0x17, 0x00, 0x18,
-				0xaa, 0xaa, 0x00, 0x01, 0x00,
-			},
+			input:  clientHello,
 			domain: "c.s-microsoft.com",
 			err:    false,
 		},
@@ -159,3 +162,26 @@ func TestTLSHeaders(t *testing.T) {
 		}
 	}
 }
+
+func TestClientHelloMetadata(t *testing.T) {
+	header, err := SniffTLS(clientHello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alpn := header.ALPN(); len(alpn) != 2 || alpn[0] != "h2" || alpn[1] != "http/1.1" {
+		t.Error("unexpected ALPN: ", alpn)
+	}
+
+	attrs := header.Attributes()
+	expected := map[string]string{
+		AttributeALPN:     "h2,http/1.1",
+		AttributeVersions: "tls1.2",
+		AttributeJA3:      "b8f81673c0e1d29908346f3bab892b9b",
+		AttributeJA4:      "t12d1510h2_f0daf39aad75_e69ac49eb88f",
+	}
+	for key, value := range expected {
+		if attrs[key] != value {
+			t.Error("expect ", key, " to be ", value, " but got ", attrs[key])
+		}
+	}
+}
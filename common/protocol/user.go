@@ -1,5 +1,7 @@
 package protocol
 
+import "time"
+
 func (u *User) GetTypedAccount() (Account, error) {
 	if u.GetAccount() == nil {
 		return nil, newError("Account missing").AtWarning()
@@ -23,11 +25,17 @@ func (u *User) ToMemoryUser() (*MemoryUser, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &MemoryUser{
+	user := &MemoryUser{
 		Account: account,
 		Email:   u.Email,
 		Level:   u.Level,
-	}, nil
+		Tags:    u.Tags,
+		Quota:   u.Quota,
+	}
+	if u.Expiry > 0 {
+		user.Expiry = time.Unix(u.Expiry, 0)
+	}
+	return user, nil
 }
 
 // MemoryUser is a parsed form of User, to reduce number of parsing of Account proto.
@@ -36,4 +44,14 @@ type MemoryUser struct {
 	Account Account
 	Email   string
 	Level   uint32
+	Tags    []string
+	// Quota is the traffic quota in bytes. 0 for unlimited.
+	Quota uint64
+	// Expiry is the time when the user expires. Zero for never.
+	Expiry time.Time
+}
+
+// Expired returns whether the user has expired at the given time.
+func (u *MemoryUser) Expired(now time.Time) bool {
+	return !u.Expiry.IsZero() && !now.Before(u.Expiry)
 }
@@ -54,6 +54,9 @@ type Inbound struct {
 type Outbound struct {
 	// Target address of the outbound connection.
 	Target net.Destination
+	// RouteTarget is used instead of Target for routing, if valid. It is set
+	// from sniffed results that are used for routing only.
+	RouteTarget net.Destination
 	// Gateway address
 	Gateway net.Address
 }
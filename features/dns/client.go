@@ -1,6 +1,10 @@
 package dns
 
 import (
+	"context"
+
+	"golang.org/x/net/dns/dnsmessage"
+
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/serial"
@@ -24,6 +28,17 @@ type Client interface {
 	LookupIP(domain string, option IPOption) ([]net.IP, error)
 }
 
+// MessageClient is an optional feature of Client for querying DNS records of
+// any type.
+//
+// xray:api:beta
+type MessageClient interface {
+	// LookupMessage answers the single question of a DNS query message. The
+	// answer has the same ID as the query. The source of the inbound in ctx, if
+	// any, is used for routing queries to name servers.
+	LookupMessage(ctx context.Context, query *dnsmessage.Message) (*dnsmessage.Message, error)
+}
+
 // ClientType returns the type of Client interface. Can be used for implementing common.HasType.
 //
 // xray:api:beta
@@ -34,6 +49,10 @@ func ClientType() interface{} {
 // ErrEmptyResponse indicates that DNS query succeeded but no answer was returned.
 var ErrEmptyResponse = errors.New("empty response")
 
+// ErrMessageNotSupported indicates that no name server is able to answer the
+// query message.
+var ErrMessageNotSupported = errors.New("message query not supported")
+
 type RCodeError uint16
 
 func (e RCodeError) Error() string {
@@ -0,0 +1,39 @@
+package dns
+
+import (
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/xtls/xray-core/common/net"
+)
+
+// EventChannel is the name of the stats channel where QueryEvents are
+// published.
+const EventChannel = "dns>>>events"
+
+// QueryEvent is a DNS query answered by a name server or from the cache.
+//
+// xray:api:beta
+type QueryEvent struct {
+	Time time.Time
+	// Client is the source of the DNS request, if known.
+	Client net.Destination
+	Domain string
+	Type   dnsmessage.Type
+	// Server is the name of the name server queried.
+	Server   string
+	Latency  time.Duration
+	RCode    dnsmessage.RCode
+	CacheHit bool
+	IPs      []net.IP
+	// Error is set if the name server failed to answer.
+	Error error
+}
+
+// UpstreamCounterName returns the name of a counter of queries sent to a name
+// server. The counter may be "success", "failure", or "latency", the sum of
+// latencies in milliseconds of successful queries.
+func UpstreamCounterName(server string, counter string) string {
+	return "dns>>>upstream>>>" + server + ">>>" + counter
+}
@@ -14,4 +14,14 @@ type FakeDNSEngine interface {
 	GetFakeIPRange() *gonet.IPNet
 }
 
+// FakeDNSEngineRev0 is a FakeDNSEngine with multiple IP pools, possibly of
+// different IP versions.
+type FakeDNSEngineRev0 interface {
+	FakeDNSEngine
+	// IsIPInIPPool returns true if ip is in any of the IP pools.
+	IsIPInIPPool(ip net.Address) bool
+	// GetFakeIPForDomain3 returns fake IPs of domain in pools of the enabled IP versions.
+	GetFakeIPForDomain3(domain string, IPv4, IPv6 bool) []net.Address
+}
+
 var FakeIPPool = "198.18.0.0/16"
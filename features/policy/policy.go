@@ -35,6 +35,27 @@ type Buffer struct {
 	PerConnection int32
 }
 
+// Rate contains limits of throughput, in bytes per second. Connections under
+// the same limit share its bandwidth.
+type Rate struct {
+	// Uplink rate. 0 for unlimited.
+	Uplink uint64
+	// Downlink rate. 0 for unlimited.
+	Downlink uint64
+	// Burst size in bytes. 0 for the rate of one second.
+	Burst uint64
+}
+
+// Limit contains limits of connections of a user.
+type Limit struct {
+	// Maximum number of concurrent connections. 0 for unlimited.
+	Connections uint32
+	// Maximum number of distinct source IPs. 0 for unlimited.
+	SourceIPs uint32
+	// How long a source IP is still counted after its last connection ends.
+	SourceIPWindow time.Duration
+}
+
 // SystemStats contains stat policy settings on system level.
 type SystemStats struct {
 	// Whether or not to enable stat counter for uplink traffic in inbound handlers.
@@ -51,6 +72,10 @@ type SystemStats struct {
 type System struct {
 	Stats  SystemStats
 	Buffer Buffer
+	// Aggregate rate limits of inbound handlers, by tag.
+	InboundRate map[string]Rate
+	// Aggregate rate limits of outbound handlers, by tag.
+	OutboundRate map[string]Rate
 }
 
 // Session is session based settings for controlling Xray requests. It contains various settings (or limits) that may differ for different users in the context.
@@ -58,6 +83,8 @@ type Session struct {
 	Timeouts Timeout // Timeout settings
 	Stats    Stats
 	Buffer   Buffer
+	Rate     Rate
+	Limit    Limit
 }
 
 // Manager is a feature that provides Policy for the given user by its id or level.
@@ -73,6 +100,25 @@ type Manager interface {
 	ForSystem() System
 }
 
+// UserManager is an optional interface of Manager for policies overridden per
+// user.
+//
+// xray:api:beta
+type UserManager interface {
+	// ForUser returns the Session policy for the user of the given level and
+	// email.
+	ForUser(level uint32, email string) Session
+}
+
+// ForUser returns the Session policy for the user from the Manager. The policy
+// of the user level is returned if the Manager has no user policies.
+func ForUser(m Manager, level uint32, email string) Session {
+	if um, ok := m.(UserManager); ok {
+		return um.ForUser(level, email)
+	}
+	return m.ForLevel(level)
+}
+
 // ManagerType returns the type of Manager interface. Can be used to implement common.HasType.
 //
 // xray:api:stable
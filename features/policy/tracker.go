@@ -0,0 +1,54 @@
+package policy
+
+import (
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+)
+
+// OnlineUser is a user with active connections.
+type OnlineUser struct {
+	Email       string
+	Connections uint32
+	// SourceIPs are the source IPs counted in the Limit of the user.
+	SourceIPs []net.IP
+}
+
+// UserTracker is an optional interface of Manager tracking connections of
+// users for enforcing their Limit.
+//
+// xray:api:beta
+type UserTracker interface {
+	// AcquireConnection registers a connection of the user from the source IP.
+	// An error is returned if the connection exceeds the Limit of the user.
+	// Otherwise release must be called when the connection ends.
+	AcquireConnection(user *protocol.MemoryUser, source net.Address) (release func(), err error)
+
+	// OnlineUsers returns the users with active connections.
+	OnlineUsers() []OnlineUser
+}
+
+// AcquireConnection registers a connection of the user with the Manager.
+// Connections are not limited if the Manager doesn't track users.
+func AcquireConnection(m Manager, user *protocol.MemoryUser, source net.Address) (release func(), err error) {
+	if t, ok := m.(UserTracker); ok && user != nil {
+		return t.AcquireConnection(user, source)
+	}
+	return func() {}, nil
+}
+
+// AcquireInboundConnection is AcquireConnection for a connection accepted by
+// an inbound. A rejected connection is recorded in the access log as from.
+func AcquireInboundConnection(m Manager, user *protocol.MemoryUser, source net.Address, from interface{}) (release func(), err error) {
+	release, err = AcquireConnection(m, user, source)
+	if err != nil {
+		log.Record(&log.AccessMessage{
+			From:   from,
+			To:     "",
+			Status: log.AccessRejected,
+			Reason: err,
+			Email:  user.Email,
+		})
+	}
+	return release, err
+}
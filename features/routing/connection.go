@@ -0,0 +1,56 @@
+package routing
+
+import (
+	"time"
+
+	"github.com/xtls/xray-core/common/net"
+)
+
+// ConnectionEventChannel is the name of the stats channel where
+// ConnectionEvents are published.
+const ConnectionEventChannel = "dispatcher>>>connections"
+
+// Connection is a connection being dispatched by the Dispatcher.
+//
+// xray:api:beta
+type Connection struct {
+	// ID is the session ID of the connection. Connections of a session, such
+	// as UDP packets to different destinations, share the ID.
+	ID          uint32
+	InboundTag  string
+	Email       string
+	Source      net.Destination
+	Destination net.Destination
+	// Domain is the domain sniffed from the content of the connection.
+	Domain      string
+	OutboundTag string
+	Uplink      int64
+	Downlink    int64
+	Start       time.Time
+}
+
+// ConnectionEvent is a connection opened or closed by the Dispatcher.
+//
+// xray:api:beta
+type ConnectionEvent struct {
+	Time   time.Time
+	Closed bool
+	// Reason is why the connection is closed, one of "eof", "timeout",
+	// "error" and "killed".
+	Reason     string
+	Connection Connection
+}
+
+// ConnectionManager is a Dispatcher able to list and close its connections.
+//
+// xray:api:beta
+type ConnectionManager interface {
+	// Connections returns connections being dispatched.
+	Connections() []Connection
+	// CloseConnection closes connections of the session ID, and returns the
+	// number of them.
+	CloseConnection(id uint32) int
+	// CloseConnectionsByUser closes connections of the user, and returns the
+	// number of them.
+	CloseConnectionsByUser(email string) int
+}
@@ -35,6 +35,13 @@ type Context interface {
 	// GetUser returns the user email from the connection content, if exists.
 	GetUser() string
 
+	// GetUserLevel returns the user level from the connection content, and
+	// whether the connection has a user.
+	GetUserLevel() (uint32, bool)
+
+	// GetUserTags returns the user tags from the connection content, if exists.
+	GetUserTags() []string
+
 	// GetAttributes returns extra attributes from the conneciont content.
 	GetAttributes() map[string]string
 }
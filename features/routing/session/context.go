@@ -44,14 +44,27 @@ func (ctx *Context) GetSourcePort() net.Port {
 	return ctx.Inbound.Source.Port
 }
 
+// target returns the destination for routing, which is the route target of
+// the outbound if set.
+func (ctx *Context) target() net.Destination {
+	if ctx.Outbound == nil {
+		return net.Destination{}
+	}
+	if ctx.Outbound.RouteTarget.IsValid() {
+		return ctx.Outbound.RouteTarget
+	}
+	return ctx.Outbound.Target
+}
+
 // GetTargetIPs implements routing.Context.
 func (ctx *Context) GetTargetIPs() []net.IP {
-	if ctx.Outbound == nil || !ctx.Outbound.Target.IsValid() {
+	dest := ctx.target()
+	if !dest.IsValid() {
 		return nil
 	}
 
-	if ctx.Outbound.Target.Address.Family().IsIP() {
-		return []net.IP{ctx.Outbound.Target.Address.IP()}
+	if dest.Address.Family().IsIP() {
+		return []net.IP{dest.Address.IP()}
 	}
 
 	return nil
@@ -59,18 +72,19 @@ func (ctx *Context) GetTargetIPs() []net.IP {
 
 // GetTargetPort implements routing.Context.
 func (ctx *Context) GetTargetPort() net.Port {
-	if ctx.Outbound == nil || !ctx.Outbound.Target.IsValid() {
+	dest := ctx.target()
+	if !dest.IsValid() {
 		return 0
 	}
-	return ctx.Outbound.Target.Port
+	return dest.Port
 }
 
 // GetTargetDomain implements routing.Context.
 func (ctx *Context) GetTargetDomain() string {
-	if ctx.Outbound == nil || !ctx.Outbound.Target.IsValid() {
+	dest := ctx.target()
+	if !dest.IsValid() {
 		return ""
 	}
-	dest := ctx.Outbound.Target
 	if !dest.Address.Family().IsDomain() {
 		return ""
 	}
@@ -101,6 +115,22 @@ func (ctx *Context) GetUser() string {
 	return ctx.Inbound.User.Email
 }
 
+// GetUserLevel implements routing.Context.
+func (ctx *Context) GetUserLevel() (uint32, bool) {
+	if ctx.Inbound == nil || ctx.Inbound.User == nil {
+		return 0, false
+	}
+	return ctx.Inbound.User.Level, true
+}
+
+// GetUserTags implements routing.Context.
+func (ctx *Context) GetUserTags() []string {
+	if ctx.Inbound == nil || ctx.Inbound.User == nil {
+		return nil
+	}
+	return ctx.Inbound.User.Tags
+}
+
 // GetAttributes implements routing.Context.
 func (ctx *Context) GetAttributes() map[string]string {
 	if ctx.Content == nil {
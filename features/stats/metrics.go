@@ -0,0 +1,70 @@
+package stats
+
+import (
+	"sort"
+	"sync"
+)
+
+// Metric is a sample of a metric not kept in a Counter.
+//
+// xray:api:beta
+type Metric struct {
+	// Name of the metric, such as "xray_dispatcher_links_total".
+	Name   string
+	Help   string
+	Labels map[string]string
+	Value  float64
+	// Gauge is true if the value may go down. Otherwise the metric is a counter.
+	Gauge bool
+}
+
+// MetricsProvider is an optional interface of features and transports for
+// exporting their metrics.
+//
+// xray:api:beta
+type MetricsProvider interface {
+	Metrics() []Metric
+}
+
+var (
+	providersAccess sync.RWMutex
+	providers       = make(map[string]MetricsProvider)
+)
+
+// RegisterMetricsProvider registers a provider of metrics not reachable through
+// features of the instance, such as those of transports. A provider of the same
+// name is replaced.
+func RegisterMetricsProvider(name string, provider MetricsProvider) {
+	providersAccess.Lock()
+	defer providersAccess.Unlock()
+	providers[name] = provider
+}
+
+// UnregisterMetricsProvider unregisters the provider of the name.
+func UnregisterMetricsProvider(name string) {
+	providersAccess.Lock()
+	defer providersAccess.Unlock()
+	delete(providers, name)
+}
+
+// VisitMetricsProviders calls visitor on each registered provider in order of
+// their names, until visitor returns false.
+func VisitMetricsProviders(visitor func(string, MetricsProvider) bool) {
+	providersAccess.RLock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	list := make(map[string]MetricsProvider, len(providers))
+	for name, p := range providers {
+		list[name] = p
+	}
+	providersAccess.RUnlock()
+
+	sort.Strings(names)
+	for _, name := range names {
+		if !visitor(name, list[name]) {
+			break
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"strings"
 
 	"github.com/xtls/xray-core/app/commander"
+	connectionservice "github.com/xtls/xray-core/app/dispatcher/command"
+	dnsservice "github.com/xtls/xray-core/app/dns/command"
 	loggerservice "github.com/xtls/xray-core/app/log/command"
 	handlerservice "github.com/xtls/xray-core/app/proxyman/command"
 	statsservice "github.com/xtls/xray-core/app/stats/command"
@@ -31,6 +33,10 @@ func (c *APIConfig) Build() (*commander.Config, error) {
 			services = append(services, serial.ToTypedMessage(&loggerservice.Config{}))
 		case "statsservice":
 			services = append(services, serial.ToTypedMessage(&statsservice.Config{}))
+		case "dnsservice":
+			services = append(services, serial.ToTypedMessage(&dnsservice.Config{}))
+		case "connectionservice":
+			services = append(services, serial.ToTypedMessage(&connectionservice.Config{}))
 		}
 	}
 
@@ -229,13 +229,15 @@ func (list *PortList) UnmarshalJSON(data []byte) error {
 }
 
 type User struct {
-	EmailString string `json:"email"`
-	LevelByte   byte   `json:"level"`
+	EmailString string   `json:"email"`
+	LevelByte   byte     `json:"level"`
+	Tags        []string `json:"tags"`
 }
 
 func (v *User) Build() *protocol.User {
 	return &protocol.User{
 		Email: v.EmailString,
 		Level: uint32(v.LevelByte),
+		Tags:  v.Tags,
 	}
 }
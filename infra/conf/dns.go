@@ -11,10 +11,14 @@ import (
 )
 
 type NameServerConfig struct {
-	Address   *Address
-	Port      uint16
-	Domains   []string
-	ExpectIPs StringList
+	Address       *Address
+	Port          uint16
+	Domains       []string
+	ExpectIPs     StringList
+	QueryStrategy string
+	ClientIP      *Address
+	Timeout       uint32
+	SkipFallback  bool
 }
 
 func (c *NameServerConfig) UnmarshalJSON(data []byte) error {
@@ -25,16 +29,24 @@ func (c *NameServerConfig) UnmarshalJSON(data []byte) error {
 	}
 
 	var advanced struct {
-		Address   *Address   `json:"address"`
-		Port      uint16     `json:"port"`
-		Domains   []string   `json:"domains"`
-		ExpectIPs StringList `json:"expectIps"`
+		Address       *Address   `json:"address"`
+		Port          uint16     `json:"port"`
+		Domains       []string   `json:"domains"`
+		ExpectIPs     StringList `json:"expectIps"`
+		QueryStrategy string     `json:"queryStrategy"`
+		ClientIP      *Address   `json:"clientIp"`
+		Timeout       uint32     `json:"timeout"`
+		SkipFallback  bool       `json:"skipFallback"`
 	}
 	if err := json.Unmarshal(data, &advanced); err == nil {
 		c.Address = advanced.Address
 		c.Port = advanced.Port
 		c.Domains = advanced.Domains
 		c.ExpectIPs = advanced.ExpectIPs
+		c.QueryStrategy = advanced.QueryStrategy
+		c.ClientIP = advanced.ClientIP
+		c.Timeout = advanced.Timeout
+		c.SkipFallback = advanced.SkipFallback
 		return nil
 	}
 
@@ -57,6 +69,10 @@ func toDomainMatchingType(t router.Domain_Type) dns.DomainMatchingType {
 }
 
 func (c *NameServerConfig) Build() (*dns.NameServer, error) {
+	return c.build(false)
+}
+
+func (c *NameServerConfig) build(reloadable bool) (*dns.NameServer, error) {
 	if c.Address == nil {
 		return nil, newError("NameServer address is not specified.")
 	}
@@ -64,7 +80,11 @@ func (c *NameServerConfig) Build() (*dns.NameServer, error) {
 	var domains []*dns.NameServer_PriorityDomain
 	var originalRules []*dns.NameServer_OriginalRule
 
-	for _, rule := range c.Domains {
+	domainRules, domainSources, err := splitDomainSources(c.Domains, reloadable)
+	if err != nil {
+		return nil, newError("invalid domain rule").Base(err)
+	}
+	for _, rule := range domainRules {
 		parsedDomain, err := parseDomainRule(rule)
 		if err != nil {
 			return nil, newError("invalid domain rule: ", rule).Base(err)
@@ -82,10 +102,30 @@ func (c *NameServerConfig) Build() (*dns.NameServer, error) {
 		})
 	}
 
-	geoipList, err := toCidrList(c.ExpectIPs)
+	expectIPs, geoipSources, err := splitIPSources(c.ExpectIPs, reloadable)
 	if err != nil {
 		return nil, newError("invalid IP rule: ", c.ExpectIPs).Base(err)
 	}
+	geoipList, err := toCidrList(expectIPs)
+	if err != nil {
+		return nil, newError("invalid IP rule: ", c.ExpectIPs).Base(err)
+	}
+
+	queryStrategy := dns.QueryStrategy_USE_IP
+	switch strings.ToLower(c.QueryStrategy) {
+	case "useip4", "useipv4", "use_ipv4", "use_ip_v4", "use_ip4":
+		queryStrategy = dns.QueryStrategy_USE_IP4
+	case "useip6", "useipv6", "use_ipv6", "use_ip_v6", "use_ip6":
+		queryStrategy = dns.QueryStrategy_USE_IP6
+	}
+
+	var clientIP []byte
+	if c.ClientIP != nil {
+		if !c.ClientIP.Family().IsIP() {
+			return nil, newError("not an IP address:", c.ClientIP.String())
+		}
+		clientIP = []byte(c.ClientIP.IP())
+	}
 
 	return &dns.NameServer{
 		Address: &net.Endpoint{
@@ -96,6 +136,12 @@ func (c *NameServerConfig) Build() (*dns.NameServer, error) {
 		PrioritizedDomain: domains,
 		Geoip:             geoipList,
 		OriginalRules:     originalRules,
+		DomainSource:      domainSources,
+		GeoipSource:       geoipSources,
+		QueryStrategy:     queryStrategy,
+		ClientIp:          clientIP,
+		Timeout:           c.Timeout,
+		SkipFallback:      c.SkipFallback,
 	}, nil
 }
 
@@ -108,10 +154,25 @@ var typeMap = map[router.Domain_Type]dns.DomainMatchingType{
 
 // DNSConfig is a JSON serializable object for dns.Config.
 type DNSConfig struct {
-	Servers  []*NameServerConfig `json:"servers"`
-	Hosts    map[string]*Address `json:"hosts"`
-	ClientIP *Address            `json:"clientIp"`
-	Tag      string              `json:"tag"`
+	Servers    []*NameServerConfig `json:"servers"`
+	Hosts      map[string]*Address `json:"hosts"`
+	HostsFiles []string            `json:"hostsFiles"`
+	ClientIP   *Address            `json:"clientIp"`
+	Tag        string              `json:"tag"`
+
+	ReloadInterval uint32 `json:"reloadInterval"`
+
+	DisableCache bool   `json:"disableCache"`
+	MinTTL       uint32 `json:"minTTL"`
+	MaxTTL       uint32 `json:"maxTTL"`
+	CacheSize    uint32 `json:"cacheSize"`
+	ServeStale   bool   `json:"serveStale"`
+	StaleTTL     uint32 `json:"staleTTL"`
+	Prefetch     bool   `json:"prefetch"`
+
+	DisableFallback  bool   `json:"disableFallback"`
+	ParallelQuery    bool   `json:"parallelQuery"`
+	PreferenceWindow uint32 `json:"preferenceWindow"`
 }
 
 func getHostMapping(addr *Address) *dns.Config_HostMapping {
@@ -128,8 +189,25 @@ func getHostMapping(addr *Address) *dns.Config_HostMapping {
 
 // Build implements Buildable
 func (c *DNSConfig) Build() (*dns.Config, error) {
+	if c.MaxTTL > 0 && c.MinTTL > c.MaxTTL {
+		return nil, newError("minTTL ", c.MinTTL, " is larger than maxTTL ", c.MaxTTL)
+	}
+
 	config := &dns.Config{
-		Tag: c.Tag,
+		Tag:            c.Tag,
+		HostsFiles:     c.HostsFiles,
+		ReloadInterval: c.ReloadInterval,
+		DisableCache:   c.DisableCache,
+		MinTtl:         c.MinTTL,
+		MaxTtl:         c.MaxTTL,
+		CacheSize:      c.CacheSize,
+		ServeStale:     c.ServeStale,
+		StaleTtl:       c.StaleTTL,
+		Prefetch:       c.Prefetch,
+
+		DisableFallback:  c.DisableFallback,
+		ParallelQuery:    c.ParallelQuery,
+		PreferenceWindow: c.PreferenceWindow,
 	}
 
 	if c.ClientIP != nil {
@@ -140,7 +218,7 @@ func (c *DNSConfig) Build() (*dns.Config, error) {
 	}
 
 	for _, server := range c.Servers {
-		ns, err := server.Build()
+		ns, err := server.build(c.ReloadInterval > 0)
 		if err != nil {
 			return nil, newError("failed to build nameserver").Base(err)
 		}
@@ -24,3 +24,21 @@ func (c *DNSOutboundConfig) Build() (proto.Message, error) {
 	}
 	return config, nil
 }
+
+type DNSInboundConfig struct {
+	NetworkList *NetworkList `json:"network"`
+	DoHPath     string       `json:"dohPath"`
+}
+
+func (c *DNSInboundConfig) Build() (proto.Message, error) {
+	config := &dns.ServerConfig{
+		DohPath: c.DoHPath,
+	}
+	if c.NetworkList != nil {
+		config.Networks = c.NetworkList.Build()
+	}
+	if config.DohPath != "" && config.DohPath[0] != '/' {
+		return nil, newError("invalid DoH path: ", config.DohPath)
+	}
+	return config, nil
+}
@@ -31,3 +31,28 @@ func TestDnsProxyConfig(t *testing.T) {
 		},
 	})
 }
+
+func TestDnsInboundConfig(t *testing.T) {
+	creator := func() Buildable {
+		return new(DNSInboundConfig)
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input:  `{}`,
+			Parser: loadJSON(creator),
+			Output: &dns.ServerConfig{},
+		},
+		{
+			Input: `{
+				"network": "tcp",
+				"dohPath": "/dns-query"
+			}`,
+			Parser: loadJSON(creator),
+			Output: &dns.ServerConfig{
+				Networks: []net.Network{net.Network_TCP},
+				DohPath:  "/dns-query",
+			},
+		},
+	})
+}
@@ -23,12 +23,14 @@ func init() {
 	if _, err := os.Stat(platform.GetAssetLocation("geoip.dat")); err != nil && os.IsNotExist(err) {
 		common.Must(filesystem.CopyFile(platform.GetAssetLocation("geoip.dat"), filepath.Join(wd, "..", "..", "resources", "geoip.dat")))
 	}
+}
 
-	geositeFilePath := filepath.Join(wd, "geosite.dat")
-	os.Setenv("xray.location.asset", wd)
-	geositeFile, err := os.OpenFile(geositeFilePath, os.O_CREATE|os.O_WRONLY, 0600)
-	common.Must(err)
-	defer geositeFile.Close()
+func writeGeositeFile(t *testing.T) {
+	dir := t.TempDir()
+	os.Setenv("xray.location.asset", dir)
+	t.Cleanup(func() {
+		os.Unsetenv("xray.location.asset")
+	})
 
 	list := &router.GeoSiteList{
 		Entry: []*router.GeoSite{
@@ -43,14 +45,11 @@ func init() {
 
 	listBytes, err := proto.Marshal(list)
 	common.Must(err)
-	common.Must2(geositeFile.Write(listBytes))
+	common.Must(os.WriteFile(filepath.Join(dir, "geosite.dat"), listBytes, 0600))
 }
+
 func TestDNSConfigParsing(t *testing.T) {
-	geositePath := platform.GetAssetLocation("geosite.dat")
-	defer func() {
-		os.Remove(geositePath)
-		os.Unsetenv("xray.location.asset")
-	}()
+	writeGeositeFile(t)
 
 	parserCreator := func() func(string) (proto.Message, error) {
 		return func(s string) (proto.Message, error) {
@@ -136,5 +135,67 @@ func TestDNSConfigParsing(t *testing.T) {
 				ClientIp: []byte{10, 0, 0, 1},
 			},
 		},
+		{
+			Input: `{
+				"servers": ["8.8.8.8", {
+					"address": "1.1.1.1",
+					"queryStrategy": "UseIPv6",
+					"clientIp": "10.0.0.2",
+					"timeout": 1500,
+					"skipFallback": true
+				}],
+				"disableFallback": true,
+				"parallelQuery": true,
+				"preferenceWindow": 50,
+				"minTTL": 60,
+				"maxTTL": 3600,
+				"cacheSize": 4096,
+				"serveStale": true,
+				"staleTTL": 7200,
+				"prefetch": true,
+				"hostsFiles": ["/etc/hosts", "hosts.txt"],
+				"reloadInterval": 30
+			}`,
+			Parser: parserCreator(),
+			Output: &dns.Config{
+				NameServer: []*dns.NameServer{
+					{
+						Address: &net.Endpoint{
+							Address: &net.IPOrDomain{
+								Address: &net.IPOrDomain_Ip{
+									Ip: []byte{8, 8, 8, 8},
+								},
+							},
+							Network: net.Network_UDP,
+						},
+					},
+					{
+						Address: &net.Endpoint{
+							Address: &net.IPOrDomain{
+								Address: &net.IPOrDomain_Ip{
+									Ip: []byte{1, 1, 1, 1},
+								},
+							},
+							Network: net.Network_UDP,
+						},
+						QueryStrategy: dns.QueryStrategy_USE_IP6,
+						ClientIp:      []byte{10, 0, 0, 2},
+						Timeout:       1500,
+						SkipFallback:  true,
+					},
+				},
+				DisableFallback:  true,
+				ParallelQuery:    true,
+				PreferenceWindow: 50,
+				MinTtl:           60,
+				MaxTtl:           3600,
+				CacheSize:        4096,
+				ServeStale:       true,
+				StaleTtl:         7200,
+				Prefetch:         true,
+				HostsFiles:       []string{"/etc/hosts", "hosts.txt"},
+				ReloadInterval:   30,
+			},
+		},
 	})
 }
@@ -1,21 +1,72 @@
 package conf
 
 import (
+	"encoding/json"
+
 	"github.com/golang/protobuf/proto"
 	"github.com/xtls/xray-core/app/dns/fakedns"
 	"github.com/xtls/xray-core/features/dns"
 )
 
-type FakeDNSConfig struct {
+type FakeDNSPoolElementConfig struct {
 	IPPool  string `json:"ipPool"`
 	LruSize int64  `json:"poolSize"`
 }
 
+type FakeDNSConfig struct {
+	IPPool          string                      `json:"ipPool"`
+	LruSize         int64                       `json:"poolSize"`
+	Pools           []*FakeDNSPoolElementConfig `json:"pools"`
+	PersistPath     string                      `json:"persistPath"`
+	PersistInterval uint32                      `json:"persistInterval"`
+}
+
+// UnmarshalJSON implements encoding/json.Unmarshaler.UnmarshalJSON. A list of
+// pools is accepted in place of the object.
+func (f *FakeDNSConfig) UnmarshalJSON(data []byte) error {
+	var pools []*FakeDNSPoolElementConfig
+	if err := json.Unmarshal(data, &pools); err == nil {
+		*f = FakeDNSConfig{Pools: pools}
+		return nil
+	}
+
+	type fakeDNSConfig FakeDNSConfig
+	var config fakeDNSConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return newError("invalid fakedns config").Base(err)
+	}
+	*f = FakeDNSConfig(config)
+	return nil
+}
+
 func (f FakeDNSConfig) Build() (proto.Message, error) {
-	return &fakedns.FakeDnsPool{
-		IpPool:  f.IPPool,
-		LruSize: f.LruSize,
-	}, nil
+	if len(f.Pools) == 0 && f.PersistPath == "" {
+		return &fakedns.FakeDnsPool{
+			IpPool:  f.IPPool,
+			LruSize: f.LruSize,
+		}, nil
+	}
+
+	config := &fakedns.FakeDnsPoolMulti{
+		PersistPath:     f.PersistPath,
+		PersistInterval: f.PersistInterval,
+	}
+	if f.IPPool != "" {
+		config.Pools = append(config.Pools, &fakedns.FakeDnsPool{
+			IpPool:  f.IPPool,
+			LruSize: f.LruSize,
+		})
+	}
+	for _, pool := range f.Pools {
+		config.Pools = append(config.Pools, &fakedns.FakeDnsPool{
+			IpPool:  pool.IPPool,
+			LruSize: pool.LruSize,
+		})
+	}
+	if len(config.Pools) == 0 {
+		return nil, newError("no fakedns pool specified")
+	}
+	return config, nil
 }
 
 type FakeDNSPostProcessingStage struct{}
@@ -0,0 +1,62 @@
+package conf_test
+
+import (
+	"testing"
+
+	"github.com/xtls/xray-core/app/dns/fakedns"
+	"github.com/xtls/xray-core/infra/conf"
+)
+
+func TestFakeDNSConfig(t *testing.T) {
+	creator := func() conf.Buildable {
+		return new(conf.FakeDNSConfig)
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"ipPool": "198.18.0.0/15",
+				"poolSize": 65535
+			}`,
+			Parser: loadJSON(creator),
+			Output: &fakedns.FakeDnsPool{
+				IpPool:  "198.18.0.0/15",
+				LruSize: 65535,
+			},
+		},
+		{
+			Input: `[{
+				"ipPool": "198.18.0.0/15",
+				"poolSize": 65535
+			}, {
+				"ipPool": "fc00::/18",
+				"poolSize": 65535
+			}]`,
+			Parser: loadJSON(creator),
+			Output: &fakedns.FakeDnsPoolMulti{
+				Pools: []*fakedns.FakeDnsPool{
+					{IpPool: "198.18.0.0/15", LruSize: 65535},
+					{IpPool: "fc00::/18", LruSize: 65535},
+				},
+			},
+		},
+		{
+			Input: `{
+				"pools": [{
+					"ipPool": "fc00::/18",
+					"poolSize": 1000
+				}],
+				"persistPath": "fakedns.json",
+				"persistInterval": 60
+			}`,
+			Parser: loadJSON(creator),
+			Output: &fakedns.FakeDnsPoolMulti{
+				Pools: []*fakedns.FakeDnsPool{
+					{IpPool: "fc00::/18", LruSize: 1000},
+				},
+				PersistPath:     "fakedns.json",
+				PersistInterval: 60,
+			},
+		},
+	})
+}
@@ -15,34 +15,120 @@ func DefaultLogConfig() *log.Config {
 	}
 }
 
+type LogRotateConfig struct {
+	MaxSize    uint32 `json:"maxSize"`    // in MB
+	Interval   uint32 `json:"interval"`   // in hours
+	MaxBackups uint32 `json:"maxBackups"` // number of rotated files
+	MaxAge     uint32 `json:"maxAge"`     // in days
+	Compress   bool   `json:"compress"`
+}
+
+func (c *LogRotateConfig) Build() *log.RotateConfig {
+	return &log.RotateConfig{
+		MaxSize:    uint64(c.MaxSize) * 1024 * 1024,
+		Interval:   c.Interval * 3600,
+		MaxBackups: c.MaxBackups,
+		MaxAge:     c.MaxAge * 86400,
+		Compress:   c.Compress,
+	}
+}
+
+var syslogFacilities = map[string]log.SyslogConfig_Facility{
+	"kern":   log.SyslogConfig_Kern,
+	"user":   log.SyslogConfig_User,
+	"mail":   log.SyslogConfig_Mail,
+	"daemon": log.SyslogConfig_Daemon,
+	"auth":   log.SyslogConfig_Auth,
+	"syslog": log.SyslogConfig_Syslog,
+	"local0": log.SyslogConfig_Local0,
+	"local1": log.SyslogConfig_Local1,
+	"local2": log.SyslogConfig_Local2,
+	"local3": log.SyslogConfig_Local3,
+	"local4": log.SyslogConfig_Local4,
+	"local5": log.SyslogConfig_Local5,
+	"local6": log.SyslogConfig_Local6,
+	"local7": log.SyslogConfig_Local7,
+}
+
+type SyslogConfig struct {
+	Network  string `json:"network"`
+	Address  string `json:"address"`
+	Tag      string `json:"tag"`
+	Facility string `json:"facility"`
+}
+
+func (c *SyslogConfig) Build() (*log.SyslogConfig, error) {
+	config := &log.SyslogConfig{
+		Network: c.Network,
+		Address: c.Address,
+		Tag:     c.Tag,
+	}
+	if c.Facility != "" {
+		facility, found := syslogFacilities[strings.ToLower(c.Facility)]
+		if !found {
+			return nil, newError("unknown syslog facility: ", c.Facility)
+		}
+		config.Facility = facility
+	}
+	return config, nil
+}
+
 type LogConfig struct {
-	AccessLog string `json:"access"`
-	ErrorLog  string `json:"error"`
-	LogLevel  string `json:"loglevel"`
-	DNSLog    bool   `json:"dnsLog"`
+	AccessLog string           `json:"access"`
+	ErrorLog  string           `json:"error"`
+	LogLevel  string           `json:"loglevel"`
+	DNSLog    bool             `json:"dnsLog"`
+	Format    string           `json:"format"`
+	Rotate    *LogRotateConfig `json:"rotate"`
+	Syslog    *SyslogConfig    `json:"syslog"`
 }
 
-func (v *LogConfig) Build() *log.Config {
+// logType returns the type of the access or error log. Paths other than
+// "none", "syslog" and "journald" are files.
+func logType(path string) log.LogType {
+	switch path {
+	case "":
+		return log.LogType_Console
+	case "none":
+		return log.LogType_None
+	case "syslog":
+		return log.LogType_Syslog
+	case "journald":
+		return log.LogType_Journald
+	default:
+		return log.LogType_File
+	}
+}
+
+func (v *LogConfig) Build() (*log.Config, error) {
 	if v == nil {
-		return nil
+		return nil, nil
 	}
 	config := &log.Config{
-		ErrorLogType:  log.LogType_Console,
-		AccessLogType: log.LogType_Console,
-		EnableDnsLog:  v.DNSLog,
+		EnableDnsLog: v.DNSLog,
 	}
 
-	if v.AccessLog == "none" {
-		config.AccessLogType = log.LogType_None
-	} else if len(v.AccessLog) > 0 {
+	config.AccessLogType = logType(v.AccessLog)
+	if config.AccessLogType == log.LogType_File {
 		config.AccessLogPath = v.AccessLog
-		config.AccessLogType = log.LogType_File
 	}
-	if v.ErrorLog == "none" {
-		config.ErrorLogType = log.LogType_None
-	} else if len(v.ErrorLog) > 0 {
+	config.ErrorLogType = logType(v.ErrorLog)
+	if config.ErrorLogType == log.LogType_File {
 		config.ErrorLogPath = v.ErrorLog
-		config.ErrorLogType = log.LogType_File
+	}
+	if v.Rotate != nil {
+		config.Rotate = v.Rotate.Build()
+	}
+	if v.Syslog != nil {
+		syslog, err := v.Syslog.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.Syslog = syslog
+	}
+
+	if strings.ToLower(v.Format) == "json" {
+		config.Format = log.LogFormat_JSON
 	}
 
 	level := strings.ToLower(v.LogLevel)
@@ -59,5 +145,5 @@ func (v *LogConfig) Build() *log.Config {
 	default:
 		config.ErrorLogLevel = clog.Severity_Warning
 	}
-	return config
+	return config, nil
 }
@@ -0,0 +1,34 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/xtls/xray-core/app/log"
+	"github.com/xtls/xray-core/common"
+	. "github.com/xtls/xray-core/infra/conf"
+)
+
+func TestSyslogFacility(t *testing.T) {
+	build := func(s string) (*log.SyslogConfig, error) {
+		config := new(SyslogConfig)
+		common.Must(json.Unmarshal([]byte(s), config))
+		return config.Build()
+	}
+
+	for input, expected := range map[string]log.SyslogConfig_Facility{
+		`{}`:                     log.SyslogConfig_Unset,
+		`{"facility": "kern"}`:   log.SyslogConfig_Kern,
+		`{"facility": "Local7"}`: log.SyslogConfig_Local7,
+	} {
+		config, err := build(input)
+		common.Must(err)
+		if config.Facility != expected {
+			t.Error(input, ": expect facility ", expected, ", but got ", config.Facility)
+		}
+	}
+
+	if _, err := build(`{"facility": "kernel"}`); err == nil {
+		t.Error("expect error for unknown facility")
+	}
+}
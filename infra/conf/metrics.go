@@ -0,0 +1,26 @@
+package conf
+
+import (
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/xtls/xray-core/app/metrics"
+)
+
+type MetricsConfig struct {
+	Listen string `json:"listen"`
+	Path   string `json:"path"`
+}
+
+func (c *MetricsConfig) Build() (proto.Message, error) {
+	if c.Listen == "" {
+		return nil, newError("metrics listen address is not specified")
+	}
+	if c.Path != "" && !strings.HasPrefix(c.Path, "/") {
+		return nil, newError("metrics path must start with '/': ", c.Path)
+	}
+	return &metrics.Config{
+		Listen: c.Listen,
+		Path:   c.Path,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package conf_test
+
+import (
+	"testing"
+
+	"github.com/xtls/xray-core/app/metrics"
+	"github.com/xtls/xray-core/infra/conf"
+)
+
+func TestMetricsConfig(t *testing.T) {
+	creator := func() conf.Buildable {
+		return new(conf.MetricsConfig)
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"listen": "127.0.0.1:9100"
+			}`,
+			Parser: loadJSON(creator),
+			Output: &metrics.Config{
+				Listen: "127.0.0.1:9100",
+			},
+		},
+		{
+			Input: `{
+				"listen": "127.0.0.1:9100",
+				"path": "/xray/metrics"
+			}`,
+			Parser: loadJSON(creator),
+			Output: &metrics.Config{
+				Listen: "127.0.0.1:9100",
+				Path:   "/xray/metrics",
+			},
+		},
+	})
+}
@@ -12,6 +12,12 @@ type Policy struct {
 	StatsUserUplink   bool    `json:"statsUserUplink"`
 	StatsUserDownlink bool    `json:"statsUserDownlink"`
 	BufferSize        *int32  `json:"bufferSize"`
+	UplinkRate        *uint64 `json:"uplinkRate"`
+	DownlinkRate      *uint64 `json:"downlinkRate"`
+	RateBurst         *uint64 `json:"rateBurst"`
+	MaxConnections    *uint32 `json:"maxConnections"`
+	MaxSourceIPs      *uint32 `json:"maxSourceIPs"`
+	SourceIPWindow    *uint32 `json:"sourceIPWindow"`
 }
 
 func (t *Policy) Build() (*policy.Policy, error) {
@@ -47,14 +53,72 @@ func (t *Policy) Build() (*policy.Policy, error) {
 		}
 	}
 
+	if t.UplinkRate != nil || t.DownlinkRate != nil || t.RateBurst != nil {
+		p.Rate = (&RateConfig{
+			Uplink:   t.UplinkRate,
+			Downlink: t.DownlinkRate,
+			Burst:    t.RateBurst,
+		}).Build()
+	}
+
+	if t.MaxConnections != nil || t.MaxSourceIPs != nil || t.SourceIPWindow != nil {
+		p.Limit = new(policy.Policy_Limit)
+		if t.MaxConnections != nil {
+			p.Limit.Connections = *t.MaxConnections
+		}
+		if t.MaxSourceIPs != nil {
+			p.Limit.SourceIps = *t.MaxSourceIPs
+		}
+		if t.SourceIPWindow != nil {
+			p.Limit.SourceIpWindow = *t.SourceIPWindow
+		}
+	}
+
 	return p, nil
 }
 
+// RateConfig is a rate limit in KB per second, with burst in KB.
+type RateConfig struct {
+	Uplink   *uint64 `json:"uplink"`
+	Downlink *uint64 `json:"downlink"`
+	Burst    *uint64 `json:"burst"`
+}
+
+func (c *RateConfig) Build() *policy.Rate {
+	r := new(policy.Rate)
+	if c.Uplink != nil {
+		r.Uplink = *c.Uplink * 1024
+	}
+	if c.Downlink != nil {
+		r.Downlink = *c.Downlink * 1024
+	}
+	if c.Burst != nil {
+		r.Burst = *c.Burst * 1024
+	}
+	return r
+}
+
+func buildRates(rates map[string]*RateConfig) map[string]*policy.Rate {
+	if len(rates) == 0 {
+		return nil
+	}
+	m := make(map[string]*policy.Rate, len(rates))
+	for tag, r := range rates {
+		if r != nil {
+			m[tag] = r.Build()
+		}
+	}
+	return m
+}
+
 type SystemPolicy struct {
 	StatsInboundUplink    bool `json:"statsInboundUplink"`
 	StatsInboundDownlink  bool `json:"statsInboundDownlink"`
 	StatsOutboundUplink   bool `json:"statsOutboundUplink"`
 	StatsOutboundDownlink bool `json:"statsOutboundDownlink"`
+
+	InboundRates  map[string]*RateConfig `json:"inboundRates"`
+	OutboundRates map[string]*RateConfig `json:"outboundRates"`
 }
 
 func (p *SystemPolicy) Build() (*policy.SystemPolicy, error) {
@@ -65,11 +129,14 @@ func (p *SystemPolicy) Build() (*policy.SystemPolicy, error) {
 			OutboundUplink:   p.StatsOutboundUplink,
 			OutboundDownlink: p.StatsOutboundDownlink,
 		},
+		InboundRate:  buildRates(p.InboundRates),
+		OutboundRate: buildRates(p.OutboundRates),
 	}, nil
 }
 
 type PolicyConfig struct {
 	Levels map[uint32]*Policy `json:"levels"`
+	Users  map[string]*Policy `json:"users"`
 	System *SystemPolicy      `json:"system"`
 }
 
@@ -88,6 +155,20 @@ func (c *PolicyConfig) Build() (*policy.Config, error) {
 		Level: levels,
 	}
 
+	for email, p := range c.Users {
+		if p == nil {
+			continue
+		}
+		pp, err := p.Build()
+		if err != nil {
+			return nil, err
+		}
+		if config.User == nil {
+			config.User = make(map[string]*policy.Policy)
+		}
+		config.User[email] = pp
+	}
+
 	if c.System != nil {
 		sc, err := c.System.Build()
 		if err != nil {
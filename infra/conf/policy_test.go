@@ -1,8 +1,12 @@
 package conf_test
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
+
+	"github.com/xtls/xray-core/app/policy"
 	"github.com/xtls/xray-core/common"
 	. "github.com/xtls/xray-core/infra/conf"
 )
@@ -38,3 +42,74 @@ func TestBufferSize(t *testing.T) {
 		}
 	}
 }
+
+func TestPolicyRate(t *testing.T) {
+	var config PolicyConfig
+	common.Must(json.Unmarshal([]byte(`{
+		"levels": {
+			"0": {"uplinkRate": 1024, "downlinkRate": 2048}
+		},
+		"users": {
+			"test@example.com": {"downlinkRate": 512, "rateBurst": 64}
+		},
+		"system": {
+			"inboundRates": {"in": {"uplink": 100}},
+			"outboundRates": {"out": {"downlink": 200, "burst": 10}}
+		}
+	}`), &config))
+	pc, err := config.Build()
+	common.Must(err)
+
+	expected := &policy.Config{
+		Level: map[uint32]*policy.Policy{
+			0: {
+				Timeout: &policy.Policy_Timeout{},
+				Stats:   &policy.Policy_Stats{},
+				Rate:    &policy.Rate{Uplink: 1024 * 1024, Downlink: 2048 * 1024},
+			},
+		},
+		User: map[string]*policy.Policy{
+			"test@example.com": {
+				Timeout: &policy.Policy_Timeout{},
+				Stats:   &policy.Policy_Stats{},
+				Rate:    &policy.Rate{Downlink: 512 * 1024, Burst: 64 * 1024},
+			},
+		},
+		System: &policy.SystemPolicy{
+			Stats: &policy.SystemPolicy_Stats{},
+			InboundRate: map[string]*policy.Rate{
+				"in": {Uplink: 100 * 1024},
+			},
+			OutboundRate: map[string]*policy.Rate{
+				"out": {Downlink: 200 * 1024, Burst: 10 * 1024},
+			},
+		},
+	}
+	if !proto.Equal(pc, expected) {
+		t.Error("unexpected policy config: ", pc)
+	}
+}
+
+func TestPolicyLimit(t *testing.T) {
+	var config PolicyConfig
+	common.Must(json.Unmarshal([]byte(`{
+		"users": {
+			"test@example.com": {"maxConnections": 8, "maxSourceIPs": 2, "sourceIPWindow": 300}
+		}
+	}`), &config))
+	pc, err := config.Build()
+	common.Must(err)
+
+	expected := &policy.Config{
+		User: map[string]*policy.Policy{
+			"test@example.com": {
+				Timeout: &policy.Policy_Timeout{},
+				Stats:   &policy.Policy_Stats{},
+				Limit:   &policy.Policy_Limit{Connections: 8, SourceIps: 2, SourceIpWindow: 300},
+			},
+		},
+	}
+	if !proto.Equal(pc, expected) {
+		t.Error("unexpected policy config: ", pc)
+	}
+}
@@ -0,0 +1,19 @@
+package conf
+
+import (
+	"github.com/xtls/xray-core/app/proxyman"
+)
+
+type QuotaConfig struct {
+	UsageFile        string `json:"usageFile"`
+	SaveInterval     uint32 `json:"saveInterval"`
+	CloseConnections bool   `json:"closeConnections"`
+}
+
+func (c *QuotaConfig) Build() *proxyman.QuotaConfig {
+	return &proxyman.QuotaConfig{
+		UsageFile:        c.UsageFile,
+		SaveInterval:     c.SaveInterval,
+		CloseConnections: c.CloseConnections,
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 
@@ -42,6 +43,7 @@ type RouterConfig struct {
 	RuleList       []json.RawMessage  `json:"rules"`
 	DomainStrategy *string            `json:"domainStrategy"`
 	Balancers      []*BalancingRule   `json:"balancers"`
+	ReloadInterval uint32             `json:"reloadInterval"`
 }
 
 func (c *RouterConfig) getDomainStrategy() router.Config_DomainStrategy {
@@ -67,6 +69,7 @@ func (c *RouterConfig) getDomainStrategy() router.Config_DomainStrategy {
 func (c *RouterConfig) Build() (*router.Config, error) {
 	config := new(router.Config)
 	config.DomainStrategy = c.getDomainStrategy()
+	config.ReloadInterval = c.ReloadInterval
 
 	var rawRuleList []json.RawMessage
 	if c != nil {
@@ -78,7 +81,7 @@ func (c *RouterConfig) Build() (*router.Config, error) {
 	}
 
 	for _, rawRule := range rawRuleList {
-		rule, err := ParseRule(rawRule)
+		rule, err := parseRule(rawRule, c.ReloadInterval > 0)
 		if err != nil {
 			return nil, err
 		}
@@ -344,6 +347,13 @@ func parseDomainRule(domain string) ([]*router.Domain, error) {
 	}
 	if isExtDatFile != 0 {
 		kv := strings.Split(domain[isExtDatFile:], ":")
+		if len(kv) == 1 {
+			domains, err := (&router.RuleSource{File: kv[0]}).LoadDomains()
+			if err != nil {
+				return nil, newError("failed to load external domain list: ", kv[0]).Base(err)
+			}
+			return domains, nil
+		}
 		if len(kv) != 2 {
 			return nil, newError("invalid external resource: ", domain)
 		}
@@ -392,6 +402,92 @@ func parseDomainRule(domain string) ([]*router.Domain, error) {
 	return []*router.Domain{domainRule}, nil
 }
 
+// parseRuleSource parses an external resource in a domain or IP rule into a
+// reloadable source. It returns nil if the rule is not an external resource.
+func parseRuleSource(rule string, geoFile string, prefixes ...string) (*router.RuleSource, error) {
+	geoPrefix := strings.TrimSuffix(geoFile, ".dat") + ":"
+	if strings.HasPrefix(rule, geoPrefix) {
+		parts := strings.Split(rule[len(geoPrefix):], "@")
+		return &router.RuleSource{
+			File:      geoFile,
+			Code:      strings.ToUpper(parts[0]),
+			Attribute: parts[1:],
+		}, nil
+	}
+	for _, prefix := range prefixes {
+		if !strings.HasPrefix(rule, prefix) {
+			continue
+		}
+		kv := strings.Split(rule[len(prefix):], ":")
+		switch len(kv) {
+		case 1:
+			return &router.RuleSource{File: kv[0]}, nil
+		case 2:
+			parts := strings.Split(kv[1], "@")
+			return &router.RuleSource{
+				File:      kv[0],
+				Code:      strings.ToUpper(parts[0]),
+				Attribute: parts[1:],
+			}, nil
+		default:
+			return nil, newError("invalid external resource: ", rule)
+		}
+	}
+	return nil, nil
+}
+
+func parseDomainSource(domain string) (*router.RuleSource, error) {
+	return parseRuleSource(domain, "geosite.dat", "ext:", "ext-domain:")
+}
+
+func parseIPSource(ip string) (*router.RuleSource, error) {
+	return parseRuleSource(ip, "geoip.dat", "ext:", "ext-ip:")
+}
+
+// splitDomainSources separates external resources from other domain rules if
+// reloading is enabled.
+func splitDomainSources(domains []string, reloadable bool) ([]string, []*router.RuleSource, error) {
+	if !reloadable {
+		return domains, nil, nil
+	}
+	var rest []string
+	var sources []*router.RuleSource
+	for _, domain := range domains {
+		source, err := parseDomainSource(domain)
+		if err != nil {
+			return nil, nil, err
+		}
+		if source != nil {
+			sources = append(sources, source)
+		} else {
+			rest = append(rest, domain)
+		}
+	}
+	return rest, sources, nil
+}
+
+// splitIPSources separates external resources from other IP rules if
+// reloading is enabled.
+func splitIPSources(ips StringList, reloadable bool) (StringList, []*router.RuleSource, error) {
+	if !reloadable {
+		return ips, nil, nil
+	}
+	var rest StringList
+	var sources []*router.RuleSource
+	for _, ip := range ips {
+		source, err := parseIPSource(ip)
+		if err != nil {
+			return nil, nil, err
+		}
+		if source != nil {
+			sources = append(sources, source)
+		} else {
+			rest = append(rest, ip)
+		}
+	}
+	return rest, sources, nil
+}
+
 func toCidrList(ips StringList) ([]*router.GeoIP, error) {
 	var geoipList []*router.GeoIP
 	var customCidrs []*router.CIDR
@@ -423,6 +519,16 @@ func toCidrList(ips StringList) ([]*router.GeoIP, error) {
 		}
 		if isExtDatFile != 0 {
 			kv := strings.Split(ip[isExtDatFile:], ":")
+			if len(kv) == 1 {
+				cidrs, err := (&router.RuleSource{File: kv[0]}).LoadCIDRs()
+				if err != nil {
+					return nil, newError("failed to load external IP list: ", kv[0]).Base(err)
+				}
+				geoipList = append(geoipList, &router.GeoIP{
+					Cidr: cidrs,
+				})
+				continue
+			}
 			if len(kv) != 2 {
 				return nil, newError("invalid external resource: ", ip)
 			}
@@ -458,20 +564,206 @@ func toCidrList(ips StringList) ([]*router.GeoIP, error) {
 	return geoipList, nil
 }
 
-func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+func parseWeekday(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) >= 3 {
+		for i, name := range weekdayNames {
+			if strings.HasPrefix(s, name) {
+				return i, nil
+			}
+		}
+	}
+	return 0, newError("invalid weekday: ", s)
+}
+
+// parseClock parses a time of day in the form of "15" or "15:04" into minutes since midnight.
+func parseClock(s string) (uint32, error) {
+	s = strings.TrimSpace(s)
+	if s == "24" || s == "24:00" {
+		return 24 * 60, nil
+	}
+	if !strings.Contains(s, ":") {
+		hour, err := strconv.ParseUint(s, 10, 32)
+		if err != nil || hour > 23 {
+			return 0, newError("invalid time of day: ", s)
+		}
+		return uint32(hour) * 60, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, newError("invalid time of day: ", s).Base(err)
+	}
+	return uint32(t.Hour()*60 + t.Minute()), nil
+}
+
+// LevelList is a list of user level ranges, like 1, "2-5", "0,7-9" or an
+// array of them.
+type LevelList struct {
+	Range []*router.LevelRange
+}
+
+func parseLevelRange(s string) (*router.LevelRange, error) {
+	parts := strings.SplitN(s, "-", 2)
+	from, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 32)
+	if err != nil {
+		return nil, newError("invalid user level: ", s).Base(err)
+	}
+	to := from
+	if len(parts) == 2 {
+		to, err = strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 32)
+		if err != nil {
+			return nil, newError("invalid user level: ", s).Base(err)
+		}
+	}
+	if from > to {
+		return nil, newError("invalid user level range: ", s)
+	}
+	return &router.LevelRange{From: uint32(from), To: uint32(to)}, nil
+}
+
+// UnmarshalJSON implements encoding/json.Unmarshaler.UnmarshalJSON
+func (list *LevelList) UnmarshalJSON(data []byte) error {
+	var number uint32
+	if err := json.Unmarshal(data, &number); err == nil {
+		list.Range = append(list.Range, &router.LevelRange{From: number, To: number})
+		return nil
+	}
+	var listStr string
+	if err := json.Unmarshal(data, &listStr); err == nil {
+		for _, rangeStr := range strings.Split(listStr, ",") {
+			if trimmed := strings.TrimSpace(rangeStr); len(trimmed) > 0 {
+				r, err := parseLevelRange(trimmed)
+				if err != nil {
+					return err
+				}
+				list.Range = append(list.Range, r)
+			}
+		}
+		return nil
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return newError("invalid user level list: ", string(data)).Base(err)
+	}
+	for _, item := range items {
+		if err := list.UnmarshalJSON(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TimeWindowConfig is the JSON config of a routing time window.
+type TimeWindowConfig struct {
+	Weekdays  StringList `json:"weekdays"`
+	Hours     string     `json:"hours"`
+	Timezone  string     `json:"timezone"`
+	StartDate string     `json:"startDate"`
+	EndDate   string     `json:"endDate"`
+}
+
+// Build implements Buildable.
+func (c *TimeWindowConfig) Build() (*router.TimeWindow, error) {
+	window := &router.TimeWindow{
+		Timezone: c.Timezone,
+	}
+
+	location := time.Local
+	if len(c.Timezone) > 0 {
+		loc, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			return nil, newError("invalid timezone: ", c.Timezone).Base(err)
+		}
+		location = loc
+	}
+
+	for _, day := range c.Weekdays {
+		days := strings.SplitN(day, "-", 2)
+		from, err := parseWeekday(days[0])
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if len(days) == 2 {
+			if to, err = parseWeekday(days[1]); err != nil {
+				return nil, err
+			}
+		}
+		// Ranges may wrap around the week, e.g. "sat-mon".
+		for d := from; ; d = (d + 1) % 7 {
+			window.Weekdays |= 1 << uint(d)
+			if d == to {
+				break
+			}
+		}
+	}
+
+	if len(c.Hours) > 0 {
+		parts := strings.Split(c.Hours, "-")
+		if len(parts) != 2 {
+			return nil, newError("invalid hours: ", c.Hours)
+		}
+		start, err := parseClock(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if start >= 24*60 {
+			return nil, newError("invalid hours: ", c.Hours)
+		}
+		if end == 24*60 {
+			end = 0
+		}
+		window.StartMinute = start
+		window.EndMinute = end
+	}
+
+	if len(c.StartDate) > 0 {
+		date, err := time.ParseInLocation("2006-01-02", c.StartDate, location)
+		if err != nil {
+			return nil, newError("invalid start date: ", c.StartDate).Base(err)
+		}
+		window.NotBefore = date.Unix()
+	}
+	if len(c.EndDate) > 0 {
+		date, err := time.ParseInLocation("2006-01-02", c.EndDate, location)
+		if err != nil {
+			return nil, newError("invalid end date: ", c.EndDate).Base(err)
+		}
+		// The end date is inclusive.
+		window.NotAfter = date.AddDate(0, 0, 1).Unix() - 1
+	}
+	if window.NotBefore != 0 && window.NotAfter != 0 && window.NotBefore > window.NotAfter {
+		return nil, newError("start date is after end date: ", c.StartDate, " > ", c.EndDate)
+	}
+
+	return window, nil
+}
+
+func parseFieldRule(msg json.RawMessage, reloadable bool) (*router.RoutingRule, error) {
 	type RawFieldRule struct {
 		RouterRule
-		Domain     *StringList  `json:"domain"`
-		Domains    *StringList  `json:"domains"`
-		IP         *StringList  `json:"ip"`
-		Port       *PortList    `json:"port"`
-		Network    *NetworkList `json:"network"`
-		SourceIP   *StringList  `json:"source"`
-		SourcePort *PortList    `json:"sourcePort"`
-		User       *StringList  `json:"user"`
-		InboundTag *StringList  `json:"inboundTag"`
-		Protocols  *StringList  `json:"protocol"`
-		Attributes string       `json:"attrs"`
+		Domain         *StringList         `json:"domain"`
+		Domains        *StringList         `json:"domains"`
+		IP             *StringList         `json:"ip"`
+		Port           *PortList           `json:"port"`
+		Network        *NetworkList        `json:"network"`
+		SourceIP       *StringList         `json:"source"`
+		SourcePort     *PortList           `json:"sourcePort"`
+		User           *StringList         `json:"user"`
+		UserLevel      *LevelList          `json:"userLevel"`
+		UserTag        *StringList         `json:"userTag"`
+		InboundTag     *StringList         `json:"inboundTag"`
+		Protocols      *StringList         `json:"protocol"`
+		Attributes     string              `json:"attrs"`
+		ALPN           *StringList         `json:"alpn"`
+		TLSFingerprint *StringList         `json:"tlsFingerprint"`
+		Time           []*TimeWindowConfig `json:"time"`
 	}
 	rawFieldRule := new(RawFieldRule)
 	err := json.Unmarshal(msg, rawFieldRule)
@@ -497,32 +789,39 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 		rule.DomainMatcher = rawFieldRule.DomainMatcher
 	}
 
+	var domains []string
 	if rawFieldRule.Domain != nil {
-		for _, domain := range *rawFieldRule.Domain {
-			rules, err := parseDomainRule(domain)
-			if err != nil {
-				return nil, newError("failed to parse domain rule: ", domain).Base(err)
-			}
-			rule.Domain = append(rule.Domain, rules...)
-		}
+		domains = append(domains, *rawFieldRule.Domain...)
 	}
-
 	if rawFieldRule.Domains != nil {
-		for _, domain := range *rawFieldRule.Domains {
+		domains = append(domains, *rawFieldRule.Domains...)
+	}
+	if len(domains) > 0 {
+		domains, sources, err := splitDomainSources(domains, reloadable)
+		if err != nil {
+			return nil, err
+		}
+		for _, domain := range domains {
 			rules, err := parseDomainRule(domain)
 			if err != nil {
 				return nil, newError("failed to parse domain rule: ", domain).Base(err)
 			}
 			rule.Domain = append(rule.Domain, rules...)
 		}
+		rule.DomainSource = sources
 	}
 
 	if rawFieldRule.IP != nil {
-		geoipList, err := toCidrList(*rawFieldRule.IP)
+		ips, sources, err := splitIPSources(*rawFieldRule.IP, reloadable)
+		if err != nil {
+			return nil, err
+		}
+		geoipList, err := toCidrList(ips)
 		if err != nil {
 			return nil, err
 		}
 		rule.Geoip = geoipList
+		rule.GeoipSource = sources
 	}
 
 	if rawFieldRule.Port != nil {
@@ -551,6 +850,14 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 		}
 	}
 
+	if rawFieldRule.UserLevel != nil {
+		rule.UserLevel = rawFieldRule.UserLevel.Range
+	}
+
+	if rawFieldRule.UserTag != nil {
+		rule.UserTag = *rawFieldRule.UserTag
+	}
+
 	if rawFieldRule.InboundTag != nil {
 		for _, s := range *rawFieldRule.InboundTag {
 			rule.InboundTag = append(rule.InboundTag, s)
@@ -567,17 +874,37 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 		rule.Attributes = rawFieldRule.Attributes
 	}
 
+	if rawFieldRule.ALPN != nil {
+		rule.Alpn = *rawFieldRule.ALPN
+	}
+
+	if rawFieldRule.TLSFingerprint != nil {
+		rule.TlsFingerprint = *rawFieldRule.TLSFingerprint
+	}
+
+	for _, t := range rawFieldRule.Time {
+		window, err := t.Build()
+		if err != nil {
+			return nil, newError("invalid time window").Base(err)
+		}
+		rule.TimeWindow = append(rule.TimeWindow, window)
+	}
+
 	return rule, nil
 }
 
 func ParseRule(msg json.RawMessage) (*router.RoutingRule, error) {
+	return parseRule(msg, false)
+}
+
+func parseRule(msg json.RawMessage, reloadable bool) (*router.RoutingRule, error) {
 	rawRule := new(RouterRule)
 	err := json.Unmarshal(msg, rawRule)
 	if err != nil {
 		return nil, newError("invalid router rule").Base(err)
 	}
 	if rawRule.Type == "field" {
-		fieldrule, err := parseFieldRule(msg)
+		fieldrule, err := parseFieldRule(msg, reloadable)
 		if err != nil {
 			return nil, newError("invalid field rule").Base(err)
 		}
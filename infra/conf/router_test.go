@@ -260,5 +260,98 @@ func TestRouterConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			Input: `{
+				"balancers": [
+					{
+						"tag": "evening",
+						"selector": ["direct"]
+					}
+				],
+				"rules": [
+					{
+						"type": "field",
+						"network": "tcp",
+						"time": [
+							{
+								"weekdays": "mon-fri",
+								"hours": "18:00-24",
+								"timezone": "UTC"
+							},
+							{
+								"weekdays": ["sat", "Sunday"],
+								"hours": "22-6:30",
+								"timezone": "UTC",
+								"startDate": "2021-12-24",
+								"endDate": "2021-12-31"
+							}
+						],
+						"balancerTag": "evening"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				BalancingRule: []*router.BalancingRule{
+					{
+						Tag:              "evening",
+						OutboundSelector: []string{"direct"},
+					},
+				},
+				Rule: []*router.RoutingRule{
+					{
+						Networks: []net.Network{net.Network_TCP},
+						TimeWindow: []*router.TimeWindow{
+							{
+								Weekdays:    0x3e,
+								StartMinute: 18 * 60,
+								Timezone:    "UTC",
+							},
+							{
+								Weekdays:    0x41,
+								StartMinute: 22 * 60,
+								EndMinute:   6*60 + 30,
+								Timezone:    "UTC",
+								NotBefore:   1640304000,
+								NotAfter:    1640995199,
+							},
+						},
+						TargetTag: &router.RoutingRule_BalancingTag{
+							BalancingTag: "evening",
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"rules": [
+					{
+						"type": "field",
+						"userLevel": [0, "5-9,12"],
+						"userTag": "premium,gold",
+						"alpn": ["h2"],
+						"outboundTag": "h2"
+					}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						UserLevel: []*router.LevelRange{
+							{From: 0, To: 0},
+							{From: 5, To: 9},
+							{From: 12, To: 12},
+						},
+						UserTag: []string{"premium", "gold"},
+						Alpn:    []string{"h2"},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "h2",
+						},
+					},
+				},
+			},
+		},
 	})
 }
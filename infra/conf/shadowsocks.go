@@ -34,10 +34,13 @@ func cipherFromString(c string) shadowsocks.CipherType {
 }
 
 type ShadowsocksUserConfig struct {
-	Cipher   string `json:"method"`
-	Password string `json:"password"`
-	Level    byte   `json:"level"`
-	Email    string `json:"email"`
+	Cipher   string   `json:"method"`
+	Password string   `json:"password"`
+	Level    byte     `json:"level"`
+	Email    string   `json:"email"`
+	Tags     []string `json:"tags"`
+	Quota    uint64   `json:"quota"`
+	Expiry   int64    `json:"expiry"`
 }
 
 type ShadowsocksServerConfig struct {
@@ -68,6 +71,9 @@ func (v *ShadowsocksServerConfig) Build() (proto.Message, error) {
 			config.Users = append(config.Users, &protocol.User{
 				Email:   user.Email,
 				Level:   uint32(user.Level),
+				Tags:    user.Tags,
+				Quota:   user.Quota,
+				Expiry:  user.Expiry,
 				Account: serial.ToTypedMessage(account),
 			})
 		}
@@ -32,5 +32,29 @@ func TestShadowsocksServerConfigParsing(t *testing.T) {
 				Network: []net.Network{net.Network_TCP},
 			},
 		},
+		{
+			Input: `{
+				"clients": [{
+					"method": "aes-256-gcm",
+					"password": "xray-password",
+					"email": "love@example.com",
+					"quota": 1073741824,
+					"expiry": 1893456000
+				}]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &shadowsocks.ServerConfig{
+				Users: []*protocol.User{{
+					Email:  "love@example.com",
+					Quota:  1073741824,
+					Expiry: 1893456000,
+					Account: serial.ToTypedMessage(&shadowsocks.Account{
+						CipherType: shadowsocks.CipherType_AES_256_GCM,
+						Password:   "xray-password",
+					}),
+				}},
+				Network: []net.Network{net.Network_TCP},
+			},
+		},
 	})
 }
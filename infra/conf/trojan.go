@@ -95,10 +95,13 @@ type TrojanInboundFallback struct {
 
 // TrojanUserConfig is user configuration
 type TrojanUserConfig struct {
-	Password string `json:"password"`
-	Level    byte   `json:"level"`
-	Email    string `json:"email"`
-	Flow     string `json:"flow"`
+	Password string   `json:"password"`
+	Level    byte     `json:"level"`
+	Email    string   `json:"email"`
+	Flow     string   `json:"flow"`
+	Tags     []string `json:"tags"`
+	Quota    uint64   `json:"quota"`
+	Expiry   int64    `json:"expiry"`
 }
 
 // TrojanServerConfig is Inbound configuration
@@ -129,6 +132,9 @@ func (c *TrojanServerConfig) Build() (proto.Message, error) {
 
 		user.Email = rawUser.Email
 		user.Level = uint32(rawUser.Level)
+		user.Tags = rawUser.Tags
+		user.Quota = rawUser.Quota
+		user.Expiry = rawUser.Expiry
 		user.Account = serial.ToTypedMessage(account)
 		config.Users[idx] = user
 	}
@@ -28,6 +28,7 @@ var (
 		"trojan":        func() interface{} { return new(TrojanServerConfig) },
 		"tunnel":        func() interface{} { return new(TunnelConfig) },
 		"mtproto":       func() interface{} { return new(MTProtoServerConfig) },
+		"dns":           func() interface{} { return new(DNSInboundConfig) },
 	}, "protocol", "settings")
 
 	outboundConfigLoader = NewJSONConfigLoader(ConfigCreatorCache{
@@ -78,6 +79,8 @@ func (c *SniffingConfig) Build() (*proxyman.SniffingConfig, error) {
 				p = append(p, "http")
 			case "tls", "https", "ssl":
 				p = append(p, "tls")
+			case "quic":
+				p = append(p, "quic")
 			case "fakedns":
 				p = append(p, "fakedns")
 			default:
@@ -376,11 +379,19 @@ func (c *OutboundDetourConfig) Build() (*core.OutboundHandlerConfig, error) {
 	}, nil
 }
 
-type StatsConfig struct{}
+type StatsConfig struct {
+	PersistFile     string   `json:"persistFile"`
+	PersistPatterns []string `json:"persistPatterns"`
+	PersistInterval uint32   `json:"persistInterval"`
+}
 
 // Build implements Buildable.
 func (c *StatsConfig) Build() (*stats.Config, error) {
-	return &stats.Config{}, nil
+	return &stats.Config{
+		PersistFile:     c.PersistFile,
+		PersistPatterns: c.PersistPatterns,
+		PersistInterval: c.PersistInterval,
+	}, nil
 }
 
 type Config struct {
@@ -416,6 +427,8 @@ type Config struct {
 	Stats           *StatsConfig           `json:"stats"`
 	Reverse         *ReverseConfig         `json:"reverse"`
 	FakeDNS         *FakeDNSConfig         `json:"fakeDns"`
+	Metrics         *MetricsConfig         `json:"metrics"`
+	Quota           *QuotaConfig           `json:"quota"`
 }
 
 func (c *Config) findInboundTag(tag string) int {
@@ -473,6 +486,14 @@ func (c *Config) Override(o *Config, fn string) {
 		c.FakeDNS = o.FakeDNS
 	}
 
+	if o.Metrics != nil {
+		c.Metrics = o.Metrics
+	}
+
+	if o.Quota != nil {
+		c.Quota = o.Quota
+	}
+
 	// deprecated attrs... keep them for now
 	if o.InboundConfig != nil {
 		c.InboundConfig = o.InboundConfig
@@ -548,10 +569,15 @@ func (c *Config) Build() (*core.Config, error) {
 		return nil, err
 	}
 
+	inboundConfig := &proxyman.InboundConfig{}
+	if c.Quota != nil {
+		inboundConfig.Quota = c.Quota.Build()
+	}
+
 	config := &core.Config{
 		App: []*serial.TypedMessage{
 			serial.ToTypedMessage(&dispatcher.Config{}),
-			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(inboundConfig),
 			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
 		},
 	}
@@ -574,7 +600,11 @@ func (c *Config) Build() (*core.Config, error) {
 
 	var logConfMsg *serial.TypedMessage
 	if c.LogConfig != nil {
-		logConfMsg = serial.ToTypedMessage(c.LogConfig.Build())
+		logConf, err := c.LogConfig.Build()
+		if err != nil {
+			return nil, err
+		}
+		logConfMsg = serial.ToTypedMessage(logConf)
 	} else {
 		logConfMsg = serial.ToTypedMessage(DefaultLogConfig())
 	}
@@ -622,6 +652,14 @@ func (c *Config) Build() (*core.Config, error) {
 		config.App = append(config.App, serial.ToTypedMessage(r))
 	}
 
+	if c.Metrics != nil {
+		r, err := c.Metrics.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.App = append(config.App, serial.ToTypedMessage(r))
+	}
+
 	var inbounds []InboundDetourConfig
 
 	if c.InboundConfig != nil {
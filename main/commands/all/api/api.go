@@ -12,9 +12,14 @@ var CmdAPI = &base.Command{
 `,
 	Commands: []*base.Command{
 		cmdRestartLogger,
+		cmdSetLogLevel,
 		cmdGetStats,
 		cmdQueryStats,
 		cmdSysStats,
+		cmdOnlineUsers,
+		cmdSnapshotStats,
+		cmdListConnections,
+		cmdCloseConnections,
 		cmdAddInbounds,
 		cmdAddOutbounds,
 		cmdRemoveInbounds,
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"io"
+
+	connService "github.com/xtls/xray-core/app/dispatcher/command"
+	"github.com/xtls/xray-core/main/commands/base"
+)
+
+var cmdListConnections = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} api conns [--server=127.0.0.1:8080] [-email ''] [-inbound ''] [-watch]",
+	Short:       "List connections",
+	Long: `
+List connections dispatched by Xray, or watch them being opened, routed 
+and closed.
+Arguments:
+	-s, -server 
+		The API server address. Default 127.0.0.1:8080
+	-t, -timeout
+		Timeout seconds to call API. Default 3
+	-email
+		List connections of the user only.
+	-inbound
+		List connections of the inbound only.
+	-watch
+		Print connection events until interrupted.
+Example:
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -email "user@example.com"
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -watch
+`,
+	Run: executeListConnections,
+}
+
+func executeListConnections(cmd *base.Command, args []string) {
+	setSharedFlags(cmd)
+	email := cmd.Flag.String("email", "", "")
+	inbound := cmd.Flag.String("inbound", "", "")
+	watch := cmd.Flag.Bool("watch", false, "")
+	cmd.Flag.Parse(args)
+
+	conn, ctx, close := dialAPIServer()
+	defer close()
+
+	client := connService.NewConnectionServiceClient(conn)
+	if *watch {
+		watchConnections(client, *email, *inbound)
+		return
+	}
+	r := &connService.ListConnectionsRequest{
+		Email:      *email,
+		InboundTag: *inbound,
+	}
+	resp, err := client.ListConnections(ctx, r)
+	if err != nil {
+		base.Fatalf("failed to list connections: %s", err)
+	}
+	showResponese(resp)
+}
+
+func watchConnections(client connService.ConnectionServiceClient, email string, inbound string) {
+	// The stream lasts until interrupted, so it is not bound to the timeout.
+	stream, err := client.WatchConnections(context.Background(), &connService.WatchConnectionsRequest{})
+	if err != nil {
+		base.Fatalf("failed to watch connections: %s", err)
+	}
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			base.Fatalf("failed to watch connections: %s", err)
+		}
+		c := event.GetConnection()
+		if email != "" && c.GetEmail() != email {
+			continue
+		}
+		if inbound != "" && c.GetInboundTag() != inbound {
+			continue
+		}
+		showResponese(event)
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	connService "github.com/xtls/xray-core/app/dispatcher/command"
+	"github.com/xtls/xray-core/main/commands/base"
+)
+
+var cmdCloseConnections = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} api connsclose [--server=127.0.0.1:8080] <-id 1|-email ''>",
+	Short:       "Close connections",
+	Long: `
+Close a connection by its ID, or all connections of a user.
+Arguments:
+	-s, -server 
+		The API server address. Default 127.0.0.1:8080
+	-t, -timeout
+		Timeout seconds to call API. Default 3
+	-id
+		ID of the connection to close, as listed by "api conns".
+	-email
+		Close all connections of the user.
+Example:
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -id 1234567
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -email "user@example.com"
+`,
+	Run: executeCloseConnections,
+}
+
+func executeCloseConnections(cmd *base.Command, args []string) {
+	setSharedFlags(cmd)
+	id := cmd.Flag.Uint("id", 0, "")
+	email := cmd.Flag.String("email", "", "")
+	cmd.Flag.Parse(args)
+
+	if (*id == 0) == (*email == "") {
+		base.Fatalf("specify either -id or -email")
+	}
+
+	conn, ctx, close := dialAPIServer()
+	defer close()
+
+	client := connService.NewConnectionServiceClient(conn)
+	if *id != 0 {
+		resp, err := client.CloseConnection(ctx, &connService.CloseConnectionRequest{Id: uint32(*id)})
+		if err != nil {
+			base.Fatalf("failed to close connection: %s", err)
+		}
+		showResponese(resp)
+		return
+	}
+	resp, err := client.CloseConnectionsByUser(ctx, &connService.CloseConnectionsByUserRequest{Email: *email})
+	if err != nil {
+		base.Fatalf("failed to close connections: %s", err)
+	}
+	showResponese(resp)
+}
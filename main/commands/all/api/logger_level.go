@@ -0,0 +1,101 @@
+package api
+
+import (
+	"strings"
+
+	logService "github.com/xtls/xray-core/app/log/command"
+	clog "github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/main/commands/base"
+)
+
+var cmdSetLogLevel = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} api loglevel [--server=127.0.0.1:8080] [-level warning] [-reset] [module=level]...",
+	Short:       "Set the log level",
+	Long: `
+Change the severity of the error log of Xray, for all modules or for some of 
+them, without restarting it. A module is the path of a package, like 
+"transport/internet/tunnel", and applies to its sub packages as well. The 
+most specific module of a message takes precedence.
+
+Levels are "debug", "info", "warning" and "error". Level "default" removes 
+the level of the module, so the module follows the level of the error log.
+
+Prints the current levels after the change, or without change if no 
+argument is given.
+Arguments:
+	-s, -server 
+		The API server address. Default 127.0.0.1:8080
+	-t, -timeout
+		Timeout seconds to call API. Default 3
+	-level
+		The level of the error log.
+	-reset
+		Remove levels of all modules before applying the others.
+Example:
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 transport/internet/tunnel=debug
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -level warning -reset
+`,
+	Run: executeSetLogLevel,
+}
+
+func executeSetLogLevel(cmd *base.Command, args []string) {
+	setSharedFlags(cmd)
+	level := cmd.Flag.String("level", "", "")
+	reset := cmd.Flag.Bool("reset", false, "")
+	cmd.Flag.Parse(args)
+
+	r := &logService.SetLogLevelRequest{
+		ResetModules: *reset,
+	}
+	if *level != "" {
+		severity, ok := parseSeverity(*level)
+		if !ok || severity == clog.Severity_Unknown {
+			base.Fatalf("invalid level: %s", *level)
+		}
+		r.Level = severity
+	}
+	for _, arg := range cmd.Flag.Args() {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			base.Fatalf("invalid module level: %s", arg)
+		}
+		severity, ok := parseSeverity(parts[1])
+		if !ok {
+			base.Fatalf("invalid level of module %s: %s", parts[0], parts[1])
+		}
+		r.Modules = append(r.Modules, &logService.ModuleLevel{
+			Module: parts[0],
+			Level:  severity,
+		})
+	}
+
+	conn, ctx, close := dialAPIServer()
+	defer close()
+
+	client := logService.NewLoggerServiceClient(conn)
+	resp, err := client.SetLogLevel(ctx, r)
+	if err != nil {
+		base.Fatalf("failed to set log level: %s", err)
+	}
+	showResponese(resp)
+}
+
+// parseSeverity parses the level of the error log. "default" is
+// Severity_Unknown.
+func parseSeverity(level string) (clog.Severity, bool) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return clog.Severity_Debug, true
+	case "info":
+		return clog.Severity_Info, true
+	case "warning":
+		return clog.Severity_Warning, true
+	case "error":
+		return clog.Severity_Error, true
+	case "default":
+		return clog.Severity_Unknown, true
+	default:
+		return clog.Severity_Unknown, false
+	}
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	statsService "github.com/xtls/xray-core/app/stats/command"
+	"github.com/xtls/xray-core/main/commands/base"
+)
+
+var cmdOnlineUsers = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} api statsonline [--server=127.0.0.1:8080]",
+	Short:       "Get online users",
+	Long: `
+Get users with active connections, and their source IPs, from Xray.
+Arguments:
+	-s, -server 
+		The API server address. Default 127.0.0.1:8080
+	-t, -timeout
+		Timeout seconds to call API. Default 3
+`,
+	Run: executeOnlineUsers,
+}
+
+func executeOnlineUsers(cmd *base.Command, args []string) {
+	setSharedFlags(cmd)
+	cmd.Flag.Parse(args)
+
+	conn, ctx, close := dialAPIServer()
+	defer close()
+
+	client := statsService.NewStatsServiceClient(conn)
+	r := &statsService.GetOnlineUsersRequest{}
+	resp, err := client.GetOnlineUsers(ctx, r)
+	if err != nil {
+		base.Fatalf("failed to get online users: %s", err)
+	}
+	showResponese(resp)
+}
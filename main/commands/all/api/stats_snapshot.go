@@ -0,0 +1,37 @@
+package api
+
+import (
+	statsService "github.com/xtls/xray-core/app/stats/command"
+	"github.com/xtls/xray-core/main/commands/base"
+)
+
+var cmdSnapshotStats = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} api statssnapshot [--server=127.0.0.1:8080]",
+	Short:       "Save statistics counters",
+	Long: `
+Save statistics counters of Xray to its persist file immediately.
+Arguments:
+	-s, -server 
+		The API server address. Default 127.0.0.1:8080
+	-t, -timeout
+		Timeout seconds to call API. Default 3
+`,
+	Run: executeSnapshotStats,
+}
+
+func executeSnapshotStats(cmd *base.Command, args []string) {
+	setSharedFlags(cmd)
+	cmd.Flag.Parse(args)
+
+	conn, ctx, close := dialAPIServer()
+	defer close()
+
+	client := statsService.NewStatsServiceClient(conn)
+	r := &statsService.SnapshotStatsRequest{}
+	resp, err := client.SnapshotStats(ctx, r)
+	if err != nil {
+		base.Fatalf("failed to snapshot stats: %s", err)
+	}
+	showResponese(resp)
+}
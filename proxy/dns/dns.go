@@ -9,6 +9,7 @@ import (
 
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
 	dns_proto "github.com/xtls/xray-core/common/protocol/dns"
 	"github.com/xtls/xray-core/common/session"
@@ -37,6 +38,7 @@ type ownLinkVerifier interface {
 
 type Handler struct {
 	client          dns.Client
+	messageClient   dns.MessageClient
 	ownLinkVerifier ownLinkVerifier
 	server          net.Destination
 }
@@ -46,6 +48,9 @@ func (h *Handler) Init(config *Config, dnsClient dns.Client) error {
 	if v, ok := dnsClient.(ownLinkVerifier); ok {
 		h.ownLinkVerifier = v
 	}
+	if c, ok := dnsClient.(dns.MessageClient); ok {
+		h.messageClient = c
+	}
 
 	if config.Server != nil {
 		h.server = config.Server.AsDestination()
@@ -81,6 +86,18 @@ func parseIPQuery(b []byte) (r bool, domain string, id uint16, qType dnsmessage.
 	return
 }
 
+func parseQuery(b []byte) *dnsmessage.Message {
+	query := new(dnsmessage.Message)
+	if err := query.Unpack(b); err != nil {
+		newError("unpack query").Base(err).WriteToLog()
+		return nil
+	}
+	if len(query.Questions) != 1 || query.Response {
+		return nil
+	}
+	return query
+}
+
 // Process implements proxy.Outbound.
 func (h *Handler) Process(ctx context.Context, link *transport.Link, d internet.Dialer) error {
 	outbound := session.OutboundFromContext(ctx)
@@ -142,6 +159,13 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, d internet.
 		}
 	}
 
+	var connWriterAccess sync.Mutex
+	forward := func(b *buf.Buffer) error {
+		connWriterAccess.Lock()
+		defer connWriterAccess.Unlock()
+		return connWriter.WriteMessage(b)
+	}
+
 	request := func() error {
 		defer conn.Close()
 
@@ -161,9 +185,15 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, d internet.
 					go h.handleIPQuery(id, qType, domain, writer)
 					continue
 				}
+				if h.messageClient != nil {
+					if query := parseQuery(b.Bytes()); query != nil {
+						go h.handleMessageQuery(ctx, query, b, writer, forward)
+						continue
+					}
+				}
 			}
 
-			if err := connWriter.WriteMessage(b); err != nil {
+			if err := forward(b); err != nil {
 				return err
 			}
 		}
@@ -264,12 +294,48 @@ func (h *Handler) handleIPQuery(id uint16, qType dnsmessage.Type, domain string,
 	}
 }
 
+// handleMessageQuery answers the query with the DNS client. The query in b is
+// forwarded to the original destination if the client is not able to answer it.
+func (h *Handler) handleMessageQuery(ctx context.Context, query *dnsmessage.Message, b *buf.Buffer, writer dns_proto.MessageWriter, forward func(*buf.Buffer) error) {
+	resp, err := h.messageClient.LookupMessage(ctx, query)
+	if errors.Cause(err) == dns.ErrMessageNotSupported {
+		if err := forward(b); err != nil {
+			newError("forward query").Base(err).WriteToLog()
+		}
+		return
+	}
+	b.Release()
+	if err != nil {
+		newError("message query").Base(err).WriteToLog()
+		resp = &dnsmessage.Message{
+			Header: dnsmessage.Header{
+				ID:                 query.ID,
+				Response:           true,
+				RecursionDesired:   query.RecursionDesired,
+				RecursionAvailable: true,
+				RCode:              dnsmessage.RCodeServerFailure,
+			},
+			Questions: query.Questions,
+		}
+	}
+
+	msg, err := dns_proto.PackMessage(resp)
+	if err != nil {
+		newError("pack message").Base(err).WriteToLog()
+		return
+	}
+	if err := writer.WriteMessage(msg); err != nil {
+		newError("write message answer").Base(err).WriteToLog()
+	}
+}
+
 type outboundConn struct {
 	access sync.Mutex
 	dialer func() (internet.Connection, error)
 
 	conn      net.Conn
 	connReady chan struct{}
+	closed    bool
 }
 
 func (c *outboundConn) dial() error {
@@ -285,6 +351,11 @@ func (c *outboundConn) dial() error {
 func (c *outboundConn) Write(b []byte) (int, error) {
 	c.access.Lock()
 
+	if c.closed {
+		c.access.Unlock()
+		return 0, io.ErrClosedPipe
+	}
+
 	if c.conn == nil {
 		if err := c.dial(); err != nil {
 			c.access.Unlock()
@@ -317,6 +388,11 @@ func (c *outboundConn) Read(b []byte) (int, error) {
 
 func (c *outboundConn) Close() error {
 	c.access.Lock()
+	if c.closed {
+		c.access.Unlock()
+		return nil
+	}
+	c.closed = true
 	close(c.connReady)
 	if c.conn != nil {
 		c.conn.Close()
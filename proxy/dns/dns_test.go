@@ -70,6 +70,11 @@ func (*staticHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 
 		case q.Name == "notexist.google.com." && q.Qtype == dns.TypeAAAA:
 			ans.MsgHdr.Rcode = dns.RcodeNameError
+
+		case q.Name == "google.com." && q.Qtype == dns.TypeTXT:
+			rr, err := dns.NewRR("google.com. IN TXT \"v=spf1 -all\"")
+			common.Must(err)
+			ans.Answer = append(ans.Answer, rr)
 		}
 	}
 	w.WriteMsg(ans)
@@ -368,3 +373,121 @@ func TestUDP2TCPDNSTunnel(t *testing.T) {
 		t.Error(r)
 	}
 }
+
+func newMessageQueryInstance(nameServer *net.Endpoint, target net.Port, serverPort net.Port) *core.Instance {
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dnsapp.Config{
+				NameServers: []*net.Endpoint{nameServer},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+					Address:  net.NewIPOrDomain(net.LocalHostIP),
+					Port:     uint32(target),
+					Networks: []net.Network{net.Network_UDP},
+				}),
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(serverPort),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&dns_proxy.Config{}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	return v
+}
+
+func queryTXT(t *testing.T, serverPort net.Port) {
+	t.Helper()
+
+	m1 := new(dns.Msg)
+	m1.Id = dns.Id()
+	m1.RecursionDesired = true
+	m1.Question = make([]dns.Question, 1)
+	m1.Question[0] = dns.Question{Name: "google.com.", Qtype: dns.TypeTXT, Qclass: dns.ClassINET}
+
+	c := new(dns.Client)
+	in, _, err := c.Exchange(m1, "127.0.0.1:"+strconv.Itoa(int(serverPort)))
+	common.Must(err)
+
+	if in.Id != m1.Id {
+		t.Error("unexpected ID: ", in.Id)
+	}
+	if len(in.Answer) != 1 {
+		t.Fatal("len(answer): ", len(in.Answer))
+	}
+	rr, ok := in.Answer[0].(*dns.TXT)
+	if !ok {
+		t.Fatal("not TXT record")
+	}
+	if r := cmp.Diff(rr.Txt, []string{"v=spf1 -all"}); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestUDPDNSMessageQuery(t *testing.T) {
+	port := udp.PickPort()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &staticHandler{},
+		UDPSize: 1200,
+	}
+	defer dnsServer.Shutdown()
+
+	go dnsServer.ListenAndServe()
+	time.Sleep(time.Second)
+
+	// No server listens at the original destination, so the query can only be
+	// answered by the DNS client.
+	serverPort := udp.PickPort()
+	v := newMessageQueryInstance(&net.Endpoint{
+		Network: net.Network_UDP,
+		Address: net.NewIPOrDomain(net.LocalHostIP),
+		Port:    uint32(port),
+	}, udp.PickPort(), serverPort)
+	defer v.Close()
+
+	queryTXT(t, serverPort)
+}
+
+func TestUDPDNSMessageQueryForward(t *testing.T) {
+	port := udp.PickPort()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &staticHandler{},
+		UDPSize: 1200,
+	}
+	defer dnsServer.Shutdown()
+
+	go dnsServer.ListenAndServe()
+	time.Sleep(time.Second)
+
+	// The local name server is not able to answer TXT queries, so they are
+	// forwarded to the original destination.
+	serverPort := udp.PickPort()
+	v := newMessageQueryInstance(&net.Endpoint{
+		Network: net.Network_UDP,
+		Address: net.NewIPOrDomain(net.DomainAddress("localhost")),
+	}, port, serverPort)
+	defer v.Close()
+
+	queryTXT(t, serverPort)
+}
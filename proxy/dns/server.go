@@ -0,0 +1,349 @@
+package dns
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	dns_proto "github.com/xtls/xray-core/common/protocol/dns"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+const (
+	// maxUDPSize is the size of UDP responses to queries without EDNS.
+	maxUDPSize = 512
+	// ednsUDPSize is the UDP payload size advertised in EDNS responses.
+	ednsUDPSize = 1232
+)
+
+// Server is an inbound handler answering DNS queries with the DNS client.
+type Server struct {
+	config        *ServerConfig
+	client        dns.Client
+	messageClient dns.MessageClient
+}
+
+// NewServer creates a new DNS server inbound handler.
+func NewServer(config *ServerConfig, client dns.Client) *Server {
+	s := &Server{
+		config: config,
+		client: client,
+	}
+	if c, ok := client.(dns.MessageClient); ok {
+		s.messageClient = c
+	}
+	return s
+}
+
+// Network implements proxy.Inbound.
+func (s *Server) Network() []net.Network {
+	if len(s.config.Networks) == 0 {
+		return []net.Network{net.Network_TCP, net.Network_UDP}
+	}
+	return s.config.Networks
+}
+
+// Process implements proxy.Inbound.
+func (s *Server) Process(ctx context.Context, network net.Network, conn internet.Connection, dispatcher routing.Dispatcher) error {
+	if network == net.Network_TCP && s.config.DohPath != "" {
+		return s.serveHTTP(ctx, conn)
+	}
+
+	var reader dns_proto.MessageReader
+	var writer dns_proto.MessageWriter
+	if network == net.Network_TCP {
+		reader = dns_proto.NewTCPReader(buf.NewReader(conn))
+		writer = &dns_proto.TCPWriter{
+			Writer: buf.NewWriter(conn),
+		}
+	} else {
+		reader = &dns_proto.UDPReader{
+			Reader: buf.NewPacketReader(conn),
+		}
+		writer = &dns_proto.UDPWriter{
+			Writer: buf.NewWriter(conn),
+		}
+	}
+
+	var writerAccess sync.Mutex
+	for {
+		b, err := reader.ReadMessage()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return newError("connection ends").Base(err)
+		}
+
+		go func() {
+			defer b.Release()
+			resp := s.answer(ctx, b.Bytes(), network == net.Network_UDP)
+			if resp == nil {
+				return
+			}
+			writerAccess.Lock()
+			defer writerAccess.Unlock()
+			if err := writer.WriteMessage(resp); err != nil {
+				newError("failed to write DNS response").Base(err).WriteToLog(session.ExportIDToError(ctx))
+			}
+		}()
+	}
+}
+
+// answer returns the response to the query in b, or nil if the query is
+// malformed.
+func (s *Server) answer(ctx context.Context, b []byte, udp bool) *buf.Buffer {
+	query := new(dnsmessage.Message)
+	if err := query.Unpack(b); err != nil {
+		newError("failed to parse DNS query").Base(err).WriteToLog(session.ExportIDToError(ctx))
+		return nil
+	}
+	if query.Response {
+		return nil
+	}
+
+	start := time.Now()
+	resp := s.lookup(ctx, query)
+
+	var source net.Destination
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		source = inbound.Source
+	}
+	var question interface{} = "<no question>"
+	if len(query.Questions) > 0 {
+		question = serialQuestion(query.Questions[0])
+	}
+	newError("DNS query from ", source, ": ", question, " -> ", resp.RCode, " with ", len(resp.Answers), " answers in ", time.Since(start)).AtInfo().WriteToLog(session.ExportIDToError(ctx))
+
+	size := maxUDPSize
+	for _, r := range query.Additionals {
+		if r.Header.Type == dnsmessage.TypeOPT {
+			if n := int(r.Header.Class); n > size {
+				size = n
+			}
+			var opt dnsmessage.ResourceHeader
+			common.Must(opt.SetEDNS0(ednsUDPSize, dnsmessage.RCodeSuccess, false))
+			resp.Additionals = append(resp.Additionals, dnsmessage.Resource{Header: opt, Body: &dnsmessage.OPTResource{}})
+			break
+		}
+	}
+
+	msg, err := dns_proto.PackMessage(resp)
+	if err != nil {
+		newError("failed to pack DNS response").Base(err).WriteToLog(session.ExportIDToError(ctx))
+		msg, err = dns_proto.PackMessage(newServerResponse(query, dnsmessage.RCodeServerFailure))
+		if err != nil {
+			return nil
+		}
+	}
+	if udp && int(msg.Len()) > size {
+		msg.Release()
+		resp.Truncated = true
+		resp.Answers, resp.Authorities = nil, nil
+		if msg, err = dns_proto.PackMessage(resp); err != nil {
+			return nil
+		}
+	}
+	return msg
+}
+
+// lookup answers the query with the DNS client. The response is always
+// returned, with an error code if the query fails.
+func (s *Server) lookup(ctx context.Context, query *dnsmessage.Message) *dnsmessage.Message {
+	if len(query.Questions) != 1 {
+		return newServerResponse(query, dnsmessage.RCodeFormatError)
+	}
+	q := query.Questions[0]
+
+	if s.messageClient != nil {
+		resp, err := s.messageClient.LookupMessage(ctx, query)
+		if err == nil {
+			return resp
+		}
+		newError("failed to answer ", serialQuestion(q)).Base(err).WriteToLog(session.ExportIDToError(ctx))
+		if errors.Cause(err) == dns.ErrMessageNotSupported {
+			return newServerResponse(query, dnsmessage.RCodeNotImplemented)
+		}
+		return newServerResponse(query, dnsmessage.RCodeServerFailure)
+	}
+
+	if q.Type != dnsmessage.TypeA && q.Type != dnsmessage.TypeAAAA {
+		return newServerResponse(query, dnsmessage.RCodeNotImplemented)
+	}
+	ips, err := s.client.LookupIP(q.Name.String(), dns.IPOption{
+		IPv4Enable: q.Type == dnsmessage.TypeA,
+		IPv6Enable: q.Type == dnsmessage.TypeAAAA,
+		FakeEnable: true,
+	})
+	rcode := dns.RCodeFromError(err)
+	if rcode == 0 && len(ips) == 0 && errors.Cause(err) != dns.ErrEmptyResponse {
+		newError("failed to answer ", serialQuestion(q)).Base(err).WriteToLog(session.ExportIDToError(ctx))
+		return newServerResponse(query, dnsmessage.RCodeServerFailure)
+	}
+
+	resp := newServerResponse(query, dnsmessage.RCode(rcode))
+	header := dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: dnsmessage.ClassINET, TTL: 600}
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil && q.Type == dnsmessage.TypeA {
+			r := &dnsmessage.AResource{}
+			copy(r.A[:], ip4)
+			resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: r})
+		} else if len(ip) == net.IPv6len && q.Type == dnsmessage.TypeAAAA {
+			r := &dnsmessage.AAAAResource{}
+			copy(r.AAAA[:], ip)
+			resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: r})
+		}
+	}
+	return resp
+}
+
+func serialQuestion(q dnsmessage.Question) string {
+	return q.Name.String() + " " + q.Type.String()
+}
+
+func newServerResponse(query *dnsmessage.Message, rcode dnsmessage.RCode) *dnsmessage.Message {
+	return &dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 query.ID,
+			Response:           true,
+			OpCode:             query.OpCode,
+			RecursionDesired:   query.RecursionDesired,
+			RecursionAvailable: true,
+			RCode:              rcode,
+		},
+		Questions: query.Questions,
+	}
+}
+
+// serveHTTP serves DNS over HTTPS on conn, with HTTP/1.1 or HTTP/2.
+func (s *Server) serveHTTP(ctx context.Context, conn net.Conn) error {
+	listener := newConnListener(conn)
+	server := &http.Server{
+		Handler: h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.serveDoH(ctx, w, r)
+		}), &http2.Server{}),
+		ReadHeaderTimeout: time.Second * 4,
+	}
+	if err := server.Serve(listener); err != nil && err != io.EOF {
+		return newError("connection ends").Base(err)
+	}
+	return nil
+}
+
+func (s *Server) serveDoH(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != s.config.DohPath {
+		http.NotFound(w, r)
+		return
+	}
+
+	var b []byte
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		b, err = base64.RawURLEncoding.DecodeString(r.URL.Query().Get("dns"))
+	case http.MethodPost:
+		if r.Header.Get("Content-Type") != "application/dns-message" {
+			http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+			return
+		}
+		b, err = io.ReadAll(io.LimitReader(r.Body, 65535))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil || len(b) == 0 {
+		http.Error(w, "invalid DNS query", http.StatusBadRequest)
+		return
+	}
+
+	resp := s.answer(ctx, b, false)
+	if resp == nil {
+		http.Error(w, "invalid DNS query", http.StatusBadRequest)
+		return
+	}
+	defer resp.Release()
+
+	w.Header().Set("Content-Type", "application/dns-message")
+	w.Header().Set("Content-Length", strconv.Itoa(int(resp.Len())))
+	w.Write(resp.Bytes())
+}
+
+// connListener is a net.Listener accepting a single connection. Accept blocks
+// after that until the connection is closed.
+type connListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+	addr  net.Addr
+}
+
+func newConnListener(conn net.Conn) *connListener {
+	l := &connListener{
+		conns: make(chan net.Conn, 1),
+		done:  make(chan struct{}),
+		addr:  conn.LocalAddr(),
+	}
+	l.conns <- &listenerConn{Conn: conn, listener: l}
+	return l
+}
+
+// Accept implements net.Listener.
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, io.EOF
+	}
+}
+
+// Close implements net.Listener.
+func (l *connListener) Close() error {
+	l.once.Do(func() {
+		close(l.done)
+	})
+	return nil
+}
+
+// Addr implements net.Listener.
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
+
+type listenerConn struct {
+	net.Conn
+	listener *connListener
+}
+
+func (c *listenerConn) Close() error {
+	c.listener.Close()
+	return c.Conn.Close()
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*ServerConfig)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		var s *Server
+		if err := core.RequireFeatures(ctx, func(client dns.Client) {
+			s = NewServer(config.(*ServerConfig), client)
+		}); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}))
+}
@@ -0,0 +1,239 @@
+package dns_test
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	"golang.org/x/net/http2"
+
+	"github.com/xtls/xray-core/app/dispatcher"
+	dnsapp "github.com/xtls/xray-core/app/dns"
+	"github.com/xtls/xray-core/app/policy"
+	"github.com/xtls/xray-core/app/proxyman"
+	"github.com/xtls/xray-core/app/router"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	feature_dns "github.com/xtls/xray-core/features/dns"
+	"github.com/xtls/xray-core/proxy/blackhole"
+	dns_proxy "github.com/xtls/xray-core/proxy/dns"
+	"github.com/xtls/xray-core/proxy/freedom"
+	"github.com/xtls/xray-core/testing/servers/tcp"
+	"github.com/xtls/xray-core/testing/servers/udp"
+)
+
+func startStaticDNSServer(t *testing.T) net.Port {
+	t.Helper()
+
+	port := udp.PickPort()
+	dnsServer := &dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &staticHandler{},
+		UDPSize: 1200,
+	}
+	go dnsServer.ListenAndServe()
+	t.Cleanup(func() {
+		dnsServer.Shutdown()
+	})
+	time.Sleep(time.Second)
+	return port
+}
+
+func newServerInstance(upstream net.Port, inbound *dns_proxy.ServerConfig, serverPort net.Port, rules ...*router.RoutingRule) *core.Instance {
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dnsapp.Config{
+				Tag: "dns",
+				NameServer: []*dnsapp.NameServer{
+					{
+						Address: &net.Endpoint{
+							Network: net.Network_UDP,
+							Address: net.NewIPOrDomain(net.LocalHostIP),
+							Port:    uint32(upstream),
+						},
+						Timeout: 500,
+					},
+				},
+				StaticHosts: []*dnsapp.Config_HostMapping{
+					{
+						Type:   dnsapp.DomainMatchingType_Full,
+						Domain: "hosts.test",
+						Ip:     [][]byte{{10, 0, 0, 1}},
+					},
+				},
+			}),
+			serial.ToTypedMessage(&router.Config{Rule: rules}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(inbound),
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(serverPort),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+			{
+				Tag:           "blocked",
+				ProxySettings: serial.ToTypedMessage(&blackhole.Config{}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	return v
+}
+
+func exchange(t *testing.T, network string, serverPort net.Port, name string, qtype uint16) *dns.Msg {
+	t.Helper()
+
+	m := new(dns.Msg)
+	m.SetQuestion(name, qtype)
+	c := &dns.Client{Net: network}
+	in, _, err := c.Exchange(m, "127.0.0.1:"+strconv.Itoa(int(serverPort)))
+	common.Must(err)
+	if in.Id != m.Id {
+		t.Error("unexpected ID: ", in.Id)
+	}
+	return in
+}
+
+func TestDNSServer(t *testing.T) {
+	upstream := startStaticDNSServer(t)
+	serverPort := tcp.PickPort()
+	v := newServerInstance(upstream, &dns_proxy.ServerConfig{}, serverPort)
+	defer v.Close()
+
+	for _, network := range []string{"udp", "tcp"} {
+		in := exchange(t, network, serverPort, "google.com.", dns.TypeA)
+		if len(in.Answer) != 1 || in.Answer[0].(*dns.A).A.String() != "8.8.8.8" {
+			t.Error(network, " unexpected A answer: ", in.Answer)
+		}
+
+		in = exchange(t, network, serverPort, "hosts.test.", dns.TypeA)
+		if len(in.Answer) != 1 || in.Answer[0].(*dns.A).A.String() != "10.0.0.1" {
+			t.Error(network, " unexpected hosts answer: ", in.Answer)
+		}
+
+		in = exchange(t, network, serverPort, "google.com.", dns.TypeTXT)
+		if len(in.Answer) != 1 {
+			t.Fatal(network, " unexpected TXT answer: ", in.Answer)
+		}
+		if r := cmp.Diff(in.Answer[0].(*dns.TXT).Txt, []string{"v=spf1 -all"}); r != "" {
+			t.Error(r)
+		}
+
+		in = exchange(t, network, serverPort, "notexist.google.com.", dns.TypeAAAA)
+		if in.Rcode != dns.RcodeNameError {
+			t.Error(network, " expect NXDOMAIN, but got ", dns.RcodeToString[in.Rcode])
+		}
+	}
+}
+
+func TestDNSServerDoH(t *testing.T) {
+	upstream := startStaticDNSServer(t)
+	serverPort := tcp.PickPort()
+	v := newServerInstance(upstream, &dns_proxy.ServerConfig{
+		Networks: []net.Network{net.Network_TCP},
+		DohPath:  "/dns-query",
+	}, serverPort)
+	defer v.Close()
+
+	m := new(dns.Msg)
+	m.SetQuestion("google.com.", dns.TypeA)
+	query, err := m.Pack()
+	common.Must(err)
+
+	url := "http://127.0.0.1:" + serverPort.String() + "/dns-query"
+	h2c := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+	requests := map[string]func() (*http.Response, error){
+		"GET": func() (*http.Response, error) {
+			return http.Get(url + "?dns=" + base64.RawURLEncoding.EncodeToString(query))
+		},
+		"POST": func() (*http.Response, error) {
+			return http.Post(url, "application/dns-message", bytes.NewReader(query))
+		},
+		"h2c POST": func() (*http.Response, error) {
+			return h2c.Post(url, "application/dns-message", bytes.NewReader(query))
+		},
+	}
+	for name, request := range requests {
+		resp, err := request()
+		common.Must(err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		common.Must(err)
+
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/dns-message" {
+			t.Fatal(name, " unexpected response: ", resp.Status, " ", resp.Header.Get("Content-Type"))
+		}
+		in := new(dns.Msg)
+		common.Must(in.Unpack(body))
+		if len(in.Answer) != 1 || in.Answer[0].(*dns.A).A.String() != "8.8.8.8" {
+			t.Error(name, " unexpected answer: ", in.Answer)
+		}
+	}
+
+	resp, err := http.Get("http://127.0.0.1:" + serverPort.String() + "/other")
+	common.Must(err)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Error("expect 404 for other paths, but got ", resp.Status)
+	}
+}
+
+func TestDNSServerRoutingBySource(t *testing.T) {
+	upstream := startStaticDNSServer(t)
+	serverPort := udp.PickPort()
+	// Queries of clients from 127.0.0.1 are blocked.
+	v := newServerInstance(upstream, &dns_proxy.ServerConfig{
+		Networks: []net.Network{net.Network_UDP},
+	}, serverPort, &router.RoutingRule{
+		TargetTag:  &router.RoutingRule_Tag{Tag: "blocked"},
+		InboundTag: []string{"dns"},
+		SourceGeoip: []*router.GeoIP{
+			{Cidr: []*router.CIDR{{Ip: []byte{127, 0, 0, 1}, Prefix: 32}}},
+		},
+	})
+	defer v.Close()
+
+	in := exchange(t, "udp", serverPort, "facebook.com.", dns.TypeA)
+	if in.Rcode != dns.RcodeServerFailure {
+		t.Error("expect SERVFAIL, but got ", dns.RcodeToString[in.Rcode], " ", in.Answer)
+	}
+
+	// Queries without a source are not affected.
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.Client)
+	ips, err := client.LookupIP("facebook.com", feature_dns.IPOption{IPv4Enable: true})
+	common.Must(err)
+	if r := cmp.Diff(ips, []net.IP{{9, 9, 9, 9}}); r != "" {
+		t.Error(r)
+	}
+}
@@ -120,6 +120,13 @@ func (s *Server) handleUDPPayload(ctx context.Context, conn internet.Connection,
 
 	var dest *net.Destination
 
+	releases := make(map[string]func())
+	defer func() {
+		for _, release := range releases {
+			release()
+		}
+	}()
+
 	reader := buf.NewPacketReader(conn)
 	for {
 		mpayload, err := reader.ReadMultiBuffer()
@@ -157,6 +164,16 @@ func (s *Server) handleUDPPayload(ctx context.Context, conn internet.Connection,
 				continue
 			}
 
+			if _, found := releases[request.User.Email]; !found {
+				release, err := policy.AcquireInboundConnection(s.policyManager, request.User, inbound.Source.Address, inbound.Source)
+				if err != nil {
+					newError("dropping UDP packet from: ", inbound.Source).Base(err).WriteToLog(session.ExportIDToError(ctx))
+					data.Release()
+					continue
+				}
+				releases[request.User.Email] = release
+			}
+
 			destination := request.Destination()
 
 			currentPacketCtx := ctx
@@ -210,6 +227,12 @@ func (s *Server) handleConnection(ctx context.Context, conn internet.Connection,
 	}
 	inbound.User = request.User
 
+	release, err := policy.AcquireInboundConnection(s.policyManager, request.User, inbound.Source.Address, conn.RemoteAddr())
+	if err != nil {
+		return newError("connection limit exceeded").Base(err).AtInfo()
+	}
+	defer release()
+
 	dest := request.Destination()
 	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
 		From:   conn.RemoteAddr(),
@@ -229,6 +229,13 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn internet
 		panic("no inbound metadata")
 	}
 	inbound.User = user
+
+	release, err := policy.AcquireInboundConnection(s.policyManager, user, inbound.Source.Address, conn.RemoteAddr())
+	if err != nil {
+		return newError("connection limit exceeded").Base(err).AtInfo()
+	}
+	defer release()
+
 	sessionPolicy = s.policyManager.ForLevel(user.Level)
 
 	if destination.Network == net.Network_UDP { // handle udp request
@@ -435,6 +435,12 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection i
 	}
 	inbound.User = request.User
 
+	release, err := policy.AcquireInboundConnection(h.policyManager, request.User, inbound.Source.Address, connection.RemoteAddr())
+	if err != nil {
+		return newError("connection limit exceeded").Base(err).AtInfo()
+	}
+	defer release()
+
 	account := request.User.Account.(*vless.MemoryAccount)
 
 	responseAddons := &encoding.Addons{
@@ -9,6 +9,7 @@ import (
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/route"
 	"github.com/xtls/xray-core/common/signal/done"
+	"github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/transport/internet"
 	"github.com/xtls/xray-core/transport/internet/tunnel/stack"
 	tundev "github.com/xtls/xray-core/transport/internet/tunnel/tun"
@@ -61,8 +62,11 @@ func Listen(ctx context.Context, address net.Address, port net.Port, streamSetti
 
 	err = setRouteTable(helper, tun, net.ParseIP(tunGW))
 	if err != nil {
+		l.stack.GetStack().Close()
+		l.Close()
 		return nil, err
 	}
+	stats.RegisterMetricsProvider(metricsName(tunName), l)
 	return l, nil
 }
 
@@ -77,8 +81,24 @@ type listener struct {
 	done        *done.Instance
 }
 
+// metricsName returns the name of the metrics provider of the TUN device.
+func metricsName(name string) string {
+	return "tun>>>" + name
+}
+
+// Metrics implements stats.MetricsProvider. Metrics of the stack are labeled
+// with the name of the TUN device.
+func (l *listener) Metrics() []stats.Metric {
+	metrics := l.stack.Metrics()
+	for i := range metrics {
+		metrics[i].Labels = map[string]string{"device": l.config.GetName()}
+	}
+	return metrics
+}
+
 func (l *listener) Close() error {
 	l.done.Close()
+	stats.UnregisterMetricsProvider(metricsName(l.config.GetName()))
 	err := l.tun.Close()
 	if err != nil {
 		return newError("Cannot close tun device").Base(err).AtWarning()
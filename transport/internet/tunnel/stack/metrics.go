@@ -0,0 +1,34 @@
+package stack
+
+import (
+	"github.com/xtls/xray-core/features/stats"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// Metrics implements stats.MetricsProvider.
+func (s *Stack) Metrics() []stats.Metric {
+	st := s.stack.Stats()
+	metric := func(name string, help string, counter *tcpip.StatCounter) stats.Metric {
+		return stats.Metric{
+			Name:  "xray_tun_" + name,
+			Help:  help,
+			Value: float64(counter.Value()),
+		}
+	}
+	established := metric("tcp_established_connections", "Number of TCP connections in ESTABLISHED or CLOSE-WAIT state.", st.TCP.CurrentEstablished)
+	established.Gauge = true
+	return []stats.Metric{
+		metric("ip_packets_received_total", "Number of IP packets received from the TUN device.", st.IP.PacketsReceived),
+		metric("ip_packets_sent_total", "Number of IP packets sent to the TUN device.", st.IP.PacketsSent),
+		metric("tcp_active_openings_total", "Number of TCP connections opened by the stack.", st.TCP.ActiveConnectionOpenings),
+		metric("tcp_passive_openings_total", "Number of TCP connections accepted by the stack.", st.TCP.PassiveConnectionOpenings),
+		metric("tcp_failed_attempts_total", "Number of failed TCP connection attempts.", st.TCP.FailedConnectionAttempts),
+		metric("tcp_resets_received_total", "Number of TCP resets received.", st.TCP.ResetsReceived),
+		established,
+		metric("udp_packets_received_total", "Number of UDP packets received.", st.UDP.PacketsReceived),
+		metric("udp_packets_sent_total", "Number of UDP packets sent.", st.UDP.PacketsSent),
+		metric("udp_receive_buffer_errors_total", "Number of UDP packets dropped for full receive buffers.", st.UDP.ReceiveBufferErrors),
+		metric("udp_unknown_port_errors_total", "Number of UDP packets to unknown ports.", st.UDP.UnknownPortErrors),
+	}
+}
@@ -51,6 +51,21 @@ func (v *Dispatcher) RemoveRay(dest net.Destination) {
 	}
 }
 
+// Close closes all connections of the dispatcher.
+func (v *Dispatcher) Close() error {
+	v.RLock()
+	conns := make([]*connEntry, 0, len(v.conns))
+	for _, conn := range v.conns {
+		conns = append(conns, conn)
+	}
+	v.RUnlock()
+
+	for _, conn := range conns {
+		conn.cancel()
+	}
+	return nil
+}
+
 func (v *Dispatcher) getInboundRay(ctx context.Context, dest net.Destination) *connEntry {
 	v.Lock()
 	defer v.Unlock()